@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/services"
+)
+
+// AdminAuthMiddleware 以 ADMIN_TOKEN 保護管理端點（未設定時拒絕所有請求）
+func AdminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminToken := os.Getenv("ADMIN_TOKEN")
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListDeadLettersHandler 列出最近處理失敗的事件
+func ListDeadLettersHandler(deadLetterService *services.DeadLetterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		letters, err := deadLetterService.ListRecent(limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"deadLetters": letters})
+	}
+}
+
+// ResolveDeadLetterHandler 標記失敗事件為已處理
+func ResolveDeadLetterHandler(deadLetterService *services.DeadLetterService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := deadLetterService.MarkResolved(c.Param("id")); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// MessageQuotaHandler 查詢本月推播額度（refresh=1 時向 LINE 重新同步）
+func MessageQuotaHandler(messagingService *services.MessagingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			quota *models.MessageQuota
+			err   error
+		)
+		if c.Query("refresh") == "1" {
+			quota, err = messagingService.RefreshQuota(c.Request.Context())
+		} else {
+			quota, err = messagingService.GetQuota(c.Request.Context())
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"quota": quota, "remaining": quota.Remaining()})
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
+)
+
+// 平板筆順練習頁面
+const strokePracticeURL = "https://hanziplay.com/characters/practice"
+
+// 快速回覆最多 13 個按鈕
+const maxQuickReplyItems = 13
+
+// 顯示單字詳細資料卡片
+func handleCharacterLookup(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, char string) error {
+	if char == "" {
+		return utils.NewUserInputError("請輸入要查的字，例如：查字 森")
+	}
+
+	// 簡體字、異體字先轉為繁體
+	var messages []linebot.SendingMessage
+	if converted, changed := utils.NormalizeToTraditional(char); changed {
+		messages = append(messages, linebot.NewTextMessage(fmt.Sprintf("🔄 已將「%s」轉為繁體「%s」", char, converted)))
+		char = converted
+	}
+
+	info, err := services.NewCharacterService(firebaseClient).LookupCharacter(char)
+	if err != nil {
+		return utils.NewUserInputError(fmt.Sprintf("🔍 查不到「%s」的資料", char))
+	}
+
+	state := getEventUserState(firebaseClient, event, userID)
+	introducedIn := ""
+	if state.PreferredPublisher != "" {
+		lesson, err := services.NewLessonService(firebaseClient).FindIntroducingLesson(state.PreferredPublisher, char)
+		if err != nil {
+			log.Printf("Error finding introducing lesson: %v", err)
+		} else if lesson == nil {
+			introducedIn = fmt.Sprintf("%s版課本沒有教到這個字", state.PreferredPublisher)
+		} else {
+			introducedIn = fmt.Sprintf("%s %d年級%s 第%d課", lesson.Publisher, lesson.Grade, semesterName(lesson.Semester), lesson.Order)
+			if lesson.Title != "" {
+				introducedIn += fmt.Sprintf("〈%s〉", lesson.Title)
+			}
+			if state.PreferredGrade > 0 {
+				learned := lesson.Grade < state.PreferredGrade ||
+					(lesson.Grade == state.PreferredGrade && lesson.Semester < state.PreferredSemester) ||
+					(lesson.Grade == state.PreferredGrade && lesson.Semester == state.PreferredSemester && lesson.Order <= state.PreferredLesson)
+				if learned {
+					introducedIn += "\n✅ 已學過"
+				} else {
+					introducedIn += "\n⏳ 還沒教到"
+				}
+			}
+		}
+	}
+
+	practiceURL := strokePracticeURL + "?char=" + url.QueryEscape(char)
+	messages = append(messages, utils.CreateCharacterDetailFlex(info, introducedIn, practiceURL))
+	return replyMessages(event, bot, messages...)
+}
+
+// 查詢結果中每個字的快速回覆，點選後顯示單字卡片
+func characterLookupQuickReply(chars []string) *linebot.QuickReplyItems {
+	var items []*linebot.QuickReplyButton
+	seen := make(map[string]bool)
+	for _, char := range chars {
+		if seen[char] || !utils.IsChineseCharacter([]rune(char)[0]) {
+			continue
+		}
+		seen[char] = true
+		data := url.Values{}
+		data.Set("action", "lookup")
+		data.Set("char", char)
+		items = append(items, &linebot.QuickReplyButton{
+			Action: linebot.NewPostbackAction(char, data.Encode(), "", "查字 "+char, "", ""),
+		})
+		if len(items) == maxQuickReplyItems {
+			break
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return &linebot.QuickReplyItems{Items: items}
+}
+
+func semesterName(semester int) string {
+	if semester == 2 {
+		return "下學期"
+	}
+	return "上學期"
+}
+
+// 找字結果每頁筆數（保留一個快速回覆按鈕給「下一頁」）
+const characterSearchPageSize = maxQuickReplyItems - 1
+
+// 依注音、部首、筆畫找字，page 從 1 開始
+func handleCharacterSearch(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, query string, page int) error {
+	criteria, learnedOnly, ok := utils.ParseCharacterSearch(query)
+	if !ok {
+		return utils.NewUserInputError("請輸入找字條件，例如：\n找字 ㄕㄣ\n找字 木部 5-8畫\n找字 ㄇㄨˋ 已學")
+	}
+	if page > 0 {
+		criteria.Page = page
+	}
+	criteria.PageSize = characterSearchPageSize
+
+	if learnedOnly {
+		state := getEventUserState(firebaseClient, event, userID)
+		if state.PreferredPublisher == "" || state.PreferredGrade == 0 || state.PreferredSemester == 0 || state.PreferredLesson == 0 {
+			return utils.NewUserInputError("🔎 只找已學過的字前，請先使用「查詢累積字詞」設定課程和課次")
+		}
+		learned, err := getCumulativeCharacters(firebaseClient, state.PreferredPublisher, state.PreferredGrade, state.PreferredSemester, state.PreferredLesson)
+		if err != nil {
+			return utils.NewTransientError("failed to get cumulative characters", err)
+		}
+		criteria.Within = learned
+	}
+
+	result, err := services.NewCharacterService(firebaseClient).SearchCharacters(criteria)
+	if err != nil {
+		return utils.NewTransientError("failed to search characters", err)
+	}
+	if result.Total == 0 {
+		return replyMessage(event, bot, "🔎 找不到符合條件的字，試試放寬條件")
+	}
+
+	totalPages := (result.Total + result.PageSize - 1) / result.PageSize
+	responseText := fmt.Sprintf("🔎 找到 %d 個字（第 %d/%d 頁）\n", result.Total, result.Page, totalPages)
+	var chars []string
+	for _, info := range result.Characters {
+		responseText += fmt.Sprintf("\n%s  %s  %s部  %d畫", info.Character, info.Phonetic, info.Radical, info.StrokeCount)
+		chars = append(chars, info.Character)
+	}
+	if len(result.Characters) == 0 {
+		responseText += "\n這一頁沒有更多結果了"
+	}
+
+	quickReply := characterLookupQuickReply(chars)
+	if result.Page < totalPages {
+		data := url.Values{}
+		data.Set("action", "search")
+		data.Set("q", query)
+		data.Set("page", strconv.Itoa(result.Page+1))
+		if quickReply == nil {
+			quickReply = &linebot.QuickReplyItems{}
+		}
+		quickReply.Items = append(quickReply.Items, &linebot.QuickReplyButton{
+			Action: linebot.NewPostbackAction("下一頁 ▶", data.Encode(), "", "下一頁", "", ""),
+		})
+	}
+	if quickReply == nil {
+		return replyMessage(event, bot, responseText)
+	}
+	responseText += "\n\n👇 點選字查看詳細資料"
+	return replyMessageWithQuickReply(event, bot, responseText, quickReply)
+}
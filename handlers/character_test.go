@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+func TestCharacterLookupQuickReply(t *testing.T) {
+	items := characterLookupQuickReply([]string{"森", "林", "森", "A", "，", "鳥"})
+	if items == nil || len(items.Items) != 3 {
+		t.Fatalf("quick reply items = %+v, want 森 林 鳥", items)
+	}
+	for i, want := range []string{"森", "林", "鳥"} {
+		action, ok := items.Items[i].Action.(*linebot.PostbackAction)
+		if !ok || action.Label != want || !strings.Contains(action.Data, "action=lookup") {
+			t.Errorf("item %d = %+v, want a lookup postback for %s", i, items.Items[i].Action, want)
+		}
+	}
+
+	if items := characterLookupQuickReply([]string{"A", "1"}); items != nil {
+		t.Errorf("non-Chinese input gave %d items, want none", len(items.Items))
+	}
+
+	many := strings.Split("一二三四五六七八九十山水火木金土日月天地", "")
+	if items := characterLookupQuickReply(many); len(items.Items) != maxQuickReplyItems {
+		t.Errorf("got %d items, want at most %d", len(items.Items), maxQuickReplyItems)
+	}
+}
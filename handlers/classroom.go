@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
+)
+
+// 班級報告統計的天數
+const classroomReportDays = 7
+
+// 班級報告最多查詢幾位成員的顯示名稱
+const maxClassroomNameLookups = 30
+
+// 群組中的模式閒置多久後結束，避免之後的聊天都被當成查詢或作答
+const groupModeIdleTimeout = 10 * time.Minute
+
+// 事件來自的 LINE 群組，非群組時回傳空字串
+func groupIDOf(event *linebot.Event) string {
+	if event.Source == nil || event.Source.Type != linebot.EventSourceTypeGroup {
+		return ""
+	}
+	return event.Source.GroupID
+}
+
+// 群組中的模式閒置過久時視為已結束（只改記憶體中的狀態）
+func expireIdleGroupMode(state *models.UserState, now time.Time) {
+	if state.GroupID == "" || state.Mode == "" {
+		return
+	}
+	if now.Sub(time.UnixMilli(state.UpdatedAt)) > groupModeIdleTimeout {
+		state.ClearMode()
+	}
+}
+
+// 群組已登記為班級時，讓這次訊息沿用班級的課程設定（只改記憶體中的狀態，不覆蓋用戶自己的偏好設定）
+func applyClassroom(firebaseClient *config.FirebaseClient, groupID string, state *models.UserState) {
+	if groupID == "" {
+		return
+	}
+	classroom, err := services.NewClassroomService(firebaseClient).GetClassroom(groupID)
+	if err != nil {
+		log.Printf("Error getting classroom: %v", err)
+		return
+	}
+	if classroom == nil {
+		return
+	}
+	state.PreferredPublisher = classroom.Publisher
+	state.PreferredGrade = classroom.Grade
+	state.PreferredSemester = classroom.Semester
+	state.PreferredLesson = classroom.Lesson
+}
+
+// 成員在班級群組中使用指令時，記錄為班級成員
+func recordClassroomMember(firebaseClient *config.FirebaseClient, event *linebot.Event, userID string) {
+	groupID := groupIDOf(event)
+	if groupID == "" {
+		return
+	}
+	classroomService := services.NewClassroomService(firebaseClient)
+	classroom, err := classroomService.GetClassroom(groupID)
+	if err != nil || classroom == nil {
+		return
+	}
+	if err := classroomService.AddMember(classroom, userID); err != nil {
+		log.Printf("Error adding classroom member: %v", err)
+	}
+}
+
+// 機器人被加入群組：說明如何登記班級
+func handleJoin(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
+	if groupIDOf(event) == "" {
+		return nil
+	}
+	return replyMessage(event, bot, "👋 大家好！我可以幫忙查生字、練習和印字帖\n\n"+
+		"🏫 老師可以輸入「登記班級 康軒 二上 第5課」把這個群組登記為班級，同學們會自動使用班級的課程設定\n\n"+
+		"💡 輸入「幫助」查看所有功能")
+}
+
+// 機器人被移出群組：刪除班級登記與成員在群組中的狀態
+func handleLeave(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
+	groupID := groupIDOf(event)
+	if groupID == "" {
+		return nil
+	}
+	clearGroupUserStates(firebaseClient, groupID)
+	if err := services.NewClassroomService(firebaseClient).DeleteClassroom(groupID); err != nil {
+		log.Printf("Error deleting classroom: %v", err)
+	}
+	return nil
+}
+
+// 登記班級：在群組中輸入「登記班級 康軒 二上 第5課 二年甲班」，登記的人即為班級老師
+func handleRegisterClassroom(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, args string) error {
+	groupID := groupIDOf(event)
+	if groupID == "" {
+		return utils.NewUserInputError("🏫 請先把我加入班級的 LINE 群組，再在群組中輸入「登記班級 康軒 二上 第5課」")
+	}
+	course, name := utils.ParseCourse(args)
+	if course.Publisher == "" || course.Grade == 0 || course.Semester == 0 || course.Lesson == 0 {
+		return utils.NewUserInputError("🏫 請輸入出版社、年級、學期和目前課次，例如：\n登記班級 康軒 二上 第5課\n登記班級 翰林 3下 L2 三年乙班")
+	}
+
+	classroomService := services.NewClassroomService(firebaseClient)
+	classroom, err := classroomService.GetClassroom(groupID)
+	if err != nil {
+		return utils.NewTransientError("failed to get classroom", err)
+	}
+	if classroom != nil && classroom.TeacherID != userID {
+		return utils.NewUserInputError("🏫 這個群組已經由其他老師登記為班級了")
+	}
+	if classroom == nil {
+		classroom = &models.Classroom{GroupID: groupID, TeacherID: userID}
+	}
+	if name == "" && classroom.Name == "" {
+		name = "班級"
+		if summary, err := bot.GetGroupSummary(groupID).Do(); err == nil && summary.GroupName != "" {
+			name = summary.GroupName
+		}
+	}
+	if name != "" {
+		classroom.Name = name
+	}
+	classroom.Publisher = course.Publisher
+	classroom.Grade = course.Grade
+	classroom.Semester = course.Semester
+	classroom.Lesson = course.Lesson
+	if err := classroomService.SaveClassroom(classroom); err != nil {
+		return utils.NewTransientError("failed to save classroom", err)
+	}
+
+	return replyMessage(event, bot, fmt.Sprintf("🏫 已登記「%s」\n📖 %s\n\n"+
+		"同學在群組中查詢、練習時會自動使用這個課程設定\n"+
+		"老師可以輸入「班級進度 第6課」更新課次，私訊我「班級報告」查看全班練習情形",
+		classroom.Name, classroomCourseText(classroom)))
+}
+
+// 更新班級進度：老師在群組中輸入「班級進度 第6課」（也可以一併改出版社、年級學期）
+func handleClassroomProgress(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, args string) error {
+	groupID := groupIDOf(event)
+	if groupID == "" {
+		return utils.NewUserInputError("🏫 請在班級群組中輸入「班級進度 第6課」")
+	}
+
+	classroomService := services.NewClassroomService(firebaseClient)
+	classroom, err := classroomService.GetClassroom(groupID)
+	if err != nil {
+		return utils.NewTransientError("failed to get classroom", err)
+	}
+	if classroom == nil {
+		return utils.NewUserInputError("🏫 這個群組還沒有登記班級，請老師輸入「登記班級 康軒 二上 第5課」")
+	}
+	if classroom.TeacherID != userID {
+		return utils.NewUserInputError("🏫 只有登記班級的老師可以更新進度")
+	}
+
+	course, _ := utils.ParseCourse(args)
+	if !course.HasCourse() {
+		return utils.NewUserInputError(fmt.Sprintf("🏫 目前進度：%s\n\n請輸入新的課次，例如：班級進度 第%d課", classroomCourseText(classroom), classroom.Lesson+1))
+	}
+	if course.Publisher != "" {
+		classroom.Publisher = course.Publisher
+	}
+	if course.Grade > 0 {
+		classroom.Grade = course.Grade
+	}
+	if course.Semester > 0 {
+		classroom.Semester = course.Semester
+	}
+	if course.Lesson > 0 {
+		classroom.Lesson = course.Lesson
+	}
+	if err := classroomService.SaveClassroom(classroom); err != nil {
+		return utils.NewTransientError("failed to save classroom", err)
+	}
+	return replyMessage(event, bot, fmt.Sprintf("🏫「%s」的進度已更新為 %s", classroom.Name, classroomCourseText(classroom)))
+}
+
+// 班級報告：老師私訊查看所登記班級近 7 天的練習統計，避免在群組公開個人成績
+func handleClassroomReport(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string) error {
+	if groupIDOf(event) != "" {
+		return replyMessage(event, bot, "🏫 班級報告包含每位同學的練習情形，請老師私訊我「班級報告」查看")
+	}
+
+	classroomService := services.NewClassroomService(firebaseClient)
+	classrooms, err := classroomService.GetTeacherClassrooms(userID)
+	if err != nil {
+		return utils.NewTransientError("failed to get classrooms", err)
+	}
+	if len(classrooms) == 0 {
+		return utils.NewUserInputError("🏫 你還沒有登記班級\n\n請把我加入班級的 LINE 群組，在群組中輸入「登記班級 康軒 二上 第5課」")
+	}
+
+	// 一次回覆最多 5 則訊息
+	since := time.Now().AddDate(0, 0, -classroomReportDays)
+	var messages []linebot.SendingMessage
+	for _, classroom := range classrooms {
+		if len(messages) >= 5 {
+			break
+		}
+		report, err := classroomService.GetReport(classroom, since)
+		if err != nil {
+			log.Printf("Error building classroom report for %s: %v", classroom.GroupID, err)
+			continue
+		}
+		names := classroomMemberNames(bot, classroom.GroupID, report.Participants)
+		messages = append(messages, linebot.NewTextMessage(utils.CreateClassroomReportText(report, names)))
+	}
+	if len(messages) == 0 {
+		return utils.NewTransientError("failed to build classroom reports", nil)
+	}
+	return replyMessages(event, bot, messages...)
+}
+
+// 查詢成員在群組中的顯示名稱，查不到的成員不列入
+func classroomMemberNames(bot *linebot.Client, groupID string, participants []models.ClassroomParticipant) map[string]string {
+	names := make(map[string]string)
+	for i, participant := range participants {
+		if i >= maxClassroomNameLookups {
+			break
+		}
+		profile, err := bot.GetGroupMemberProfile(groupID, participant.UserID).Do()
+		if err != nil {
+			continue
+		}
+		names[participant.UserID] = profile.DisplayName
+	}
+	return names
+}
+
+// 班級的課程文字，例如「康軒 2年級上學期 第5課」
+func classroomCourseText(classroom *models.Classroom) string {
+	return fmt.Sprintf("%s %d年級%s 第%d課", classroom.Publisher, classroom.Grade, semesterName(classroom.Semester), classroom.Lesson)
+}
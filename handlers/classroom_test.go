@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"chinese-learning-linebot/models"
+)
+
+func TestExpireIdleGroupMode(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		groupID  string
+		idle     time.Duration
+		wantMode string
+	}{
+		{"active group query", "C1", 3 * time.Minute, "cumulative_query"},
+		{"idle group query", "C1", 15 * time.Minute, ""},
+		// 私訊中的模式不會因閒置結束
+		{"idle private query", "", 15 * time.Minute, "cumulative_query"},
+	}
+	for _, tt := range tests {
+		state := &models.UserState{Mode: "cumulative_query", Step: 4, GroupID: tt.groupID, UpdatedAt: now.Add(-tt.idle).UnixMilli()}
+		expireIdleGroupMode(state, now)
+		if state.Mode != tt.wantMode {
+			t.Errorf("%s: mode = %q, want %q", tt.name, state.Mode, tt.wantMode)
+		}
+	}
+}
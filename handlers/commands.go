@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/utils"
+)
+
+// 指令分類，依顯示順序排列
+type commandCategory struct {
+	Name  string
+	Emoji string
+}
+
+var (
+	categoryQuery     = commandCategory{Name: "查詢", Emoji: "📚"}
+	categoryPractice  = commandCategory{Name: "練習", Emoji: "✏️"}
+	categoryPush      = commandCategory{Name: "推播訂閱", Emoji: "🔔"}
+	categoryClassroom = commandCategory{Name: "班級", Emoji: "🏫"}
+	categorySettings  = commandCategory{Name: "設定", Emoji: "🔧"}
+	categoryOther     = commandCategory{Name: "其他", Emoji: "❓"}
+
+	commandCategories = []commandCategory{categoryQuery, categoryPractice, categoryPush, categoryClassroom, categorySettings, categoryOther}
+)
+
+// 執行指令時的上下文
+type commandContext struct {
+	event          *linebot.Event
+	bot            *linebot.Client
+	firebaseClient *config.FirebaseClient
+	userID         string
+	state          *models.UserState
+	command        *command
+	args           string // 指令名稱之後的文字（僅 Prefix 指令）
+}
+
+// 正規化後的指令文字（別名換成指令名稱，保留參數）
+func (c *commandContext) canonicalText() string {
+	return strings.TrimSpace(c.command.Name + " " + c.args)
+}
+
+// 一個用戶指令，說明文字、歡迎訊息與快速回覆皆由此產生
+type command struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Examples    []string // 使用範例，空白時以 Name 為範例
+	Category    commandCategory
+	Prefix      bool     // 指令後可接參數，例如「勿擾時段 21-7」
+	AnyMode     bool     // 在查詢、練習等模式中也可使用（模式中須輸入完整的指令名稱，不含別名）
+	AliasModes  []string // AnyMode 指令在這些模式中別名也可使用（輸入不會被當成要查詢的字詞時）
+	Featured    bool     // 列在歡迎訊息與快速回覆提示中
+	Handle      func(c *commandContext) error
+}
+
+// 所有指令，依分類內的顯示順序排列
+// 在 init 中建立，因為「幫助」等指令的處理函式本身會讀取此清單
+var commandRegistry []*command
+
+func init() {
+	subscription := func(c *commandContext) error {
+		_, err := handleSubscriptionCommand(c.event, c.canonicalText(), c.bot, c.firebaseClient, c.userID)
+		return err
+	}
+
+	commandRegistry = []*command{
+		{
+			Name:        "查詢累積字詞",
+			Aliases:     []string{"累積字詞", "查詢字詞", "查累積字詞", "查詢生字"},
+			Description: "查詢字詞中哪些字已經學過（依出版社、年級、學期、課次累積）",
+			Examples:    []string{"查詢累積字詞", "康軒 二上 第5課 我好喜歡吃飯", "第6課 森林（沿用記憶的設定）"},
+			Category:    categoryQuery,
+			Featured:    true,
+			Handle: func(c *commandContext) error {
+				return startCumulativeQuery(c.event, c.bot, c.firebaseClient, c.userID)
+			},
+		},
+		{
+			Name:        "查字",
+			Description: "查看單字的注音、部首、筆畫、字義、例句，以及課本第幾課教到",
+			Examples:    []string{"查字 森"},
+			Category:    categoryQuery,
+			Prefix:      true,
+			Handle: func(c *commandContext) error {
+				return handleCharacterLookup(c.event, c.bot, c.firebaseClient, c.userID, utils.GetFirstChineseCharacter(c.args))
+			},
+		},
+		{
+			Name:        "找字",
+			Description: "依注音（可不含聲調）、部首、筆畫範圍找字，加上「已學」只找學過的字",
+			Examples:    []string{"找字 ㄕㄣ", "找字 木部 5-8畫", "找字 ㄇㄨˋ 已學"},
+			Category:    categoryQuery,
+			Prefix:      true,
+			Handle: func(c *commandContext) error {
+				return handleCharacterSearch(c.event, c.bot, c.firebaseClient, c.userID, c.args, 0)
+			},
+		},
+		{
+			Name:        "注音",
+			Description: "為一段文字標上注音，破音字依詞語判斷讀音；加上「生字」只標註還沒學過的字",
+			Examples:    []string{"注音 我們一起去公園", "注音 生字 小明喜歡在森林裡散步"},
+			Category:    categoryQuery,
+			Prefix:      true,
+			Handle: func(c *commandContext) error {
+				return handleZhuyinAnnotation(c.event, c.bot, c.firebaseClient, c.userID, c.args)
+			},
+		},
+		{
+			Name:        "印字帖",
+			Aliases:     []string{"字帖", "列印字帖"},
+			Description: "下載生字字帖 PDF，可選一課、連續幾課、上次查詢的生字或待複習的字，以及田字格/米字格、描紅格數、是否標注音",
+			Examples:    []string{"印字帖", "印字帖 第2-4課 米字格", "印字帖 生字", "印字帖 複習 描紅5格 不要注音"},
+			Category:    categoryQuery,
+			Prefix:      true,
+			Featured:    true,
+			Handle: func(c *commandContext) error {
+				return handlePrintWorksheet(c.event, c.bot, c.firebaseClient, c.userID, c.args)
+			},
+		},
+		{
+			Name:        "平板學寫字",
+			Aliases:     []string{"學寫字", "練寫字"},
+			Description: "前往平板練字頁面，提供即時筆劃指導",
+			Category:    categoryQuery,
+			Handle: func(c *commandContext) error {
+				return handleTabletPractice(c.event, c.bot)
+			},
+		},
+		{
+			Name:        "筆順",
+			Description: "看單字的筆順分解圖和筆順動畫",
+			Examples:    []string{"筆順 森"},
+			Category:    categoryQuery,
+			Prefix:      true,
+			Handle: func(c *commandContext) error {
+				return handleStrokeOrder(c.event, c.bot, utils.GetFirstChineseCharacter(c.args))
+			},
+		},
+		{
+			Name:        "練習",
+			Aliases:     []string{"開始練習", "小測驗", "測驗"},
+			Description: "做注音、筆畫、造句小測驗，答錯的字會排入複習",
+			Category:    categoryPractice,
+			Featured:    true,
+			Handle: func(c *commandContext) error {
+				return startPractice(c.event, c.bot, c.firebaseClient, c.userID, c.state, false)
+			},
+		},
+		{
+			Name:        "挑戰模式",
+			Aliases:     []string{"挑戰", "限時挑戰"},
+			Description: "限時作答，答得又快又對分數越高，會記錄個人最佳成績",
+			Category:    categoryPractice,
+			Handle: func(c *commandContext) error {
+				return startPractice(c.event, c.bot, c.firebaseClient, c.userID, c.state, true)
+			},
+		},
+		{
+			Name:        "挑戰紀錄",
+			Aliases:     []string{"我的紀錄", "最佳紀錄"},
+			Description: "查看挑戰模式的個人最佳成績",
+			Category:    categoryPractice,
+			Handle: func(c *commandContext) error {
+				return handleChallengeBoard(c.event, c.bot, c.firebaseClient, models.UserStateKey(c.userID, c.state.GroupID))
+			},
+		},
+		{
+			Name:        "學習週報",
+			Aliases:     []string{"週報", "本週學習"},
+			Description: "查看本週學到的字、練習正確率和最常答錯的字",
+			Examples:    []string{"學習週報", "學習週報 文字（純文字版）"},
+			Category:    categoryPractice,
+			Prefix:      true,
+			Featured:    true,
+			Handle: func(c *commandContext) error {
+				return handleWeeklyReport(c.event, c.bot, c.firebaseClient, c.userID, c.args == "文字")
+			},
+		},
+		{
+			Name:        "訂閱每日一字",
+			Aliases:     []string{"每日一字"},
+			Description: "每天早上推播下一課的生字卡",
+			Category:    categoryPush,
+			Handle:      subscription,
+		},
+		{
+			Name:        "取消每日一字",
+			Description: "停止每日一字推播",
+			Category:    categoryPush,
+			Handle:      subscription,
+		},
+		{
+			Name:        "訂閱複習提醒",
+			Aliases:     []string{"複習提醒"},
+			Description: "有到期需要複習的字時，晚上提醒您",
+			Category:    categoryPush,
+			Handle:      subscription,
+		},
+		{
+			Name:        "取消複習提醒",
+			Description: "停止複習提醒",
+			Category:    categoryPush,
+			Handle:      subscription,
+		},
+		{
+			Name:        "訂閱學習週報",
+			Description: "每週日晚上推播學習週報給家長",
+			Category:    categoryPush,
+			Handle:      subscription,
+		},
+		{
+			Name:        "取消學習週報",
+			Description: "停止學習週報推播",
+			Category:    categoryPush,
+			Handle:      subscription,
+		},
+		{
+			Name:        "勿擾時段",
+			Description: "設定不推播的時段，期間的每日一字會在結束後補送",
+			Examples:    []string{"勿擾時段 21-7"},
+			Category:    categoryPush,
+			Prefix:      true,
+			Handle:      subscription,
+		},
+		{
+			Name:        "取消勿擾",
+			Description: "取消勿擾時段",
+			Category:    categoryPush,
+			Handle:      subscription,
+		},
+		{
+			Name:        "我的訂閱",
+			Aliases:     []string{"查看訂閱"},
+			Description: "查看目前的訂閱設定",
+			Category:    categoryPush,
+			Handle:      subscription,
+		},
+		{
+			Name:        "登記班級",
+			Description: "老師在班級群組中登記班級，同學會自動使用班級的課程設定",
+			Examples:    []string{"登記班級 康軒 二上 第5課", "登記班級 翰林 3下 L2 三年乙班"},
+			Category:    categoryClassroom,
+			Prefix:      true,
+			Handle: func(c *commandContext) error {
+				return handleRegisterClassroom(c.event, c.bot, c.firebaseClient, c.userID, c.args)
+			},
+		},
+		{
+			Name:        "班級進度",
+			Aliases:     []string{"更新進度"},
+			Description: "老師在班級群組中更新目前教到的課次",
+			Examples:    []string{"班級進度 第6課"},
+			Category:    categoryClassroom,
+			Prefix:      true,
+			Handle: func(c *commandContext) error {
+				return handleClassroomProgress(c.event, c.bot, c.firebaseClient, c.userID, c.args)
+			},
+		},
+		{
+			Name:        "班級報告",
+			Aliases:     []string{"全班報告"},
+			Description: "老師私訊查看班級近 7 天的練習正確率、最常答錯的字和同學參與情形",
+			Category:    categoryClassroom,
+			Handle: func(c *commandContext) error {
+				return handleClassroomReport(c.event, c.bot, c.firebaseClient, c.userID)
+			},
+		},
+		{
+			Name:        "我的設定",
+			Aliases:     []string{"使用者課程設定", "查看設定"},
+			Description: "查看記憶的出版社、年級、學期設定",
+			Category:    categorySettings,
+			Featured:    true,
+			Handle: func(c *commandContext) error {
+				return showUserSettings(c.event, c.bot, c.firebaseClient, c.userID)
+			},
+		},
+		{
+			Name:        "重設偏好",
+			Aliases:     []string{"重設設定", "清除記憶"},
+			Description: "清除記憶的出版社/年級/學期設定",
+			Category:    categorySettings,
+			Handle: func(c *commandContext) error {
+				return resetUserPreferences(c.event, c.bot, c.firebaseClient, c.userID)
+			},
+		},
+		{
+			Name:        "退出",
+			Aliases:     []string{"離開", "結束"},
+			Description: "退出目前的查詢或練習模式",
+			Category:    categoryOther,
+			AnyMode:     true,
+			AliasModes:  []string{"practice"},
+			Handle:      exitCurrentMode,
+		},
+		{
+			Name:        "幫助",
+			Aliases:     []string{"help", "說明", "使用說明"},
+			Description: "查看使用說明",
+			Category:    categoryOther,
+			AnyMode:     true,
+			AliasModes:  []string{"practice"},
+			Featured:    true,
+			Handle: func(c *commandContext) error {
+				return handleHelp(c.event, c.bot)
+			},
+		},
+	}
+
+	var intents []utils.Intent
+	for _, cmd := range commandRegistry {
+		intents = append(intents, utils.Intent{Command: cmd.Name, Synonyms: cmd.Aliases})
+	}
+	commandMatcher = utils.NewIntentMatcher(intents)
+}
+
+// 依輸入找出指令：名稱或別名完全相同，或 Prefix 指令以名稱加空白開頭
+func findCommand(text string) (*command, string) {
+	for _, cmd := range commandRegistry {
+		if text == cmd.Name {
+			return cmd, ""
+		}
+		for _, alias := range cmd.Aliases {
+			if text == alias {
+				return cmd, ""
+			}
+		}
+		// 指令名稱後須有空白或冒號，避免「注音符號」被當成「注音 符號」
+		if rest := strings.TrimPrefix(text, cmd.Name); cmd.Prefix && rest != text && startsWithCommandSeparator(rest) {
+			return cmd, strings.TrimSpace(strings.TrimLeft(rest, ":："))
+		}
+	}
+	return nil, ""
+}
+
+// 指令在目前模式中是否可用：不在模式中時都可用；模式中只有 AnyMode 指令可用，
+// 且須輸入完整的指令名稱，除非該模式列在 AliasModes（練習的答案是選項編號或句子，不會與別名混淆）
+func (cmd *command) usableIn(mode string, text string) bool {
+	if mode == "" {
+		return true
+	}
+	if !cmd.AnyMode {
+		return false
+	}
+	return text == cmd.Name || containsMode(cmd.AliasModes, mode)
+}
+
+func containsMode(modes []string, mode string) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func startsWithCommandSeparator(rest string) bool {
+	r, _ := utf8.DecodeRuneInString(rest)
+	return unicode.IsSpace(r) || r == ':' || r == '：'
+}
+
+// 使用說明：依分類列出所有指令與範例
+func buildHelpText() string {
+	var builder strings.Builder
+	builder.WriteString("🎓 中文學習小幫手使用說明\n")
+
+	for _, category := range commandCategories {
+		builder.WriteString(fmt.Sprintf("\n%s %s：\n", category.Emoji, category.Name))
+		for _, cmd := range commandRegistry {
+			if cmd.Category != category {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("• 「%s」- %s\n", cmd.Name, cmd.Description))
+			for _, example := range cmd.Examples {
+				if example != cmd.Name {
+					builder.WriteString(fmt.Sprintf("   例：%s\n", example))
+				}
+			}
+		}
+	}
+
+	builder.WriteString("\n📱 也可以點選聊天室下方的圖文選單直接使用各項功能")
+	return builder.String()
+}
+
+// 歡迎訊息：列出主要功能
+func buildWelcomeText() string {
+	var builder strings.Builder
+	builder.WriteString("🎉 歡迎使用中文學習小幫手！\n\n我可以幫助您：\n")
+	for _, cmd := range commandRegistry {
+		if cmd.Featured && cmd.Name != "幫助" {
+			builder.WriteString(fmt.Sprintf("%s 「%s」- %s\n", cmd.Category.Emoji, cmd.Name, cmd.Description))
+		}
+	}
+	builder.WriteString("\n請點選下方的指令開始使用，或輸入「幫助」查看詳細說明！")
+	return builder.String()
+}
+
+// 主要功能的快速回覆提示
+func featuredCommandQuickReply() *linebot.QuickReplyItems {
+	var items []*linebot.QuickReplyButton
+	for _, cmd := range commandRegistry {
+		if cmd.Featured {
+			items = append(items, &linebot.QuickReplyButton{
+				Action: &linebot.MessageAction{Label: cmd.Name, Text: cmd.Name},
+			})
+		}
+	}
+	return &linebot.QuickReplyItems{Items: items}
+}
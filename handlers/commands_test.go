@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"chinese-learning-linebot/utils"
+)
+
+func TestCommandRegistryIsConsistent(t *testing.T) {
+	categories := make(map[commandCategory]bool)
+	for _, category := range commandCategories {
+		categories[category] = true
+	}
+
+	// 名稱與別名不可重複，否則後面的指令永遠不會被找到
+	seen := make(map[string]string)
+	for _, cmd := range commandRegistry {
+		if cmd.Description == "" || cmd.Handle == nil {
+			t.Errorf("%s: missing description or handler", cmd.Name)
+		}
+		if !categories[cmd.Category] {
+			t.Errorf("%s: category %q is not listed in commandCategories", cmd.Name, cmd.Category.Name)
+		}
+		for _, text := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if owner, ok := seen[text]; ok {
+				t.Errorf("%q is used by both %s and %s", text, owner, cmd.Name)
+			}
+			seen[text] = cmd.Name
+		}
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantName string
+		wantArgs string
+	}{
+		{"查詢累積字詞", "查詢累積字詞", ""},
+		{"累積字詞", "查詢累積字詞", ""},
+		{"help", "幫助", ""},
+		{"學習週報 文字", "學習週報", "文字"},
+		{"勿擾時段 21-7", "勿擾時段", "21-7"},
+		{"注音：我們", "注音", "我們"},
+		// 指令名稱後沒有空白，不是指令
+		{"注音符號", "", ""},
+		{"筆順很重要", "", ""},
+		// 非 Prefix 指令不接受後面多出的文字
+		{"練習一下", "", ""},
+		{"今天好熱", "", ""},
+	}
+
+	for _, tt := range tests {
+		cmd, args := findCommand(tt.text)
+		name := ""
+		if cmd != nil {
+			name = cmd.Name
+		}
+		if name != tt.wantName || args != tt.wantArgs {
+			t.Errorf("findCommand(%q) = %q, %q; want %q, %q", tt.text, name, args, tt.wantName, tt.wantArgs)
+		}
+	}
+}
+
+func TestHelpTextListsEveryCommand(t *testing.T) {
+	help := buildHelpText()
+	for _, cmd := range commandRegistry {
+		if !strings.Contains(help, "「"+cmd.Name+"」") {
+			t.Errorf("help text does not list %s", cmd.Name)
+		}
+	}
+}
+
+// 模糊比對靠手寫的注音表判斷同音字，新增指令或別名時須一併補上用字
+func TestZhuyinTableCoversCommands(t *testing.T) {
+	for _, cmd := range commandRegistry {
+		for _, phrase := range append([]string{cmd.Name}, cmd.Aliases...) {
+			for _, char := range phrase {
+				if !unicode.Is(unicode.Han, char) {
+					continue
+				}
+				if syllables := utils.ToZhuyinSyllables(string(char)); syllables[0] == string(char) {
+					t.Errorf("%s: %q has no entry in the zhuyin table (utils/zhuyin.go)", phrase, char)
+				}
+			}
+		}
+	}
+}
+
+func TestCommandUsableInMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		text string
+		want string
+	}{
+		{"", "結束", "退出"},
+		{"", "查字 森", "查字"},
+		{"practice", "退出", "退出"},
+		{"practice", "help", "幫助"},
+		{"practice", "結束", "退出"},
+		{"cumulative_query", "幫助", "幫助"},
+		// 查詢模式中別名可能是要查詢的字詞，其他指令也不能用
+		{"cumulative_query", "結束", ""},
+		{"cumulative_query", "說明", ""},
+		{"practice", "查字 森", ""},
+	}
+
+	for _, tt := range tests {
+		cmd, _ := findCommand(tt.text)
+		got := ""
+		if cmd != nil && cmd.usableIn(tt.mode, tt.text) {
+			got = cmd.Name
+		}
+		if got != tt.want {
+			t.Errorf("%q in mode %q runs %q, want %q", tt.text, tt.mode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/utils"
+)
+
+// 模糊比對指令用，由 commandRegistry 的名稱與別名建立
+var commandMatcher *utils.IntentMatcher
+
+// 模糊建議最多列出的指令數
+const maxCommandSuggestions = 3
+
+// 回覆相近指令的建議（快速回覆），沒有相近指令時回傳 false
+func suggestCommands(event *linebot.Event, userText string, bot *linebot.Client) (bool, error) {
+	matches := commandMatcher.Suggest(userText, maxCommandSuggestions)
+	if len(matches) == 0 {
+		return false, nil
+	}
+
+	var items []*linebot.QuickReplyButton
+	for _, match := range matches {
+		items = append(items, &linebot.QuickReplyButton{
+			Action: &linebot.MessageAction{Label: match.Command, Text: match.Command},
+		})
+	}
+
+	responseText := fmt.Sprintf("🤔 您是不是要輸入「%s」？\n\n請點選下方的指令", matches[0].Command)
+	return true, replyMessageWithQuickReply(event, bot, responseText, &linebot.QuickReplyItems{Items: items})
+}
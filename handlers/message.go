@@ -3,61 +3,96 @@ package handlers
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/line/line-bot-sdk-go/v7/linebot"
 
 	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
 )
 
-// 用戶狀態管理
-type UserState struct {
-	Mode      string // "cumulative_query" 或 ""
-	Publisher string
-	Grade     int
-	Semester  int
-	Lesson    int
-	Step      int // 0: 等待出版社, 1: 等待年級, 2: 等待學期, 3: 等待課次, 4: 等待查詢字詞
-	// 用戶偏好設定（記憶半年）
-	PreferredPublisher string
-	PreferredGrade     int
-	PreferredSemester  int
-}
-
 // 從 Firestore 獲取用戶狀態
-func getUserState(firebaseClient *config.FirebaseClient, userID string) *UserState {
+func getUserState(firebaseClient *config.FirebaseClient, userID string) *models.UserState {
 	doc, err := firebaseClient.Firestore.Collection("user_states").Doc(userID).Get(firebaseClient.Ctx)
 	if err != nil {
 		// 如果文檔不存在或發生錯誤，返回空狀態
-		return &UserState{}
+		return &models.UserState{}
 	}
 
-	var state UserState
+	var state models.UserState
 	if err := doc.DataTo(&state); err != nil {
 		// 如果解析失敗，返回空狀態
-		return &UserState{}
+		return &models.UserState{}
 	}
 
 	return &state
 }
 
+// 用戶狀態的文件 ID：群組中的模式與練習另外記錄，不影響私訊中的狀態
+// 從事件來源取得用戶狀態：群組中取該群組的狀態，並沿用班級的課程設定
+func getEventUserState(firebaseClient *config.FirebaseClient, event *linebot.Event, userID string) *models.UserState {
+	state := loadEventUserState(firebaseClient, event, userID)
+	applyClassroom(firebaseClient, state.GroupID, state)
+	return state
+}
+
+// 從事件來源讀取用戶狀態，不讀取班級設定；群組中閒置過久的模式視為已結束
+func loadEventUserState(firebaseClient *config.FirebaseClient, event *linebot.Event, userID string) *models.UserState {
+	groupID := groupIDOf(event)
+	state := getUserState(firebaseClient, models.UserStateKey(userID, groupID))
+	state.GroupID = groupID
+	expireIdleGroupMode(state, eventTime(event))
+	return state
+}
+
 // 設置用戶狀態到 Firestore
-func setUserState(firebaseClient *config.FirebaseClient, userID string, state *UserState) {
-	_, err := firebaseClient.Firestore.Collection("user_states").Doc(userID).Set(firebaseClient.Ctx, state)
+func setUserState(firebaseClient *config.FirebaseClient, userID string, state *models.UserState) {
+	state.UserID = userID
+	state.UpdatedAt = time.Now().UnixMilli()
+	_, err := firebaseClient.Firestore.Collection("user_states").Doc(models.UserStateKey(userID, state.GroupID)).Set(firebaseClient.Ctx, state)
 	if err != nil {
 		log.Printf("Error setting user state: %v", err)
 	}
 }
 
-// 清除用戶狀態從 Firestore
-func clearUserState(firebaseClient *config.FirebaseClient, userID string) {
-	_, err := firebaseClient.Firestore.Collection("user_states").Doc(userID).Delete(firebaseClient.Ctx)
+// 刪除單一狀態文件，key 為 models.UserStateKey 的鍵
+func deleteUserState(firebaseClient *config.FirebaseClient, key string) {
+	_, err := firebaseClient.Firestore.Collection("user_states").Doc(key).Delete(firebaseClient.Ctx)
 	if err != nil {
 		log.Printf("Error clearing user state: %v", err)
 	}
 }
 
+// 清除用戶在私訊與各群組中的狀態
+func clearUserState(firebaseClient *config.FirebaseClient, userID string) {
+	deleteUserState(firebaseClient, userID)
+	deleteUserStatesWhere(firebaseClient, "UserID", userID)
+}
+
+// 清除所有成員在群組中的狀態
+func clearGroupUserStates(firebaseClient *config.FirebaseClient, groupID string) {
+	deleteUserStatesWhere(firebaseClient, "GroupID", groupID)
+}
+
+// 刪除欄位 field 等於 value 的狀態文件
+func deleteUserStatesWhere(firebaseClient *config.FirebaseClient, field string, value string) {
+	docs, err := firebaseClient.Firestore.Collection("user_states").Where(field, "==", value).Documents(firebaseClient.Ctx).GetAll()
+	if err != nil {
+		log.Printf("Error listing user states by %s: %v", field, err)
+		return
+	}
+	for _, doc := range docs {
+		if _, err := doc.Ref.Delete(firebaseClient.Ctx); err != nil {
+			log.Printf("Error clearing user state %s: %v", doc.Ref.ID, err)
+		}
+	}
+}
+
 func handleMessage(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
 	switch message := event.Message.(type) {
 	case *linebot.TextMessage:
@@ -68,22 +103,34 @@ func handleMessage(event *linebot.Event, bot *linebot.Client, firebaseClient *co
 }
 
 func handleTextMessage(event *linebot.Event, message *linebot.TextMessage, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
-	userText := strings.TrimSpace(message.Text)
+	return handleUserText(event, strings.TrimSpace(message.Text), bot, firebaseClient)
+}
+
+// 處理用戶輸入的文字指令（文字訊息與圖文選單 postback 共用）
+func handleUserText(event *linebot.Event, userText string, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
 	userID := event.Source.UserID
-	state := getUserState(firebaseClient, userID)
-
-	// 處理退出指令
-	if userText == "退出" {
-		// 只清除當前查詢狀態，保留用戶偏好設定
-		state.Mode = ""
-		state.Publisher = ""
-		state.Grade = 0
-		state.Semester = 0
-		state.Lesson = 0
-		state.Step = 0
-		// 保留 PreferredPublisher, PreferredGrade, PreferredSemester
-		setUserState(firebaseClient, userID, state)
-		return replyMessage(event, bot, "已退出當前模式，請輸入新的指令。")
+	cmd, args := findCommand(userText)
+	state := loadEventUserState(firebaseClient, event, userID)
+	// 群組中不是指令、也不在任何模式中的聊天不回應，不必再讀取班級設定
+	if cmd == nil && state.GroupID != "" && state.Mode == "" {
+		return nil
+	}
+	applyClassroom(firebaseClient, state.GroupID, state)
+
+	ctx := &commandContext{
+		event:          event,
+		bot:            bot,
+		firebaseClient: firebaseClient,
+		userID:         userID,
+		state:          state,
+		command:        cmd,
+		args:           args,
+	}
+
+	// 退出、幫助在任何模式中都可使用；查詢模式中只認完整的指令名稱，「結束」等別名可能是要查詢的字詞
+	if cmd != nil && cmd.AnyMode && cmd.usableIn(state.Mode, userText) {
+		recordClassroomMember(firebaseClient, event, userID)
+		return cmd.Handle(ctx)
 	}
 
 	// 如果用戶在累積字詞查詢模式中
@@ -91,35 +138,62 @@ func handleTextMessage(event *linebot.Event, message *linebot.TextMessage, bot *
 		return handleCumulativeQueryMode(event, userText, bot, firebaseClient, userID, state)
 	}
 
-	// 處理新指令
-	switch userText {
-	case "查詢累積字詞":
-		return startCumulativeQuery(event, bot, firebaseClient, userID)
-	case "重設偏好", "重設設定", "清除記憶":
-		return resetUserPreferences(event, bot, firebaseClient, userID)
-	case "使用者課程設定", "查看設定", "我的設定":
-		return showUserSettings(event, bot, firebaseClient, userID)
-	case "印字帖":
-		return handlePrintWorksheet(event, bot, firebaseClient, userID)
-	case "平板學寫字":
-		return handleTabletPractice(event, bot)
-	case "幫助", "help", "說明":
-		return handleHelp(event, bot)
-	default:
-		return handleUnknownMessage(event, bot)
+	// 如果用戶正在練習中
+	if state.Mode == "practice" {
+		return handlePracticeMode(event, userText, bot, firebaseClient, userID, state)
+	}
+
+	// 處理新指令（名稱與別名見 commandRegistry）
+	if cmd != nil {
+		recordClassroomMember(firebaseClient, event, userID)
+		return cmd.Handle(ctx)
 	}
+	// 群組中只回應指令，一般聊天不回應
+	if groupIDOf(event) != "" {
+		return nil
+	}
+
+	// 一句話查詢，例如「康軒 二上 第5課 我好喜歡吃飯」
+	if query, ok := utils.ParseOneShotQuery(userText); ok {
+		// 只有「查」沒有課程欄位時，先確認不是打錯的指令（例如「查詢累積字」）
+		if !query.HasCourse() {
+			if handled, err := suggestCommands(event, userText, bot); handled {
+				return err
+			}
+		}
+		return handleOneShotQuery(event, query, bot, firebaseClient, userID, state)
+	}
+	// 相近的錯字提供建議
+	if handled, err := suggestCommands(event, userText, bot); handled {
+		return err
+	}
+	return handleUnknownMessage(event, bot)
+}
+
+// 退出當前模式：只清除當前查詢狀態，保留用戶偏好設定
+func exitCurrentMode(c *commandContext) error {
+	state := c.state
+	wasPracticing := state.Mode == "practice"
+	state.ClearMode()
+	// 保留 PreferredPublisher, PreferredGrade, PreferredSemester
+	setUserState(c.firebaseClient, c.userID, state)
+	if wasPracticing {
+		switchRichMenu(c.bot, c.userID, state)
+	}
+	return replyMessage(c.event, c.bot, "已退出當前模式，請輸入新的指令。")
 }
 
 // 開始累積字詞查詢模式
 func startCumulativeQuery(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string) error {
 	// 檢查是否有現有的用戶偏好設定
-	existingState := getUserState(firebaseClient, userID)
+	existingState := getEventUserState(firebaseClient, event, userID)
 	
 	// 調試日誌
 	log.Printf("User %s existing state: Publisher=%s, Grade=%d, Semester=%d", userID, existingState.PreferredPublisher, existingState.PreferredGrade, existingState.PreferredSemester)
 	
-	state := &UserState{
-		Mode: "cumulative_query",
+	state := &models.UserState{
+		Mode:    "cumulative_query",
+		GroupID: existingState.GroupID,
 	}
 	
 	// 如果用戶已有偏好設定，提供三個選項
@@ -130,6 +204,7 @@ func startCumulativeQuery(event *linebot.Event, bot *linebot.Client, firebaseCli
 		state.PreferredPublisher = existingState.PreferredPublisher
 		state.PreferredGrade = existingState.PreferredGrade
 		state.PreferredSemester = existingState.PreferredSemester
+		state.PreferredLesson = existingState.PreferredLesson
 		state.Step = -1 // 特殊步驟：等待用戶選擇操作
 		setUserState(firebaseClient, userID, state)
 		
@@ -176,6 +251,7 @@ func startCumulativeQuery(event *linebot.Event, bot *linebot.Client, firebaseCli
 	state.PreferredPublisher = existingState.PreferredPublisher
 	state.PreferredGrade = existingState.PreferredGrade
 	state.PreferredSemester = existingState.PreferredSemester
+	state.PreferredLesson = existingState.PreferredLesson
 	setUserState(firebaseClient, userID, state)
 
 	// 創建出版社選擇快速回覆
@@ -206,7 +282,7 @@ func startCumulativeQuery(event *linebot.Event, bot *linebot.Client, firebaseCli
 }
 
 // 處理累積字詞查詢模式的狀態機
-func handleCumulativeQueryMode(event *linebot.Event, userText string, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *UserState) error {
+func handleCumulativeQueryMode(event *linebot.Event, userText string, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *models.UserState) error {
 	switch state.Step {
 	case -1: // 等待用戶選擇操作（照用上次設定、修改課程、重新設定）
 		switch userText {
@@ -332,8 +408,13 @@ func handleCumulativeQueryMode(event *linebot.Event, userText string, bot *lineb
 			state.PreferredPublisher = state.Publisher
 			state.PreferredGrade = state.Grade
 			state.PreferredSemester = state.Semester
+			state.PreferredLesson = lesson
 			state.Step = 4
 			setUserState(firebaseClient, userID, state)
+			// 記錄課次進度，供學習週報計算本週學到的字
+			if err := services.NewProgressService(firebaseClient).RecordLesson(userID, state.Publisher, state.Grade, state.Semester, lesson); err != nil {
+				log.Printf("Error recording lesson progress: %v", err)
+			}
 			semesterText := "上學期"
 			if state.Semester == 2 {
 				semesterText = "下學期"
@@ -344,14 +425,21 @@ func handleCumulativeQueryMode(event *linebot.Event, userText string, bot *lineb
 		}
 
 	case 4: // 等待查詢字詞
+		// 查詢中也可用一句話查詢切換課程範圍
+		if query, ok := utils.ParseOneShotQuery(userText); ok && query.HasCourse() {
+			return handleOneShotQuery(event, query, bot, firebaseClient, userID, state)
+		}
 		if isChineseCharacter(userText) {
 			return performCumulativeQuery(event, userText, bot, firebaseClient, userID, state)
+		} else if state.GroupID != "" {
+			// 群組中其他的聊天不提示
+			return nil
 		} else {
 			return replyMessage(event, bot, "請輸入中文字詞進行查詢")
 		}
 
 	default:
-		clearUserState(firebaseClient, userID)
+		deleteUserState(firebaseClient, models.UserStateKey(userID, state.GroupID))
 		return replyMessage(event, bot, "查詢過程出現錯誤，請重新開始")
 	}
 }
@@ -400,18 +488,25 @@ func parseLesson(text string) int {
 }
 
 // 執行累積字詞查詢
-func performCumulativeQuery(event *linebot.Event, queryText string, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *UserState) error {
+func performCumulativeQuery(event *linebot.Event, queryText string, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *models.UserState) error {
+	// 簡體字、異體字先轉為繁體再查詢
+	conversionNote := ""
+	if converted, changed := utils.NormalizeToTraditional(queryText); changed {
+		conversionNote = fmt.Sprintf("🔄 已將「%s」轉為繁體「%s」再查詢\n\n", queryText, converted)
+		queryText = converted
+	}
+
 	// 分解查詢字詞為單個字符
 	queryChars := []rune(queryText)
 	learnedChars := []string{}
 	notLearnedChars := []string{}
 
-	// 獲取累積生字列表
-	cumulativeChars, err := getCumulativeCharacters(firebaseClient, state.Publisher, state.Grade, state.Semester, state.Lesson)
+	// 獲取累積生字與語詞列表
+	vocabulary, err := getCumulativeVocabulary(firebaseClient, state.Publisher, state.Grade, state.Semester, state.Lesson)
 	if err != nil {
-		log.Printf("Error getting cumulative characters: %v", err)
-		return replyMessage(event, bot, "查詢過程中發生錯誤，請稍後再試")
+		return utils.NewTransientError("failed to get cumulative characters", err)
 	}
+	cumulativeChars := vocabulary.Characters
 
 	// 檢查每個字符是否已學過
 	for _, char := range queryChars {
@@ -423,8 +518,18 @@ func performCumulativeQuery(event *linebot.Event, queryText string, bot *linebot
 		}
 	}
 
+	// 記下尚未學過的中文字（不含空白、標點與英數字），供「印字帖 生字」使用
+	var unlearnedHan []string
+	for _, char := range notLearnedChars {
+		if utils.IsChineseCharacter([]rune(char)[0]) {
+			unlearnedHan = append(unlearnedHan, char)
+		}
+	}
+	state.LastUnlearnedCharacters = uniqueCharacters(unlearnedHan)
+	setUserState(firebaseClient, userID, state)
+
 	// 構建回覆訊息（移除範圍和查詢字詞顯示）
-	responseText := "📊 累積字詞查詢結果\n\n"
+	responseText := "📊 累積字詞查詢結果\n\n" + conversionNote
 
 	if len(learnedChars) > 0 {
 		responseText += fmt.Sprintf("✅ 已學過：%s\n", strings.Join(learnedChars, ""))
@@ -434,20 +539,169 @@ func performCumulativeQuery(event *linebot.Event, queryText string, bot *linebot
 		responseText += fmt.Sprintf("❌ 尚未學過：%s\n", strings.Join(notLearnedChars, ""))
 	}
 
+	responseText += wordNotes(queryText, vocabulary)
+	responseText += readingNotes(firebaseClient, queryText, learnedChars, state)
+
 	responseText += fmt.Sprintf("\n📈 統計：已學 %d/%d 字", len(learnedChars), len(queryChars))
 	responseText += "\n\n💡 輸入新的字詞繼續查詢，或輸入「退出」結束查詢"
 
+	// 點選下方的字可查看單字卡片
+	var hanChars []string
+	for _, char := range queryChars {
+		hanChars = append(hanChars, string(char))
+	}
+	if quickReply := characterLookupQuickReply(hanChars); quickReply != nil {
+		responseText += "\n👇 點選下方的字查看注音、筆順和例句"
+		// 有尚未學過的字時，第一個按鈕為印生字字帖
+		if len(notLearnedChars) > 0 {
+			quickReply.Items = append([]*linebot.QuickReplyButton{worksheetQuickReplyButton()}, quickReply.Items...)
+			if len(quickReply.Items) > maxQuickReplyItems {
+				quickReply.Items = quickReply.Items[:maxQuickReplyItems]
+			}
+		}
+		return replyMessageWithQuickReply(event, bot, responseText, quickReply)
+	}
 	return replyMessage(event, bot, responseText)
 }
 
+// 執行一句話查詢：未提到的出版社、年級、學期、課次沿用已記憶的偏好設定
+// 查詢後進入累積字詞查詢模式，用戶可直接輸入新的字詞繼續查詢
+func handleOneShotQuery(event *linebot.Event, query *utils.OneShotQuery, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *models.UserState) error {
+	if query.Publisher == "" {
+		query.Publisher = state.PreferredPublisher
+	}
+	if query.Grade == 0 {
+		query.Grade = state.PreferredGrade
+	}
+	if query.Semester == 0 {
+		query.Semester = state.PreferredSemester
+	}
+	lessonGiven := query.Lesson > 0
+	if !lessonGiven {
+		query.Lesson = state.PreferredLesson
+	}
+
+	var missing []string
+	if query.Publisher == "" {
+		missing = append(missing, "出版社")
+	}
+	if query.Grade < 1 || query.Grade > 6 {
+		missing = append(missing, "年級")
+	}
+	if query.Semester == 0 {
+		missing = append(missing, "學期")
+	}
+	if query.Lesson <= 0 {
+		missing = append(missing, "課次")
+	}
+	if len(missing) > 0 {
+		return utils.NewUserInputError(fmt.Sprintf("🔍 缺少%s，無法直接查詢\n\n請寫完整，例如：康軒 二上 第5課 我好喜歡吃飯\n或輸入「查詢累積字詞」一步步設定", strings.Join(missing, "、")))
+	}
+
+	state.Mode = "cumulative_query"
+	state.Publisher = query.Publisher
+	state.Grade = query.Grade
+	state.Semester = query.Semester
+	state.Lesson = query.Lesson
+	state.Step = 4
+	state.PreferredPublisher = query.Publisher
+	state.PreferredGrade = query.Grade
+	state.PreferredSemester = query.Semester
+	state.PreferredLesson = query.Lesson
+	setUserState(firebaseClient, userID, state)
+	if lessonGiven {
+		// 記錄課次進度，供學習週報計算本週學到的字
+		if err := services.NewProgressService(firebaseClient).RecordLesson(userID, query.Publisher, query.Grade, query.Semester, query.Lesson); err != nil {
+			log.Printf("Error recording lesson progress: %v", err)
+		}
+	}
+
+	return performCumulativeQuery(event, query.QueryText, bot, firebaseClient, userID, state)
+}
+
+// 語詞的學習狀況：以課本語詞斷詞，列出課本語詞已學過或尚未學過
+func wordNotes(queryText string, vocabulary *cumulativeVocabulary) string {
+	learned := make(map[string]bool, len(vocabulary.Words))
+	for _, word := range vocabulary.Words {
+		learned[word] = true
+	}
+	textbook := make(map[string]bool, len(vocabulary.AllWords))
+	for _, word := range vocabulary.AllWords {
+		textbook[word] = true
+	}
+
+	var learnedWords, notLearnedWords []string
+	seen := make(map[string]bool)
+	for _, segment := range utils.SegmentWords(queryText, vocabulary.AllWords) {
+		// 只列出課本語詞；一般詞典的詞僅用於斷詞
+		if !textbook[segment] || seen[segment] {
+			continue
+		}
+		seen[segment] = true
+		if learned[segment] {
+			learnedWords = append(learnedWords, segment)
+		} else {
+			notLearnedWords = append(notLearnedWords, segment)
+		}
+	}
+
+	if len(learnedWords) == 0 && len(notLearnedWords) == 0 {
+		return ""
+	}
+	notes := "\n📖 語詞：\n"
+	if len(learnedWords) > 0 {
+		notes += fmt.Sprintf("✅ 已學過：%s\n", strings.Join(learnedWords, "、"))
+	}
+	if len(notLearnedWords) > 0 {
+		notes += fmt.Sprintf("❌ 尚未學過：%s\n", strings.Join(notLearnedWords, "、"))
+	}
+	return notes
+}
+
+// 破音字的讀音說明：依查詢字詞判斷讀音，並檢查這個讀音是否已經教過
+func readingNotes(firebaseClient *config.FirebaseClient, queryText string, learnedChars []string, state *models.UserState) string {
+	if len(learnedChars) == 0 {
+		return ""
+	}
+	characters, err := services.NewCharacterService(firebaseClient).GetCharacters(learnedChars)
+	if err != nil {
+		log.Printf("Error getting characters for reading check: %v", err)
+		return ""
+	}
+
+	notes := ""
+	seen := make(map[string]bool)
+	for _, char := range learnedChars {
+		info, ok := characters[char]
+		if !ok || !info.IsPolyphonic() || seen[char] {
+			continue
+		}
+		seen[char] = true
+		reading, word := info.ReadingInContext(queryText)
+		if reading == nil {
+			continue
+		}
+		switch {
+		case !reading.HasLessonData(state.Publisher):
+			notes += fmt.Sprintf("🔤 「%s」在「%s」唸 %s\n", char, word, reading.Phonetic)
+		case reading.TaughtBy(state.Publisher, state.Grade, state.Semester, state.Lesson):
+			notes += fmt.Sprintf("🔤 「%s」在「%s」唸 %s，這個讀音已學過\n", char, word, reading.Phonetic)
+		default:
+			notes += fmt.Sprintf("⚠️ 「%s」在「%s」唸 %s，這個讀音還沒學過\n", char, word, reading.Phonetic)
+		}
+	}
+	return notes
+}
+
 // 重設用戶偏好設定
 func resetUserPreferences(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string) error {
-	state := getUserState(firebaseClient, userID)
+	state := getEventUserState(firebaseClient, event, userID)
 	if state.PreferredPublisher != "" || state.PreferredGrade > 0 || state.PreferredSemester > 0 {
 		// 清除偏好設定但保留其他狀態
 		state.PreferredPublisher = ""
 		state.PreferredGrade = 0
 		state.PreferredSemester = 0
+		state.PreferredLesson = 0
 		setUserState(firebaseClient, userID, state)
 		return replyMessage(event, bot, "✅ 已清除您的偏好設定記憶\n\n下次查詢時將重新選擇出版社、年級和學期")
 	} else {
@@ -456,42 +710,30 @@ func resetUserPreferences(event *linebot.Event, bot *linebot.Client, firebaseCli
 }
 
 func handleHelp(event *linebot.Event, bot *linebot.Client) error {
-	helpText := `🎓 中文學習小幫手使用說明
-
-📝 功能介紹：
-• 輸入「查詢累積字詞」開始累積字詞查詢
-• 輸入「印字帖」前往印字帖網站下載練習字帖
-• 輸入「平板學寫字」前往平板練字頁面
-• 輸入「重設偏好」清除記憶的版本/年級/學期設定
-• 輸入「退出」退出當前模式
-
-💡 使用範例：
-1. 輸入「查詢累積字詞」
-2. 首次使用：選擇出版社（康軒/南一/翰林）、年級（1-6年級）、學期（上學期/下學期）
-3. 再次使用：系統會記住您的設定，直接輸入課次
-4. 輸入課次（例如：5）
-5. 輸入要查詢的字詞（例如：我好喜歡吃飯配菜）
-
-📝 印字帖功能：
-• 輸入「印字帖」前往 hanziplay.com 下載練習字帖
-• 如果已設定偏好版本，會自動帶入出版社/年級/學期參數
-
-✍️ 平板學寫字功能：
-• 輸入「平板學寫字」前往平板練字頁面
-• 可在平板上直接練習寫字，提供即時筆劃指導
-
-🔧 其他指令：
-• 「重設偏好」- 清除記憶的出版社/年級/學期設定
-• 「退出」- 退出當前查詢模式
-
-❓ 需要協助請輸入「幫助」`
+	return replyMessageWithQuickReply(event, bot, buildHelpText(), featuredCommandQuickReply())
+}
 
-	return replyMessage(event, bot, helpText)
+// 累積學過的生字與語詞
+type cumulativeVocabulary struct {
+	Characters []string // 累積學過的生字
+	Words      []string // 累積學過的語詞
+	AllWords   []string // 該出版社所有課本語詞（含尚未教到的課），供斷詞使用
 }
 
-// 獲取累積生字列表（參考demo.js的邏輯）
+// 獲取累積生字列表
 func getCumulativeCharacters(firebaseClient *config.FirebaseClient, publisher string, grade int, semester int, lesson int) ([]string, error) {
+	vocabulary, err := getCumulativeVocabulary(firebaseClient, publisher, grade, semester, lesson)
+	if err != nil {
+		return nil, err
+	}
+	return vocabulary.Characters, nil
+}
+
+// 獲取累積生字與語詞列表（參考demo.js的邏輯）
+func getCumulativeVocabulary(firebaseClient *config.FirebaseClient, publisher string, grade int, semester int, lesson int) (*cumulativeVocabulary, error) {
 	allCharacters := make(map[string]bool)
+	learnedWords := make(map[string]bool)
+	allWords := make(map[string]bool)
 
 	// 查詢所有符合條件的課程
 	lessonsRef := firebaseClient.Firestore.Collection("lessons")
@@ -523,6 +765,18 @@ func getCumulativeCharacters(firebaseClient *config.FirebaseClient, publisher st
 			(int(lessonGrade) == grade && int(lessonSemester) < semester) ||
 			(int(lessonGrade) == grade && int(lessonSemester) == semester && int(lessonNumber) <= lesson)
 
+		// 提取課程中的語詞
+		if wordsData, ok := data["words"].([]interface{}); ok {
+			for _, wordInterface := range wordsData {
+				if word, ok := wordInterface.(string); ok && word != "" {
+					allWords[word] = true
+					if isInRange {
+						learnedWords[word] = true
+					}
+				}
+			}
+		}
+
 		if isInRange {
 			// 提取課程中的字符
 			if charactersData, exists := data["characters"]; exists {
@@ -546,12 +800,19 @@ func getCumulativeCharacters(firebaseClient *config.FirebaseClient, publisher st
 	}
 
 	// 轉換為字符串切片
-	result := make([]string, 0, len(allCharacters))
-	for char := range allCharacters {
-		result = append(result, char)
-	}
+	return &cumulativeVocabulary{
+		Characters: mapKeys(allCharacters),
+		Words:      mapKeys(learnedWords),
+		AllWords:   mapKeys(allWords),
+	}, nil
+}
 
-	return result, nil
+func mapKeys(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for key := range set {
+		result = append(result, key)
+	}
+	return result
 }
 
 // 檢查字符串切片是否包含指定字符
@@ -564,25 +825,33 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// 去除重複的字，保留第一次出現的順序
+func uniqueCharacters(chars []string) []string {
+	var result []string
+	for _, char := range chars {
+		if !contains(result, char) {
+			result = append(result, char)
+		}
+	}
+	return result
+}
+
 func handleUnknownMessage(event *linebot.Event, bot *linebot.Client) error {
-	return replyMessage(event, bot, "抱歉，我不太理解您的意思。請輸入「幫助」查看使用說明，或輸入「查詢累積字詞」開始查詢。")
+	return replyMessageWithQuickReply(event, bot, "抱歉，我不太理解您的意思。請輸入「幫助」查看使用說明，或點選下方的指令開始使用。", featuredCommandQuickReply())
 }
 
 func handleFollow(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
-	welcomeText := `🎉 歡迎使用中文學習小幫手！
-
-我可以幫助您：
-📚 查詢累積字詞
-
-請輸入「查詢累積字詞」開始使用，或輸入「幫助」查看詳細說明！`
-
-	return replyMessage(event, bot, welcomeText)
+	return replyMessageWithQuickReply(event, bot, buildWelcomeText(), featuredCommandQuickReply())
 }
 
 func handleUnfollow(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
 	// 清除用戶狀態
 	userID := event.Source.UserID
 	clearUserState(firebaseClient, userID)
+	// 取消所有推播訂閱
+	if err := services.NewSubscriptionService(firebaseClient).DeleteSubscription(userID); err != nil {
+		log.Printf("Error deleting subscription: %v", err)
+	}
 	return nil
 }
 
@@ -598,15 +867,20 @@ func replyMessage(event *linebot.Event, bot *linebot.Client, text string) error
 	return err
 }
 
+func replyMessages(event *linebot.Event, bot *linebot.Client, messages ...linebot.SendingMessage) error {
+	_, err := bot.ReplyMessage(event.ReplyToken, messages...).Do()
+	return err
+}
+
 func replyMessageWithQuickReply(event *linebot.Event, bot *linebot.Client, text string, quickReply *linebot.QuickReplyItems) error {
 	message := linebot.NewTextMessage(text).WithQuickReplies(quickReply)
 	_, err := bot.ReplyMessage(event.ReplyToken, message).Do()
 	return err
 }
 
-// 處理印字帖功能
-func handlePrintWorksheet(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string) error {
-	state := getUserState(firebaseClient, userID)
+// 未設定字帖下載網址時，改為提供 hanziplay.com 的印字帖頁面
+func handleExternalWorksheet(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, selection url.Values) error {
+	state := getEventUserState(firebaseClient, event, userID)
 	
 	// 建立基本 URL
 	baseURL := "https://hanziplay.com/practice-sheet"
@@ -630,6 +904,7 @@ func handlePrintWorksheet(event *linebot.Event, bot *linebot.Client, firebaseCli
 			baseURL += fmt.Sprintf("?publisher=%s&grade=%d&semester=%d", 
 				publisher, state.PreferredGrade, state.PreferredSemester)
 		}
+		baseURL = appendURLQuery(baseURL, selection)
 		
 		semesterText := "上學期"
 		if state.PreferredSemester == 2 {
@@ -642,6 +917,7 @@ func handlePrintWorksheet(event *linebot.Event, bot *linebot.Client, firebaseCli
 		return replyMessage(event, bot, responseText)
 	} else {
 		// 沒有偏好設定，直接提供基本連結
+		baseURL = appendURLQuery(baseURL, selection)
 		responseText := fmt.Sprintf("📝 印字帖功能\n\n🔗 請點擊連結前往印字帖頁面：\n%s\n\n💡 建議您先使用「查詢累積字詞」功能設定版本年級學期，下次使用印字帖功能時會自動帶入您的設定", baseURL)
 		
 		return replyMessage(event, bot, responseText)
@@ -650,15 +926,14 @@ func handlePrintWorksheet(event *linebot.Event, bot *linebot.Client, firebaseCli
 
 // 處理平板學寫字功能
 func handleTabletPractice(event *linebot.Event, bot *linebot.Client) error {
-	url := "https://hanziplay.com/characters/practice"
-	responseText := fmt.Sprintf("✍️ 平板學寫字\n\n🔗 請點擊連結前往平板練字頁面：\n%s\n\n💡 您可以在平板上直接練習寫字，提供即時筆劃指導", url)
+	responseText := fmt.Sprintf("✍️ 平板學寫字\n\n🔗 請點擊連結前往平板練字頁面：\n%s\n\n💡 您可以在平板上直接練習寫字，提供即時筆劃指導\n✍️ 輸入「筆順 森」可以看單字的筆順分解圖和動畫", strokePracticeURL)
 	
 	return replyMessage(event, bot, responseText)
 }
 
 // 顯示用戶設定
 func showUserSettings(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string) error {
-	state := getUserState(firebaseClient, userID)
+	state := getEventUserState(firebaseClient, event, userID)
 	
 	var response string
 	
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWordNotes(t *testing.T) {
+	vocabulary := &cumulativeVocabulary{
+		Words:    []string{"森林"},
+		AllWords: []string{"森林", "散步", "小鳥"},
+	}
+
+	notes := wordNotes("小明在森林散步，森林很大", vocabulary)
+	if !strings.Contains(notes, "✅ 已學過：森林\n") || !strings.Contains(notes, "❌ 尚未學過：散步\n") {
+		t.Errorf("wordNotes = %q", notes)
+	}
+	if strings.Contains(notes, "小鳥") {
+		t.Errorf("wordNotes lists a word that is not in the text: %q", notes)
+	}
+
+	// 沒有課本語詞時不顯示語詞說明
+	if notes := wordNotes("今天天氣很好", vocabulary); notes != "" {
+		t.Errorf("wordNotes without lesson words = %q, want empty", notes)
+	}
+}
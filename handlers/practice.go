@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
+)
+
+// 開始練習：優先出到期需要複習的字；challenge 為限時的挑戰模式
+func startPractice(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *models.UserState, challenge bool) error {
+	var focusCharacters []string
+	dueItems, err := services.NewReviewService(firebaseClient).GetDueItems(models.UserStateKey(userID, state.GroupID), time.Now())
+	if err != nil {
+		log.Printf("Error getting due review items: %v", err)
+	}
+	for _, item := range dueItems {
+		focusCharacters = append(focusCharacters, item.Character)
+	}
+
+	practiceConfig := models.PracticeConfig{Type: models.PracticeTypeMixed}
+	if challenge {
+		practiceConfig = services.ChallengeConfig()
+	}
+	practiceConfig.StartTime = eventTime(event).UnixMilli()
+	practiceConfig.GroupID = groupIDOf(event)
+
+	// 已設定課次時，部首題、填空題以已學過的字詞出題
+	if state.PreferredPublisher != "" && state.PreferredGrade > 0 && state.PreferredSemester > 0 && state.PreferredLesson > 0 {
+		vocabulary, err := getCumulativeVocabulary(firebaseClient, state.PreferredPublisher, state.PreferredGrade, state.PreferredSemester, state.PreferredLesson)
+		if err != nil {
+			log.Printf("Error getting learned vocabulary for practice: %v", err)
+		} else {
+			practiceConfig.Learned = vocabulary.Characters
+			practiceConfig.LearnedWords = vocabulary.Words
+		}
+	}
+
+	practiceService := services.NewPracticeService(firebaseClient)
+	session, err := practiceService.StartSession(userID, practiceConfig, focusCharacters)
+	if err != nil {
+		return utils.NewTransientError("failed to start practice session", err)
+	}
+
+	state.Mode = "practice"
+	state.PracticeSessionID = session.ID
+	setUserState(firebaseClient, userID, state)
+	switchRichMenu(bot, userID, state)
+
+	intro := fmt.Sprintf("✏️ 開始練習，共 %d 題", len(session.Questions))
+	if challenge {
+		intro = fmt.Sprintf("⏱️ 挑戰模式開始！%d 秒內回答 %d 題，答得越快分數越高", session.TimeLimit, len(session.Questions))
+	}
+	if len(dueItems) > 0 {
+		intro += fmt.Sprintf("（包含 %d 個該複習的字）", len(dueItems))
+	}
+	intro += "\n輸入「退出」可隨時結束"
+
+	messages := []linebot.SendingMessage{linebot.NewTextMessage(intro)}
+	return replyMessages(event, bot, append(messages, createPracticeQuestionMessages(session, eventTime(event))...)...)
+}
+
+// 處理練習模式中的作答
+func handlePracticeMode(event *linebot.Event, userText string, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *models.UserState) error {
+	practiceService := services.NewPracticeService(firebaseClient)
+	session, err := practiceService.GetSession(state.PracticeSessionID)
+	if err != nil {
+		state.Mode = ""
+		state.PracticeSessionID = ""
+		setUserState(firebaseClient, userID, state)
+		switchRichMenu(bot, userID, state)
+		return utils.NewTransientError("failed to load practice session", err)
+	}
+
+	// 挑戰模式超過時間後不再接受作答，直接結算
+	answeredAt := eventTime(event)
+	if session.TimedOut(answeredAt.UnixMilli()) {
+		return finishPractice(event, bot, firebaseClient, userID, state, practiceService, session, "⏰ 時間到！最後這題不計分")
+	}
+
+	isCorrect, explanation, err := practiceService.SubmitAnswer(session, userText, answeredAt)
+	if err != nil {
+		return utils.NewTransientError("failed to submit practice answer", err)
+	}
+
+	feedback := "❌ 再加油！\n" + explanation
+	if isCorrect {
+		feedback = "⭕ 答對了！\n" + explanation
+	}
+
+	if session.CurrentQuestion() != nil {
+		// 群組中的模式閒置過久會結束，作答時更新狀態的時間
+		if state.GroupID != "" {
+			setUserState(firebaseClient, userID, state)
+		}
+		messages := []linebot.SendingMessage{linebot.NewTextMessage(feedback)}
+		return replyMessages(event, bot, append(messages, createPracticeQuestionMessages(session, answeredAt)...)...)
+	}
+
+	// 全部作答完畢
+	return finishPractice(event, bot, firebaseClient, userID, state, practiceService, session, feedback)
+}
+
+// 結束練習：更新統計、離開練習模式，並回覆成績（挑戰模式另附個人最佳紀錄）
+func finishPractice(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *models.UserState, practiceService *services.PracticeService, session *models.PracticeSession, feedback string) error {
+	summary := completePractice(bot, firebaseClient, userID, state, practiceService, session)
+	return replyMessages(event, bot, linebot.NewTextMessage(feedback), linebot.NewTextMessage(summary))
+}
+
+// ExpireChallengesJob 排程工作：結算時間到後用戶沒再作答的挑戰，讓用戶離開練習模式並推播成績
+func ExpireChallengesJob(bot *linebot.Client, firebaseClient *config.FirebaseClient, messagingService *services.MessagingService) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		practiceService := services.NewPracticeService(firebaseClient)
+		sessions, err := practiceService.ListExpiredChallenges(time.Now())
+		if err != nil {
+			log.Printf("Error listing expired challenges: %v", err)
+			return
+		}
+
+		for _, session := range sessions {
+			if ctx.Err() != nil {
+				return
+			}
+			state := getUserState(firebaseClient, session.UserKey())
+			state.GroupID = session.GroupID
+			// 用戶已退出或開始了別的練習，不結算也不打擾
+			if state.Mode != "practice" || state.PracticeSessionID != session.ID {
+				continue
+			}
+
+			summary := completePractice(bot, firebaseClient, session.UserID, state, practiceService, session)
+			to := session.UserID
+			if session.GroupID != "" {
+				to = session.GroupID
+			}
+			if err := messagingService.Push(ctx, to, linebot.NewTextMessage("⏰ 時間到！挑戰已自動結算"), linebot.NewTextMessage(summary)); err != nil {
+				log.Printf("Error pushing challenge result to %s: %v", to, err)
+			}
+		}
+	}
+}
+
+// 更新統計、離開練習模式，回傳成績文字
+func completePractice(bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, state *models.UserState, practiceService *services.PracticeService, session *models.PracticeSession) string {
+	stats, err := practiceService.CompleteSession(session)
+	if err != nil {
+		log.Printf("Error completing practice session: %v", err)
+	}
+	state.Mode = ""
+	state.PracticeSessionID = ""
+	setUserState(firebaseClient, userID, state)
+	switchRichMenu(bot, userID, state)
+
+	summary := fmt.Sprintf("🎉 練習完成！\n\n📈 得分：%d/%d", session.Score, session.TotalScore)
+	if session.TimeLimit > 0 {
+		summary = fmt.Sprintf("🏁 挑戰結束！\n\n📈 答對：%d/%d 題\n⭐ 挑戰得分：%d 分", session.Score, session.TotalScore, session.Points)
+		board, rank, err := practiceService.RecordChallenge(session)
+		if err != nil {
+			log.Printf("Error recording challenge result: %v", err)
+		}
+		if board != nil {
+			if rank == 1 {
+				summary += "\n🏆 刷新個人最佳紀錄！"
+			} else if rank > 0 {
+				summary += fmt.Sprintf("\n🥇 進入個人紀錄第 %d 名", rank)
+			}
+			summary += "\n\n" + formatChallengeBoard(board)
+		}
+	}
+	if stats != nil {
+		summary += fmt.Sprintf("\n🔥 連續練習 %d 天", stats.Streak)
+	}
+	var missed []string
+	for _, answer := range session.Answers {
+		if !answer.IsCorrect && answer.Character != "" {
+			missed = append(missed, answer.Character)
+		}
+	}
+	if len(missed) > 0 {
+		summary += fmt.Sprintf("\n\n📝 答錯的字已加入複習：%s", joinUnique(missed))
+	}
+	if session.TimeLimit > 0 {
+		summary += "\n\n💡 輸入「挑戰模式」再挑戰一次"
+	} else {
+		summary += "\n\n💡 輸入「練習」再來一次"
+	}
+	return summary
+}
+
+// 查看挑戰模式的個人最佳紀錄
+func handleChallengeBoard(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string) error {
+	board, err := services.NewPracticeService(firebaseClient).GetChallengeBoard(userID)
+	if err != nil {
+		return utils.NewTransientError("failed to get challenge records", err)
+	}
+	if len(board.Records) == 0 {
+		return replyMessage(event, bot, "🏆 還沒有挑戰紀錄\n\n💡 輸入「挑戰模式」開始限時挑戰")
+	}
+	return replyMessage(event, bot, formatChallengeBoard(board)+"\n\n💡 輸入「挑戰模式」再挑戰一次")
+}
+
+// 個人最佳紀錄的文字
+func formatChallengeBoard(board *models.ChallengeBoard) string {
+	medals := []string{"🥇", "🥈", "🥉"}
+	text := "🏆 我的挑戰紀錄"
+	for i, record := range board.Records {
+		rank := fmt.Sprintf("%d.", i+1)
+		if i < len(medals) {
+			rank = medals[i]
+		}
+		text += fmt.Sprintf("\n%s %d 分（答對 %d/%d，%d 秒）", rank, record.Points, record.Correct, record.Total, record.Duration/1000)
+	}
+	return text
+}
+
+// 事件發生的時間，沒有時間戳時使用目前時間
+func eventTime(event *linebot.Event) time.Time {
+	if event.Timestamp.IsZero() {
+		return time.Now()
+	}
+	return event.Timestamp
+}
+
+// 建立題目訊息，選擇題以快速回覆呈現選項；有題目圖片時先傳送圖片，挑戰模式顯示剩餘時間
+func createPracticeQuestionMessages(session *models.PracticeSession, now time.Time) []linebot.SendingMessage {
+	question := session.CurrentQuestion()
+	text := fmt.Sprintf("第 %d/%d 題（%s）\n\n%s", len(session.Answers)+1, len(session.Questions), utils.PracticeTypeDisplayName(question.Type), question.Question)
+	if session.TimeLimit > 0 {
+		text = fmt.Sprintf("⏱️ 剩下 %d 秒\n", session.RemainingSeconds(now.UnixMilli())) + text
+	}
+
+	var messages []linebot.SendingMessage
+	if question.ImageURL != "" {
+		messages = append(messages, linebot.NewImageMessage(question.ImageURL, question.ImageURL))
+	}
+
+	message := linebot.NewTextMessage(text)
+	if len(question.Options) == 0 {
+		return append(messages, message)
+	}
+
+	items := &linebot.QuickReplyItems{}
+	for _, option := range question.Options {
+		items.Items = append(items.Items, &linebot.QuickReplyButton{
+			Action: &linebot.MessageAction{Label: option, Text: option},
+		})
+	}
+	return append(messages, message.WithQuickReplies(items))
+}
+
+// 去除重複並以「、」連接
+func joinUnique(items []string) string {
+	seen := make(map[string]bool)
+	result := ""
+	for _, item := range items {
+		if seen[item] {
+			continue
+		}
+		seen[item] = true
+		if result != "" {
+			result += "、"
+		}
+		result += item
+	}
+	return result
+}
+
+// 所有請求共用同一個圖文選單服務，選單 ID 只需向 LINE 查詢一次
+var (
+	richMenuServiceOnce sync.Once
+	richMenuService     *services.RichMenuService
+)
+
+// 依用戶狀態切換圖文選單（練習中使用練習選單），失敗時僅記錄日誌
+// 圖文選單只顯示在私訊中，群組中的練習不切換
+func switchRichMenu(bot *linebot.Client, userID string, state *models.UserState) {
+	if state.GroupID != "" {
+		return
+	}
+	practicing := state.Mode == "practice"
+	richMenuServiceOnce.Do(func() {
+		richMenuService = services.NewRichMenuService(bot)
+	})
+	var err error
+	if practicing {
+		err = richMenuService.SwitchToPractice(userID)
+	} else {
+		err = richMenuService.SwitchToDefault(userID)
+	}
+	if err != nil {
+		log.Printf("Error switching rich menu for %s: %v", userID, err)
+	}
+}
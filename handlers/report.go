@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
+)
+
+// 顯示最近七天的學習週報
+func handleWeeklyReport(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, textOnly bool) error {
+	state := getEventUserState(firebaseClient, event, userID)
+	if state.PreferredPublisher == "" || state.PreferredGrade == 0 || state.PreferredSemester == 0 {
+		return utils.NewUserInputError("📊 學習週報需要課程進度，請先使用「查詢累積字詞」設定出版社、年級、學期和課次。")
+	}
+
+	report, err := services.NewReportService(firebaseClient).BuildWeeklyReport(userID, state, time.Now())
+	if err != nil {
+		return utils.NewTransientError("failed to build weekly report", err)
+	}
+
+	if textOnly {
+		return replyMessage(event, bot, utils.CreateWeeklyReportText(report))
+	}
+
+	quickReply := &linebot.QuickReplyItems{
+		Items: []*linebot.QuickReplyButton{
+			{Action: &linebot.MessageAction{Label: "文字版", Text: "學習週報 文字"}},
+			{Action: &linebot.MessageAction{Label: "每週自動推播", Text: "訂閱學習週報"}},
+		},
+	}
+	_, err = bot.ReplyMessage(event.ReplyToken, utils.CreateWeeklyReportFlex(report).WithQuickReplies(quickReply)).Do()
+	return err
+}
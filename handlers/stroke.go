@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
+)
+
+// 顯示筆順：傳送筆順分解圖，並附上筆順動畫連結
+func handleStrokeOrder(event *linebot.Event, bot *linebot.Client, char string) error {
+	if char == "" {
+		return utils.NewUserInputError("請輸入要看筆順的字，例如：筆順 森")
+	}
+	if converted, changed := utils.NormalizeToTraditional(char); changed {
+		char = converted
+	}
+
+	strokeService := services.NewStrokeService()
+	data := strokeService.GetStrokeData(char)
+	practiceURL := strokePracticeURL + "?char=" + url.QueryEscape(char)
+	if data == nil {
+		return utils.NewUserInputError(fmt.Sprintf("✍️ 目前沒有「%s」的筆順資料\n\n可以到平板練字頁面練習：\n%s", char, practiceURL))
+	}
+
+	stepsURL := strokeService.ImageURL(char, services.StrokeStepsFile)
+	if stepsURL == "" {
+		return utils.NewPermanentError("stroke images require PUBLIC_BASE_URL", nil)
+	}
+
+	responseText := fmt.Sprintf("✍️「%s」共 %d 畫，紅色是每一步新寫的筆畫\n\n🎬 筆順動畫：\n%s\n\n📱 平板練字：\n%s",
+		char, data.StrokeCount(), strokeService.ImageURL(char, services.StrokeAnimationFile), practiceURL)
+	return replyMessages(event, bot,
+		linebot.NewImageMessage(stepsURL, stepsURL),
+		linebot.NewTextMessage(responseText))
+}
+
+// StrokeImageHandler 產生筆順分解圖、筆順動畫及練習題用的筆順圖片
+// 伺服器端沒有快取，每次請求都重新繪製（動畫最耗時）；同一網址的圖片內容固定，
+// 目前依賴 Cache-Control 讓 LINE 與瀏覽器快取。請求量變大時，應在這裡加上以網址為鍵、有容量上限的快取
+func StrokeImageHandler(strokeService *services.StrokeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		image, contentType, err := strokeService.RenderImage(c.Param("char"), c.Param("file"))
+		if errors.Is(err, services.ErrStrokeImageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Error rendering stroke image %s/%s: %v", c.Param("char"), c.Param("file"), err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to render stroke image"})
+			return
+		}
+
+		// 筆順資料不會變動，讓 LINE 與瀏覽器快取
+		c.Header("Cache-Control", "public, max-age=86400")
+		c.Data(http.StatusOK, contentType, image)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
+)
+
+// 勿擾時段格式，例如「勿擾時段 21-7」或「勿擾時段 21:00-07:00」
+var quietHoursPattern = regexp.MustCompile(`^勿擾時段\s*(\d{1,2})(?::00)?\s*[-~到至]\s*(\d{1,2})(?::00)?$`)
+
+// 處理訂閱相關指令，非訂閱指令時回傳 handled=false
+func handleSubscriptionCommand(event *linebot.Event, userText string, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string) (bool, error) {
+	switch {
+	case userText == "訂閱每日一字":
+		return true, updateSubscription(event, bot, firebaseClient, userID, func(sub *models.Subscription, state *models.UserState) (string, error) {
+			if state.PreferredPublisher == "" || state.PreferredGrade == 0 || state.PreferredSemester == 0 {
+				return "", utils.NewUserInputError("📅 訂閱每日一字前，請先使用「查詢累積字詞」設定出版社、年級和學期，才能從下一課挑選每日一字。")
+			}
+			sub.DailyCharacter = true
+			return "✅ 已訂閱每日一字\n\n每天早上會從下一課挑一個字，附上注音、部首、筆畫和例句。", nil
+		})
+	case userText == "取消每日一字":
+		return true, updateSubscription(event, bot, firebaseClient, userID, func(sub *models.Subscription, state *models.UserState) (string, error) {
+			sub.DailyCharacter = false
+			sub.PendingDaily = false
+			return "已取消每日一字", nil
+		})
+	case userText == "訂閱複習提醒":
+		return true, updateSubscription(event, bot, firebaseClient, userID, func(sub *models.Subscription, state *models.UserState) (string, error) {
+			sub.ReviewReminder = true
+			return "✅ 已訂閱複習提醒\n\n有到期需要複習的字時，會在晚上提醒您。", nil
+		})
+	case userText == "取消複習提醒":
+		return true, updateSubscription(event, bot, firebaseClient, userID, func(sub *models.Subscription, state *models.UserState) (string, error) {
+			sub.ReviewReminder = false
+			return "已取消複習提醒", nil
+		})
+	case userText == "訂閱學習週報":
+		return true, updateSubscription(event, bot, firebaseClient, userID, func(sub *models.Subscription, state *models.UserState) (string, error) {
+			sub.WeeklyReport = true
+			return "✅ 已訂閱學習週報\n\n每週日晚上會推播本週學到的字、下週預習、練習正確率和最常答錯的字。", nil
+		})
+	case userText == "取消學習週報":
+		return true, updateSubscription(event, bot, firebaseClient, userID, func(sub *models.Subscription, state *models.UserState) (string, error) {
+			sub.WeeklyReport = false
+			return "已取消學習週報", nil
+		})
+	case userText == "取消勿擾":
+		return true, updateSubscription(event, bot, firebaseClient, userID, func(sub *models.Subscription, state *models.UserState) (string, error) {
+			sub.QuietStart = -1
+			sub.QuietEnd = -1
+			return "已取消勿擾時段", nil
+		})
+	case strings.HasPrefix(userText, "勿擾時段"):
+		start, end, ok := parseQuietHours(userText)
+		if !ok {
+			return true, utils.NewUserInputError("請輸入正確的勿擾時段，例如：勿擾時段 21-7（晚上 9 點到早上 7 點）")
+		}
+		return true, updateSubscription(event, bot, firebaseClient, userID, func(sub *models.Subscription, state *models.UserState) (string, error) {
+			sub.QuietStart = start
+			sub.QuietEnd = end
+			return fmt.Sprintf("🌙 已設定勿擾時段：%02d:00 - %02d:00\n\n這段時間內不會推播，每日一字會在勿擾結束後補送。", start, end), nil
+		})
+	case userText == "我的訂閱":
+		return true, showSubscription(event, bot, firebaseClient, userID)
+	}
+	return false, nil
+}
+
+// 讀取訂閱設定、套用修改並儲存
+func updateSubscription(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, apply func(*models.Subscription, *models.UserState) (string, error)) error {
+	subscriptionService := services.NewSubscriptionService(firebaseClient)
+	sub, err := subscriptionService.GetSubscription(userID)
+	if err != nil {
+		return utils.NewTransientError("failed to load subscription", err)
+	}
+
+	state := getUserState(firebaseClient, userID)
+	responseText, err := apply(sub, state)
+	if err != nil {
+		return err
+	}
+
+	if err := subscriptionService.SaveSubscription(sub); err != nil {
+		return utils.NewTransientError("failed to save subscription", err)
+	}
+	return replyMessage(event, bot, responseText)
+}
+
+// 顯示訂閱設定
+func showSubscription(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string) error {
+	sub, err := services.NewSubscriptionService(firebaseClient).GetSubscription(userID)
+	if err != nil {
+		return utils.NewTransientError("failed to load subscription", err)
+	}
+
+	onOff := func(enabled bool) string {
+		if enabled {
+			return "✅ 已訂閱"
+		}
+		return "❌ 未訂閱"
+	}
+
+	response := fmt.Sprintf("🔔 我的訂閱\n\n📅 每日一字：%s\n📝 複習提醒：%s\n📊 學習週報：%s\n", onOff(sub.DailyCharacter), onOff(sub.ReviewReminder), onOff(sub.WeeklyReport))
+	if sub.HasQuietHours() {
+		response += fmt.Sprintf("🌙 勿擾時段：%02d:00 - %02d:00\n", sub.QuietStart, sub.QuietEnd)
+	} else {
+		response += "🌙 勿擾時段：未設定\n"
+	}
+	response += "\n💡 可輸入「訂閱每日一字」、「取消每日一字」、「訂閱複習提醒」、「取消複習提醒」、「訂閱學習週報」、「取消學習週報」、「勿擾時段 21-7」或「取消勿擾」"
+
+	return replyMessage(event, bot, response)
+}
+
+// 解析勿擾時段
+func parseQuietHours(text string) (int, int, bool) {
+	matches := quietHoursPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return 0, 0, false
+	}
+	start, _ := strconv.Atoi(matches[1])
+	end, _ := strconv.Atoi(matches[2])
+	if start > 23 || end > 24 || start == end%24 {
+		return 0, 0, false
+	}
+	return start, end % 24, true
+}
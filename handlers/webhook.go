@@ -1,16 +1,28 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/line/line-bot-sdk-go/v7/linebot"
 
 	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
 )
 
-func WebhookHandler(bot *linebot.Client, firebaseClient *config.FirebaseClient) gin.HandlerFunc {
+// 推播錯誤訊息的逾時時間
+const errorPushTimeout = 10 * time.Second
+
+func WebhookHandler(bot *linebot.Client, firebaseClient *config.FirebaseClient, deadLetterService *services.DeadLetterService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		events, err := bot.ParseRequest(c.Request)
 		if err != nil {
@@ -23,15 +35,101 @@ func WebhookHandler(bot *linebot.Client, firebaseClient *config.FirebaseClient)
 		}
 
 		for _, event := range events {
-			if err := handleEvent(event, bot, firebaseClient); err != nil {
-				log.Printf("Error handling event: %v", err)
-			}
+			processEvent(event, bot, firebaseClient, deadLetterService)
 		}
 
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	}
 }
 
+// 處理單一事件，確保 panic 或錯誤不會影響同批次的其他事件
+func processEvent(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, deadLetterService *services.DeadLetterService) {
+	var stack string
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				stack = string(debug.Stack())
+				err = utils.NewPermanentError("panic while handling event", fmt.Errorf("%v", r))
+			}
+		}()
+		return handleEvent(event, bot, firebaseClient)
+	}()
+	if err == nil {
+		return
+	}
+
+	kind := utils.ClassifyError(err)
+	log.Printf("Error handling event (%s): %v", kind, err)
+	if stack != "" {
+		log.Printf("Panic stack trace:\n%s", stack)
+	}
+
+	// 通知用戶處理失敗，避免沒有任何回應
+	// 處理過程中可能已用掉 reply token，回覆失敗時改用推播
+	notifyEventError(event, bot, firebaseClient, utils.UserFacingErrorMessage(err))
+
+	// 用戶輸入錯誤已回覆提示，不需要進入死信記錄
+	if kind == utils.ErrorKindUserInput || deadLetterService == nil {
+		return
+	}
+
+	payload, marshalErr := event.MarshalJSON()
+	if marshalErr != nil {
+		log.Printf("Error marshaling failed event: %v", marshalErr)
+	}
+
+	var userID string
+	if event.Source != nil {
+		userID = event.Source.UserID
+	}
+
+	deadLetterService.Record(&models.DeadLetter{
+		EventType: string(event.Type),
+		UserID:    userID,
+		ErrorKind: string(kind),
+		Error:     err.Error(),
+		Stack:     stack,
+		Payload:   string(payload),
+	})
+}
+
+// 回覆錯誤訊息，reply token 無效或已使用時改推播給事件來源
+func notifyEventError(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, text string) {
+	if event.ReplyToken != "" {
+		replyErr := replyMessage(event, bot, text)
+		if replyErr == nil {
+			return
+		}
+		log.Printf("Error sending error reply, falling back to push: %v", replyErr)
+	}
+
+	to := eventTargetID(event)
+	if to == "" {
+		log.Printf("Error sending error message: event has no source to push to")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), errorPushTimeout)
+	defer cancel()
+	if pushErr := services.NewMessagingService(bot, firebaseClient).Push(ctx, to, linebot.NewTextMessage(text)); pushErr != nil {
+		log.Printf("Error pushing error message to %s: %v", to, pushErr)
+	}
+}
+
+// 事件來源的推播對象：群組、聊天室或用戶
+func eventTargetID(event *linebot.Event) string {
+	if event.Source == nil {
+		return ""
+	}
+	switch event.Source.Type {
+	case linebot.EventSourceTypeGroup:
+		return event.Source.GroupID
+	case linebot.EventSourceTypeRoom:
+		return event.Source.RoomID
+	default:
+		return event.Source.UserID
+	}
+}
+
 func handleEvent(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
 	switch event.Type {
 	case linebot.EventTypeMessage:
@@ -42,6 +140,10 @@ func handleEvent(event *linebot.Event, bot *linebot.Client, firebaseClient *conf
 		return handleFollow(event, bot, firebaseClient)
 	case linebot.EventTypeUnfollow:
 		return handleUnfollow(event, bot, firebaseClient)
+	case linebot.EventTypeJoin:
+		return handleJoin(event, bot, firebaseClient)
+	case linebot.EventTypeLeave:
+		return handleLeave(event, bot, firebaseClient)
 	default:
 		log.Printf("Unknown event type: %s", event.Type)
 	}
@@ -49,6 +151,24 @@ func handleEvent(event *linebot.Event, bot *linebot.Client, firebaseClient *conf
 }
 
 func handlePostback(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient) error {
-	// 目前不處理postback事件
+	data, err := url.ParseQuery(event.Postback.Data)
+	if err != nil {
+		return utils.NewPermanentError("invalid postback data", err)
+	}
+
+	switch data.Get("action") {
+	case "command":
+		// 圖文選單按鈕：視同用戶輸入指令
+		return handleUserText(event, data.Get("text"), bot, firebaseClient)
+	case "search":
+		// 找字結果翻頁
+		page, _ := strconv.Atoi(data.Get("page"))
+		return handleCharacterSearch(event, bot, firebaseClient, event.Source.UserID, data.Get("q"), page)
+	case "lookup":
+		// 點選查詢結果中的字：顯示單字卡片
+		return handleCharacterLookup(event, bot, firebaseClient, event.Source.UserID, data.Get("char"))
+	default:
+		log.Printf("Unknown postback action: %s", data.Get("action"))
+	}
 	return nil
-}
\ No newline at end of file
+}
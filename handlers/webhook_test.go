@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+func TestEventTargetID(t *testing.T) {
+	tests := []struct {
+		source *linebot.EventSource
+		want   string
+	}{
+		{nil, ""},
+		{&linebot.EventSource{Type: linebot.EventSourceTypeUser, UserID: "U1"}, "U1"},
+		{&linebot.EventSource{Type: linebot.EventSourceTypeGroup, GroupID: "C1", UserID: "U1"}, "C1"},
+		{&linebot.EventSource{Type: linebot.EventSourceTypeRoom, RoomID: "R1", UserID: "U1"}, "R1"},
+	}
+	for _, tt := range tests {
+		if got := eventTargetID(&linebot.Event{Source: tt.source}); got != tt.want {
+			t.Errorf("eventTargetID(%+v) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
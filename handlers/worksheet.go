@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
+)
+
+// 一次最多印幾課
+const maxWorksheetLessons = 20
+
+const worksheetUsage = "📝 印字帖用法：\n印字帖\n印字帖 第3-5課 米字格 描紅5格 不要注音\n印字帖 生字\n印字帖 複習"
+
+// 處理印字帖功能：未指定內容時讓用戶選擇要印的課次、上次查詢的生字或待複習的字
+func handlePrintWorksheet(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, args string) error {
+	options, rest := utils.ParseWorksheetOptions(args)
+	selection, rest := utils.ParseWorksheetSelection(rest)
+	if len(rest) > 0 {
+		return utils.NewUserInputError(fmt.Sprintf("看不懂「%s」\n\n%s", strings.Join(rest, " "), worksheetUsage))
+	}
+
+	state := getEventUserState(firebaseClient, event, userID)
+	if selection.Source == "" {
+		return replyWorksheetMenu(event, bot, state, args)
+	}
+
+	title, chars, err := resolveWorksheetSelection(firebaseClient, userID, state, selection)
+	if err != nil {
+		return err
+	}
+
+	worksheetService := services.NewWorksheetService(firebaseClient)
+	if !worksheetService.Enabled() {
+		return handleExternalWorksheet(event, bot, firebaseClient, userID, externalWorksheetQuery(selection, chars))
+	}
+	return replyWorksheetLink(event, bot, worksheetService, title, chars, options)
+}
+
+// 字帖內容選單：每個選項以快速回覆送出完整的印字帖指令（保留已輸入的版面選項）
+func replyWorksheetMenu(event *linebot.Event, bot *linebot.Client, state *models.UserState, args string) error {
+	var items []*linebot.QuickReplyButton
+	addItem := func(label string, selection string) {
+		text := strings.TrimSpace("印字帖 " + selection + " " + args)
+		items = append(items, &linebot.QuickReplyButton{
+			Action: linebot.NewPostbackAction(label, url.Values{"action": {"command"}, "text": {text}}.Encode(), "", text, "", ""),
+		})
+	}
+
+	responseText := "📝 要印哪些字的字帖呢？\n\n"
+	if hasWorksheetLesson(state) {
+		lesson := state.PreferredLesson
+		responseText += fmt.Sprintf("📘 目前進度：%s %d年級%s 第%d課\n", state.PreferredPublisher, state.PreferredGrade, semesterName(state.PreferredSemester), lesson)
+		addItem(fmt.Sprintf("📘 第%d課", lesson), fmt.Sprintf("第%d課", lesson))
+		if lesson > 1 {
+			addItem(fmt.Sprintf("📚 第1-%d課", lesson), fmt.Sprintf("第1-%d課", lesson))
+		}
+	} else {
+		responseText += "💡 使用「查詢累積字詞」設定課程和課次後，就能依課次印字帖\n"
+	}
+	if len(state.LastUnlearnedCharacters) > 0 {
+		addItem("❓ 上次查詢的生字", "生字")
+	}
+	addItem("🔁 待複習的字", "複習")
+
+	responseText += "\n也可以直接輸入，例如：\n印字帖 第3課\n印字帖 第2-4課 米字格\n印字帖 生字\n印字帖 複習 描紅5格"
+	return replyMessageWithQuickReply(event, bot, responseText, &linebot.QuickReplyItems{Items: items})
+}
+
+// 依選擇取得字帖標題與要練習的字
+func resolveWorksheetSelection(firebaseClient *config.FirebaseClient, userID string, state *models.UserState, selection models.WorksheetSelection) (string, []string, error) {
+	switch selection.Source {
+	case models.WorksheetSourceUnlearned:
+		if len(state.LastUnlearnedCharacters) == 0 {
+			return "", nil, utils.NewUserInputError("📝 上次查詢沒有尚未學過的字，請先使用「查詢累積字詞」查詢")
+		}
+		return "查詢生字練習", state.LastUnlearnedCharacters, nil
+
+	case models.WorksheetSourceReview:
+		items, err := services.NewReviewService(firebaseClient).GetDueItems(models.UserStateKey(userID, state.GroupID), time.Now())
+		if err != nil {
+			return "", nil, utils.NewTransientError("failed to get due review items", err)
+		}
+		if len(items) == 0 {
+			return "", nil, utils.NewUserInputError("🔁 目前沒有需要複習的字")
+		}
+		var chars []string
+		for _, item := range items {
+			chars = append(chars, item.Character)
+		}
+		return "複習字練習", chars, nil
+
+	default:
+		if !hasWorksheetLesson(state) {
+			return "", nil, utils.NewUserInputError("📝 依課次印字帖前，請先使用「查詢累積字詞」設定課程和課次")
+		}
+		if selection.ToLesson-selection.FromLesson+1 > maxWorksheetLessons {
+			return "", nil, utils.NewUserInputError(fmt.Sprintf("📝 一次最多印 %d 課", maxWorksheetLessons))
+		}
+
+		lessonService := services.NewLessonService(firebaseClient)
+		var chars []string
+		for lessonNumber := selection.FromLesson; lessonNumber <= selection.ToLesson; lessonNumber++ {
+			lesson, err := lessonService.GetLesson(state.PreferredPublisher, state.PreferredGrade, state.PreferredSemester, lessonNumber)
+			if err != nil {
+				return "", nil, utils.NewUserInputError(fmt.Sprintf("找不到 %s %d年級%s 第%d課的生字", state.PreferredPublisher, state.PreferredGrade, semesterName(state.PreferredSemester), lessonNumber))
+			}
+			chars = append(chars, lesson.Characters...)
+		}
+		chars = uniqueCharacters(chars)
+
+		lessonText := fmt.Sprintf("第%d課", selection.FromLesson)
+		if selection.ToLesson != selection.FromLesson {
+			lessonText = fmt.Sprintf("第%d-%d課", selection.FromLesson, selection.ToLesson)
+		}
+		title := fmt.Sprintf("%s %d年級%s %s 生字練習", state.PreferredPublisher, state.PreferredGrade, semesterName(state.PreferredSemester), lessonText)
+		return title, chars, nil
+	}
+}
+
+func hasWorksheetLesson(state *models.UserState) bool {
+	return state.PreferredPublisher != "" && state.PreferredGrade > 0 && state.PreferredSemester > 0 && state.PreferredLesson > 0
+}
+
+// 外部印字帖頁面的參數：課次範圍或要練習的字
+func externalWorksheetQuery(selection models.WorksheetSelection, chars []string) url.Values {
+	query := url.Values{}
+	if selection.Source == models.WorksheetSourceLesson {
+		if selection.FromLesson == selection.ToLesson {
+			query.Set("lessons", strconv.Itoa(selection.FromLesson))
+		} else {
+			query.Set("lessons", fmt.Sprintf("%d-%d", selection.FromLesson, selection.ToLesson))
+		}
+		return query
+	}
+	query.Set("characters", strings.Join(chars, ""))
+	return query
+}
+
+// 在網址後加上查詢參數
+func appendURLQuery(baseURL string, query url.Values) string {
+	if len(query) == 0 {
+		return baseURL
+	}
+	separator := "?"
+	if strings.Contains(baseURL, "?") {
+		separator = "&"
+	}
+	return baseURL + separator + query.Encode()
+}
+
+// 回覆字帖下載連結
+func replyWorksheetLink(event *linebot.Event, bot *linebot.Client, worksheetService *services.WorksheetService, title string, chars []string, options models.WorksheetOptions) error {
+	if len(chars) == 0 {
+		return utils.NewUserInputError("📝 沒有可以練習的字")
+	}
+
+	note := ""
+	if len(chars) > services.MaxWorksheetCharacters {
+		chars = chars[:services.MaxWorksheetCharacters]
+		note = fmt.Sprintf("\n⚠️ 字數較多，只列出前 %d 個字", services.MaxWorksheetCharacters)
+	}
+
+	downloadURL, err := worksheetService.SignedURL(&models.WorksheetRequest{
+		Title:      title,
+		Characters: chars,
+		Options:    options,
+	})
+	if err != nil {
+		return utils.NewPermanentError("failed to sign worksheet url", err)
+	}
+
+	grid := "田字格"
+	if options.Grid == models.WorksheetGridMi {
+		grid = "米字格"
+	}
+	zhuyin := "標注音"
+	if !options.Zhuyin {
+		zhuyin = "不標注音"
+	}
+
+	responseText := fmt.Sprintf("📝 %s\n\n✏️ 練習字：%s%s\n📐 %s、描紅 %d 格、%s\n\n🔗 下載字帖 PDF：\n%s\n\n⏰ 連結 %s內有效",
+		title, strings.Join(chars, ""), note, grid, options.TraceCells, zhuyin, downloadURL, formatValidity(worksheetService.Expiry()))
+	return replyMessage(event, bot, responseText)
+}
+
+// 連結有效時間的說明，例如「24 小時」、「1 小時 30 分鐘」、「30 分鐘」
+func formatValidity(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%d 分鐘", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d 小時", minutes/60)
+	default:
+		return fmt.Sprintf("%d 小時 %d 分鐘", minutes/60, minutes%60)
+	}
+}
+
+// 查詢結果的印生字字帖快速回覆
+func worksheetQuickReplyButton() *linebot.QuickReplyButton {
+	data := url.Values{}
+	data.Set("action", "command")
+	data.Set("text", "印字帖 生字")
+	return &linebot.QuickReplyButton{
+		Action: linebot.NewPostbackAction("🖨️ 印生字字帖", data.Encode(), "", "印字帖 生字", "", ""),
+	}
+}
+
+// WorksheetDownloadHandler 驗證簽章後產生並下載字帖 PDF
+func WorksheetDownloadHandler(worksheetService *services.WorksheetService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request, err := worksheetService.VerifyRequest(c.Request.URL.Query())
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		pdf, err := worksheetService.Render(request)
+		if err != nil {
+			log.Printf("Error rendering worksheet: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render worksheet"})
+			return
+		}
+
+		c.Header("Content-Disposition", `inline; filename="worksheet.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", pdf)
+	}
+}
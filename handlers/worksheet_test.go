@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatValidity(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{24 * time.Hour, "24 小時"},
+		{time.Hour, "1 小時"},
+		{90 * time.Minute, "1 小時 30 分鐘"},
+		// 不到一小時時不能顯示為 0 小時
+		{30 * time.Minute, "30 分鐘"},
+		{59*time.Minute + 40*time.Second, "1 小時"},
+	}
+	for _, tt := range tests {
+		if got := formatValidity(tt.d); got != tt.want {
+			t.Errorf("formatValidity(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+// 注音標註一次最多處理的中文字數（避免超過 LINE 訊息長度上限）
+const maxAnnotateCharacters = 300
+
+// 只標註還沒學過的字的關鍵字
+var unlearnedOnlyKeywords = []string{"只標生字", "生字", "未學"}
+
+// 為文字標上注音；破音字依前後的詞判斷讀音，加上「生字」時只標註還沒學過的字
+func handleZhuyinAnnotation(event *linebot.Event, bot *linebot.Client, firebaseClient *config.FirebaseClient, userID string, text string) error {
+	text, unlearnedOnly := parseUnlearnedOnly(strings.TrimSpace(text))
+	if !utils.ContainsChineseCharacters(text) {
+		return utils.NewUserInputError("請輸入要標注音的文字，例如：\n注音 我們一起去公園\n注音 生字 小明喜歡在森林裡散步")
+	}
+
+	// 簡體字、異體字先轉為繁體
+	responseText := ""
+	if converted, changed := utils.NormalizeToTraditional(text); changed {
+		responseText += fmt.Sprintf("🔄 已將「%s」轉為繁體「%s」\n\n", text, converted)
+		text = converted
+	}
+
+	chars := utils.ExtractChineseCharacters(text)
+	if len(chars) > maxAnnotateCharacters {
+		return utils.NewUserInputError(fmt.Sprintf("一次最多標注 %d 個字，請分段輸入", maxAnnotateCharacters))
+	}
+
+	var learned map[string]bool
+	if unlearnedOnly {
+		state := getEventUserState(firebaseClient, event, userID)
+		if state.PreferredPublisher == "" || state.PreferredGrade == 0 || state.PreferredSemester == 0 || state.PreferredLesson == 0 {
+			return utils.NewUserInputError("🔤 只標註生字前，請先使用「查詢累積字詞」設定課程和課次")
+		}
+		cumulativeChars, err := getCumulativeCharacters(firebaseClient, state.PreferredPublisher, state.PreferredGrade, state.PreferredSemester, state.PreferredLesson)
+		if err != nil {
+			return utils.NewTransientError("failed to get cumulative characters", err)
+		}
+		learned = make(map[string]bool, len(cumulativeChars))
+		for _, char := range cumulativeChars {
+			learned[char] = true
+		}
+	}
+
+	var lookup []string
+	seen := make(map[string]bool)
+	for _, char := range chars {
+		if !seen[char] && !learned[char] {
+			seen[char] = true
+			lookup = append(lookup, char)
+		}
+	}
+	characters, err := services.NewCharacterService(firebaseClient).GetCharacters(lookup)
+	if err != nil {
+		return utils.NewTransientError("failed to get characters", err)
+	}
+
+	var annotated strings.Builder
+	var missing []string
+	missingSeen := make(map[string]bool)
+	runes := []rune(text)
+	for i, r := range runes {
+		annotated.WriteRune(r)
+		char := string(r)
+		if !utils.IsChineseCharacter(r) || learned[char] {
+			continue
+		}
+		info, ok := characters[char]
+		if !ok || info.Phonetic == "" {
+			if !missingSeen[char] {
+				missingSeen[char] = true
+				missing = append(missing, char)
+			}
+			continue
+		}
+		phonetic := info.Phonetic
+		if reading, _ := info.ReadingAt(runes, i); reading != nil {
+			phonetic = reading.Phonetic
+		}
+		annotated.WriteString("(" + phonetic + ")")
+	}
+
+	if unlearnedOnly {
+		responseText += "🔤 生字注音\n\n"
+	} else {
+		responseText += "🔤 注音\n\n"
+	}
+	responseText += annotated.String()
+	if len(missing) > 0 {
+		responseText += fmt.Sprintf("\n\n⚠️ 查不到注音：%s", strings.Join(missing, ""))
+	}
+	return replyMessage(event, bot, responseText)
+}
+
+// 取出「生字」等關鍵字，回傳剩下的文字及是否只標註生字
+func parseUnlearnedOnly(text string) (string, bool) {
+	for _, keyword := range unlearnedOnlyKeywords {
+		if strings.HasPrefix(text, keyword) {
+			return strings.TrimSpace(strings.TrimPrefix(text, keyword)), true
+		}
+	}
+	return text, false
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import "testing"
+
+func TestParseUnlearnedOnly(t *testing.T) {
+	tests := []struct {
+		text              string
+		wantText          string
+		wantUnlearnedOnly bool
+	}{
+		{"生字 我長大了", "我長大了", true},
+		{"只標生字 森林", "森林", true},
+		{"未學 大象", "大象", true},
+		{"我長大了", "我長大了", false},
+		// 關鍵字不在開頭時是要標註的文字
+		{"我的生字", "我的生字", false},
+	}
+	for _, tt := range tests {
+		text, unlearnedOnly := parseUnlearnedOnly(tt.text)
+		if text != tt.wantText || unlearnedOnly != tt.wantUnlearnedOnly {
+			t.Errorf("parseUnlearnedOnly(%q) = %q, %v; want %q, %v", tt.text, text, unlearnedOnly, tt.wantText, tt.wantUnlearnedOnly)
+		}
+	}
+}
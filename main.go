@@ -12,6 +12,8 @@ import (
 
 	"chinese-learning-linebot/config"
 	"chinese-learning-linebot/handlers"
+	"chinese-learning-linebot/services"
+	"chinese-learning-linebot/utils"
 )
 
 func main() {
@@ -20,6 +22,39 @@ func main() {
 		log.Println("No .env file found")
 	}
 
+	// 子指令：建立並上傳圖文選單（./linebot richmenu）
+	if len(os.Args) > 1 && os.Args[1] == "richmenu" {
+		provisionRichMenu()
+		return
+	}
+
+	// 載入斷詞用的一般詞典（選用，每行「詞 詞頻」）
+	if path := os.Getenv("SEGMENTER_DICTIONARY_PATH"); path != "" {
+		if err := utils.LoadGeneralDictionary(path); err != nil {
+			log.Printf("Warning: Failed to load segmenter dictionary: %v", err)
+		}
+	}
+
+	// 載入字詞資料（選用，沒有 Firestore 時供查字與找字使用）
+	if path := os.Getenv("CHARACTER_DATA_PATH"); path != "" {
+		count, err := services.LoadCharacterSeed(path)
+		if err != nil {
+			log.Printf("Warning: Failed to load character data: %v", err)
+		} else {
+			log.Printf("Loaded %d characters", count)
+		}
+	}
+
+	// 載入筆順資料集（選用，Make Me a Hanzi 的 graphics.txt 格式）
+	if path := os.Getenv("STROKE_DATA_PATH"); path != "" {
+		count, err := services.LoadStrokeDataset(path)
+		if err != nil {
+			log.Printf("Warning: Failed to load stroke dataset: %v", err)
+		} else {
+			log.Printf("Loaded stroke data for %d characters", count)
+		}
+	}
+
 	// 初始化 Firebase
 	ctx := context.Background()
 	firebaseClient, err := config.InitFirebase(ctx)
@@ -39,6 +74,11 @@ func main() {
 		bot = nil
 	}
 
+	// 造句評分服務（選用，例如本地模型），未設定時以規則評分
+	if url := os.Getenv("SENTENCE_SCORER_URL"); url != "" {
+		services.SetSentenceScorer(services.NewHTTPSentenceScorer(url))
+	}
+
 	// 初始化 Gin 路由
 	ginMode := os.Getenv("GIN_MODE")
 	if ginMode == "release" {
@@ -52,8 +92,47 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// 處理失敗事件的死信記錄
+	deadLetterService := services.NewDeadLetterService(firebaseClient)
+
 	// LINE Bot Webhook 端點
-	r.POST("/webhook", handlers.WebhookHandler(bot, firebaseClient))
+	r.POST("/webhook", handlers.WebhookHandler(bot, firebaseClient, deadLetterService))
+
+	// 字帖 PDF 下載端點（網址含簽章與到期時間，需設定 PUBLIC_BASE_URL）
+	r.GET(services.WorksheetDownloadPath, handlers.WorksheetDownloadHandler(services.NewWorksheetService(firebaseClient)))
+
+	// 筆順分解圖與筆順動畫
+	r.GET(services.StrokeImagePath, handlers.StrokeImageHandler(services.NewStrokeService()))
+
+	// 管理端點（需設定 ADMIN_TOKEN）
+	admin := r.Group("/admin", handlers.AdminAuthMiddleware())
+	admin.GET("/dead-letters", handlers.ListDeadLettersHandler(deadLetterService))
+	admin.POST("/dead-letters/:id/resolve", handlers.ResolveDeadLetterHandler(deadLetterService))
+
+	// 主動推播服務（提醒、報告等不需要 reply token 的訊息）
+	if bot != nil {
+		messagingService := services.NewMessagingService(bot, firebaseClient)
+		admin.GET("/message-quota", handlers.MessageQuotaHandler(messagingService))
+
+		// 排程推播（每日一字、複習提醒、學習週報），以台北時間執行
+		if firebaseClient != nil {
+			scheduler := services.NewScheduler(nil)
+			notificationService := services.NewNotificationService(firebaseClient, messagingService)
+			err := notificationService.RegisterJobs(scheduler,
+				getEnvOrDefault("DAILY_CHARACTER_CRON", "0 7 * * *"),
+				getEnvOrDefault("REVIEW_REMINDER_CRON", "0 19 * * *"),
+				getEnvOrDefault("WEEKLY_REPORT_CRON", "0 20 * * 0"))
+			if err == nil {
+				// 每分鐘結算時間到後沒再作答的挑戰
+				err = scheduler.AddJob("expire_challenges", "* * * * *", handlers.ExpireChallengesJob(bot, firebaseClient, messagingService))
+			}
+			if err != nil {
+				log.Printf("Warning: Failed to register scheduled jobs: %v", err)
+			} else {
+				scheduler.Start(ctx)
+			}
+		}
+	}
 
 	// 啟動服務器
 	port := os.Getenv("PORT")
@@ -64,3 +143,22 @@ func main() {
 	fmt.Printf("Server starting on port %s\n", port)
 	log.Fatal(http.ListenAndServe(":"+port, r))
 }
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// 建立圖文選單、上傳圖片並設為預設選單
+func provisionRichMenu() {
+	bot, err := config.InitLineBot()
+	if err != nil {
+		log.Fatalf("Failed to initialize LINE Bot: %v", err)
+	}
+	if err := services.NewRichMenuService(bot).Provision(); err != nil {
+		log.Fatalf("Failed to provision rich menu: %v", err)
+	}
+	fmt.Println("Rich menu provisioned and set as default")
+}
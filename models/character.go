@@ -1,18 +1,129 @@
 package models
 
+import "strings"
+
 // CharacterInfo 字詞資訊結構
 type CharacterInfo struct {
-	Character   string   `json:"character" firestore:"character"`     // 字符本身
-	Phonetic    string   `json:"phonetic" firestore:"phonetic"`       // 注音
-	StrokeCount int      `json:"strokeCount" firestore:"strokeCount"` // 筆畫數
-	Radical     string   `json:"radical" firestore:"radical"`         // 部首
-	Meaning     string   `json:"meaning" firestore:"meaning"`         // 字義
-	Examples    []string `json:"examples" firestore:"examples"`       // 例句
-	Lessons     []string `json:"lessons"`                             // 出現的課程（查詢時填入）
-	Frequency   int      `json:"frequency" firestore:"frequency"`     // 使用頻率
-	Difficulty  int      `json:"difficulty" firestore:"difficulty"`   // 難度等級 (1-5)
-	CreatedAt   int64    `json:"createdAt" firestore:"createdAt"`     // 創建時間
-	UpdatedAt   int64    `json:"updatedAt" firestore:"updatedAt"`     // 更新時間
+	Character   string             `json:"character" firestore:"character"`     // 字符本身
+	Phonetic    string             `json:"phonetic" firestore:"phonetic"`       // 注音
+	StrokeCount int                `json:"strokeCount" firestore:"strokeCount"` // 筆畫數
+	Radical     string             `json:"radical" firestore:"radical"`         // 部首
+	Meaning     string             `json:"meaning" firestore:"meaning"`         // 字義
+	Examples    []string           `json:"examples" firestore:"examples"`       // 例句
+	Readings    []CharacterReading `json:"readings" firestore:"readings"`       // 破音字的各個讀音（單一讀音時可省略）
+	StrokeOrder *StrokeData        `json:"strokeData" firestore:"-"`            // 筆順資料（查詢時由筆順資料集填入，Firestore 不支援巢狀陣列）
+	Lessons     []string           `json:"lessons"`                             // 出現的課程（查詢時填入）
+	Frequency   int                `json:"frequency" firestore:"frequency"`     // 使用頻率
+	Difficulty  int                `json:"difficulty" firestore:"difficulty"`   // 難度等級 (1-5)
+	CreatedAt   int64              `json:"createdAt" firestore:"createdAt"`     // 創建時間
+	UpdatedAt   int64              `json:"updatedAt" firestore:"updatedAt"`     // 更新時間
+}
+
+// CharacterReading 字的一個讀音，破音字（例如長、行、樂）有多個
+type CharacterReading struct {
+	Phonetic string      `json:"phonetic" firestore:"phonetic"` // 注音
+	Meaning  string      `json:"meaning" firestore:"meaning"`   // 此讀音的字義
+	Words    []string    `json:"words" firestore:"words"`       // 例詞，例如「長大」、「校長」
+	TaughtIn []LessonRef `json:"taughtIn" firestore:"taughtIn"` // 教到此讀音的課次（各出版社）
+}
+
+// LessonRef 指向某出版社的某一課
+type LessonRef struct {
+	Publisher string `json:"publisher" firestore:"publisher"` // 出版社
+	Grade     int    `json:"grade" firestore:"grade"`         // 年級
+	Semester  int    `json:"semester" firestore:"semester"`   // 學期
+	Lesson    int    `json:"lesson" firestore:"lesson"`       // 課次
+}
+
+// ReachedBy 學到指定課次時是否已上過這一課
+func (r LessonRef) ReachedBy(publisher string, grade int, semester int, lesson int) bool {
+	if r.Publisher != publisher {
+		return false
+	}
+	return r.Grade < grade ||
+		(r.Grade == grade && r.Semester < semester) ||
+		(r.Grade == grade && r.Semester == semester && r.Lesson <= lesson)
+}
+
+// TaughtBy 學到指定課次時是否已教過此讀音
+func (r CharacterReading) TaughtBy(publisher string, grade int, semester int, lesson int) bool {
+	for _, ref := range r.TaughtIn {
+		if ref.ReachedBy(publisher, grade, semester, lesson) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLessonData 是否有此出版社教到此讀音的課次資料
+func (r CharacterReading) HasLessonData(publisher string) bool {
+	for _, ref := range r.TaughtIn {
+		if ref.Publisher == publisher {
+			return true
+		}
+	}
+	return false
+}
+
+// AllReadings 所有讀音；沒有設定 Readings 時以 Phonetic、Meaning、Examples 組成單一讀音
+func (c *CharacterInfo) AllReadings() []CharacterReading {
+	if len(c.Readings) > 0 {
+		return c.Readings
+	}
+	if c.Phonetic == "" {
+		return nil
+	}
+	return []CharacterReading{{Phonetic: c.Phonetic, Meaning: c.Meaning, Words: c.Examples}}
+}
+
+// IsPolyphonic 是否為破音字
+func (c *CharacterInfo) IsPolyphonic() bool {
+	return len(c.Readings) > 1
+}
+
+// ReadingInContext 依包含此字的詞或句子判斷讀音（取最長的相符例詞），判斷不出時回傳 nil
+func (c *CharacterInfo) ReadingInContext(text string) (*CharacterReading, string) {
+	var best *CharacterReading
+	bestWord := ""
+	for i := range c.Readings {
+		for _, word := range c.Readings[i].Words {
+			wordLength := len([]rune(word))
+			if wordLength < 2 || !strings.Contains(text, word) {
+				continue
+			}
+			if wordLength > len([]rune(bestWord)) {
+				best = &c.Readings[i]
+				bestWord = word
+			}
+		}
+	}
+	return best, bestWord
+}
+
+// ReadingAt 依文字中第 index 個字前後的詞判斷讀音（取最長的相符例詞），判斷不出時回傳 nil
+func (c *CharacterInfo) ReadingAt(text []rune, index int) (*CharacterReading, string) {
+	var best *CharacterReading
+	bestWord := ""
+	for i := range c.Readings {
+		for _, word := range c.Readings[i].Words {
+			wordRunes := []rune(word)
+			if len(wordRunes) < 2 || len(wordRunes) <= len([]rune(bestWord)) {
+				continue
+			}
+			for offset, r := range wordRunes {
+				start := index - offset
+				if string(r) != c.Character || start < 0 || start+len(wordRunes) > len(text) {
+					continue
+				}
+				if string(text[start:start+len(wordRunes)]) == word {
+					best = &c.Readings[i]
+					bestWord = word
+					break
+				}
+			}
+		}
+	}
+	return best, bestWord
 }
 
 // CharacterSearchResult 字詞搜索結果
@@ -23,10 +134,21 @@ type CharacterSearchResult struct {
 	PageSize   int              `json:"pageSize"`
 }
 
+// CharacterSearchCriteria 字詞搜索條件，未設定的條件不限制
+type CharacterSearchCriteria struct {
+	Zhuyin     string   `json:"zhuyin"`     // 注音，含聲調時須完全相同，不含聲調時不限聲調
+	Radical    string   `json:"radical"`    // 部首
+	MinStrokes int      `json:"minStrokes"` // 最少筆畫
+	MaxStrokes int      `json:"maxStrokes"` // 最多筆畫
+	Within     []string `json:"within"`     // 限定在這些字之中（例如已學過的字），nil 表示不限
+	Page       int      `json:"page"`       // 頁碼（從 1 開始）
+	PageSize   int      `json:"pageSize"`   // 每頁筆數
+}
+
 // CharacterStats 字詞統計信息
 type CharacterStats struct {
 	TotalCharacters     int `json:"totalCharacters"`
 	LearnedCharacters   int `json:"learnedCharacters"`
 	RemainingCharacters int `json:"remainingCharacters"`
 	ProgressPercentage  int `json:"progressPercentage"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,136 @@
+package models
+
+import "testing"
+
+// 測試用的破音字「長」
+func testLongCharacter() *CharacterInfo {
+	return &CharacterInfo{
+		Character: "長",
+		Phonetic:  "ㄔㄤˊ",
+		Readings: []CharacterReading{
+			{Phonetic: "ㄔㄤˊ", Meaning: "兩端的距離大", Words: []string{"長短", "長城"},
+				TaughtIn: []LessonRef{{Publisher: "康軒", Grade: 1, Semester: 2, Lesson: 3}}},
+			{Phonetic: "ㄓㄤˇ", Meaning: "生長、年紀大", Words: []string{"長大", "成長"},
+				TaughtIn: []LessonRef{{Publisher: "康軒", Grade: 2, Semester: 1, Lesson: 6}}},
+		},
+	}
+}
+
+func TestLessonRefReachedBy(t *testing.T) {
+	ref := LessonRef{Publisher: "康軒", Grade: 2, Semester: 1, Lesson: 6}
+	tests := []struct {
+		publisher               string
+		grade, semester, lesson int
+		want                    bool
+	}{
+		{"康軒", 2, 1, 6, true},
+		{"康軒", 2, 1, 5, false},
+		{"康軒", 2, 2, 1, true},
+		{"康軒", 1, 2, 12, false},
+		{"康軒", 3, 1, 1, true},
+		{"翰林", 3, 1, 1, false},
+	}
+	for _, tt := range tests {
+		if got := ref.ReachedBy(tt.publisher, tt.grade, tt.semester, tt.lesson); got != tt.want {
+			t.Errorf("ReachedBy(%s %d-%d 第%d課) = %v, want %v", tt.publisher, tt.grade, tt.semester, tt.lesson, got, tt.want)
+		}
+	}
+}
+
+func TestCharacterReadings(t *testing.T) {
+	char := testLongCharacter()
+	if !char.IsPolyphonic() || len(char.AllReadings()) != 2 {
+		t.Fatalf("長 should have two readings")
+	}
+	grow := char.Readings[1]
+	if grow.TaughtBy("康軒", 2, 1, 5) || !grow.TaughtBy("康軒", 2, 1, 6) {
+		t.Errorf("ㄓㄤˇ should be taught from 康軒 2上 第6課")
+	}
+	if !grow.HasLessonData("康軒") || grow.HasLessonData("南一") {
+		t.Errorf("HasLessonData mismatch")
+	}
+
+	// 沒有 Readings 時以 Phonetic 組成單一讀音
+	plain := &CharacterInfo{Character: "森", Phonetic: "ㄙㄣ", Meaning: "樹木眾多", Examples: []string{"森林"}}
+	readings := plain.AllReadings()
+	if plain.IsPolyphonic() || len(readings) != 1 || readings[0].Phonetic != "ㄙㄣ" || readings[0].Words[0] != "森林" {
+		t.Errorf("AllReadings for 森 = %+v", readings)
+	}
+	if (&CharacterInfo{Character: "森"}).AllReadings() != nil {
+		t.Errorf("AllReadings without phonetic should be nil")
+	}
+}
+
+// 測試用的破音字：行、樂（樂的例詞混有英文字母，字數與位元組數的長短不同）
+func testPolyphonicCharacters() map[string]*CharacterInfo {
+	return map[string]*CharacterInfo{
+		"長": testLongCharacter(),
+		"行": {Character: "行", Phonetic: "ㄒㄧㄥˊ", Readings: []CharacterReading{
+			{Phonetic: "ㄒㄧㄥˊ", Words: []string{"行人", "步行"}},
+			{Phonetic: "ㄏㄤˊ", Words: []string{"銀行", "行列"}},
+		}},
+		"樂": {Character: "樂", Phonetic: "ㄌㄜˋ", Readings: []CharacterReading{
+			{Phonetic: "ㄌㄜˋ", Words: []string{"快樂", "快樂的"}},
+			{Phonetic: "ㄩㄝˋ", Words: []string{"音樂", "DJ樂手"}},
+		}},
+	}
+}
+
+func TestReadingInContext(t *testing.T) {
+	chars := testPolyphonicCharacters()
+	tests := []struct {
+		char         string
+		text         string
+		wantPhonetic string
+		wantWord     string
+	}{
+		{"長", "我長大了", "ㄓㄤˇ", "長大"},
+		{"長", "萬里長城", "ㄔㄤˊ", "長城"},
+		{"長", "這條繩子很長", "", ""},
+		{"行", "我去銀行領錢", "ㄏㄤˊ", "銀行"},
+		{"行", "行人要走斑馬線", "ㄒㄧㄥˊ", "行人"},
+		{"樂", "我們一起聽音樂", "ㄩㄝˋ", "音樂"},
+		// 以字數比較：「DJ樂手」四個字比「快樂的」長
+		{"樂", "快樂的DJ樂手", "ㄩㄝˋ", "DJ樂手"},
+	}
+	for _, tt := range tests {
+		reading, word := chars[tt.char].ReadingInContext(tt.text)
+		phonetic := ""
+		if reading != nil {
+			phonetic = reading.Phonetic
+		}
+		if phonetic != tt.wantPhonetic || word != tt.wantWord {
+			t.Errorf("%s: ReadingInContext(%q) = %q, %q; want %q, %q", tt.char, tt.text, phonetic, word, tt.wantPhonetic, tt.wantWord)
+		}
+	}
+}
+
+func TestReadingAt(t *testing.T) {
+	chars := testPolyphonicCharacters()
+	tests := []struct {
+		char         string
+		text         string
+		index        int
+		wantPhonetic string
+		wantWord     string
+	}{
+		{"長", "長大後去看長城", 0, "ㄓㄤˇ", "長大"},
+		{"長", "長大後去看長城", 5, "ㄔㄤˊ", "長城"},
+		// 看的不是「長」所在的位置
+		{"長", "長大後去看長城", 3, "", ""},
+		{"行", "行人走到銀行", 0, "ㄒㄧㄥˊ", "行人"},
+		{"行", "行人走到銀行", 5, "ㄏㄤˊ", "銀行"},
+		{"樂", "快樂的DJ樂手", 1, "ㄌㄜˋ", "快樂的"},
+		{"樂", "快樂的DJ樂手", 5, "ㄩㄝˋ", "DJ樂手"},
+	}
+	for _, tt := range tests {
+		reading, word := chars[tt.char].ReadingAt([]rune(tt.text), tt.index)
+		phonetic := ""
+		if reading != nil {
+			phonetic = reading.Phonetic
+		}
+		if phonetic != tt.wantPhonetic || word != tt.wantWord {
+			t.Errorf("ReadingAt(%q, %d) = %q, %q; want %q, %q", tt.text, tt.index, phonetic, word, tt.wantPhonetic, tt.wantWord)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+// Classroom 班級：老師把機器人加入 LINE 群組後登記，群組成員沿用班級的課程設定
+type Classroom struct {
+	GroupID   string   `json:"groupId" firestore:"groupId"`     // LINE 群組ID
+	Name      string   `json:"name" firestore:"name"`           // 班級名稱
+	TeacherID string   `json:"teacherId" firestore:"teacherId"` // 登記班級的老師（用戶ID）
+	Publisher string   `json:"publisher" firestore:"publisher"` // 出版社
+	Grade     int      `json:"grade" firestore:"grade"`         // 年級
+	Semester  int      `json:"semester" firestore:"semester"`   // 學期
+	Lesson    int      `json:"lesson" firestore:"lesson"`       // 目前課次
+	Members   []string `json:"members" firestore:"members"`     // 在群組中使用過機器人的學生（用戶ID）
+	CreatedAt int64    `json:"createdAt" firestore:"createdAt"` // 創建時間
+	UpdatedAt int64    `json:"updatedAt" firestore:"updatedAt"` // 更新時間
+}
+
+// ClassroomReport 班級練習統計（給老師）
+type ClassroomReport struct {
+	Classroom      *Classroom                      `json:"classroom"`      // 班級
+	PeriodStart    int64                           `json:"periodStart"`    // 統計起始時間
+	PeriodEnd      int64                           `json:"periodEnd"`      // 統計結束時間
+	Sessions       int                             `json:"sessions"`       // 練習次數
+	Questions      int                             `json:"questions"`      // 作答題數
+	Correct        int                             `json:"correct"`        // 答對題數
+	AccuracyByType map[string]QuestionTypeAccuracy `json:"accuracyByType"` // 各題型正確率
+	MostMissed     []MissedCharacter               `json:"mostMissed"`     // 全班最常答錯的字
+	Participants   []ClassroomParticipant          `json:"participants"`   // 各成員的練習情形（含沒有練習的成員）
+}
+
+// ClassroomParticipant 班級成員的練習情形
+type ClassroomParticipant struct {
+	UserID    string `json:"userId"`    // 用戶ID
+	Sessions  int    `json:"sessions"`  // 練習次數
+	Questions int    `json:"questions"` // 作答題數
+	Correct   int    `json:"correct"`   // 答對題數
+}
+
+// ActiveParticipants 統計期間有練習的成員數
+func (r *ClassroomReport) ActiveParticipants() int {
+	count := 0
+	for _, participant := range r.Participants {
+		if participant.Sessions > 0 {
+			count++
+		}
+	}
+	return count
+}
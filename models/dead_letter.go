@@ -0,0 +1,14 @@
+package models
+
+// DeadLetter 處理失敗的事件記錄
+type DeadLetter struct {
+	ID         string `json:"id" firestore:"id"`                 // 文檔ID
+	EventType  string `json:"eventType" firestore:"eventType"`   // 事件類型
+	UserID     string `json:"userId" firestore:"userId"`         // 用戶ID
+	ErrorKind  string `json:"errorKind" firestore:"errorKind"`   // 錯誤分類 (user_input, transient, permanent)
+	Error      string `json:"error" firestore:"error"`           // 錯誤訊息
+	Stack      string `json:"stack" firestore:"stack"`           // panic 堆疊（如有）
+	Payload    string `json:"payload" firestore:"payload"`       // 原始事件 JSON
+	Resolved   bool   `json:"resolved" firestore:"resolved"`     // 是否已處理
+	OccurredAt int64  `json:"occurredAt" firestore:"occurredAt"` // 發生時間
+}
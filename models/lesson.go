@@ -10,6 +10,7 @@ type LessonInfo struct {
 	Semester       int      `json:"semester" firestore:"semester"`             // 學期
 	Characters     []string `json:"characters" firestore:"characters"`         // 課程中的字符
 	CharacterCount int      `json:"characterCount"`                            // 字符數量（計算得出）
+	Words          []string `json:"words" firestore:"words"`                   // 課程中的語詞
 	Description    string   `json:"description" firestore:"description"`       // 課程描述
 	Objectives     []string `json:"objectives" firestore:"objectives"`         // 學習目標
 	Difficulty     int      `json:"difficulty" firestore:"difficulty"`         // 難度等級
@@ -61,4 +62,14 @@ type PublisherInfo struct {
 	Description string `json:"description"` // 描述
 	Grades      []int  `json:"grades"`      // 支援的年級
 	Active      bool   `json:"active"`      // 是否啟用
-}
\ No newline at end of file
+}
+
+// LessonProgressRecord 課次進度紀錄（用戶每次設定課次時寫入）
+type LessonProgressRecord struct {
+	UserID     string `json:"userId" firestore:"userId"`         // 用戶ID
+	Publisher  string `json:"publisher" firestore:"publisher"`   // 出版社
+	Grade      int    `json:"grade" firestore:"grade"`           // 年級
+	Semester   int    `json:"semester" firestore:"semester"`     // 學期
+	Lesson     int    `json:"lesson" firestore:"lesson"`         // 課次
+	RecordedAt int64  `json:"recordedAt" firestore:"recordedAt"` // 紀錄時間
+}
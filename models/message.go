@@ -0,0 +1,20 @@
+package models
+
+// MessageQuota 本月訊息額度使用狀況
+type MessageQuota struct {
+	Month     string `json:"month" firestore:"month"`         // 月份 (YYYY-MM)
+	Limit     int64  `json:"limit" firestore:"limit"`         // 額度上限（-1 表示無上限）
+	Used      int64  `json:"used" firestore:"used"`           // 已使用則數
+	UpdatedAt int64  `json:"updatedAt" firestore:"updatedAt"` // 更新時間
+}
+
+// Remaining 剩餘額度（無上限時回傳 -1）
+func (q *MessageQuota) Remaining() int64 {
+	if q.Limit < 0 {
+		return -1
+	}
+	if q.Used >= q.Limit {
+		return 0
+	}
+	return q.Limit - q.Used
+}
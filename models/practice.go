@@ -2,54 +2,92 @@ package models
 
 // PracticeQuestion 練習題目結構
 type PracticeQuestion struct {
-	ID            string   `json:"id"`            // 題目ID
-	Type          string   `json:"type"`          // 題目類型 (phonetic, stroke, sentence)
-	Character     string   `json:"character"`     // 相關字符
-	Question      string   `json:"question"`      // 題目內容
-	Options       []string `json:"options"`       // 選項（選擇題用）
-	CorrectAnswer string   `json:"correctAnswer"` // 正確答案
-	Explanation   string   `json:"explanation"`   // 解釋說明
-	Difficulty    int      `json:"difficulty"`    // 難度等級
-	CreatedAt     int64    `json:"createdAt"`     // 創建時間
+	ID            string   `json:"id" firestore:"id"`                       // 題目ID
+	Type          string   `json:"type" firestore:"type"`                   // 題目類型 (phonetic, stroke, sentence, stroke_order, next_stroke, radical, radical_character, cloze)
+	Character     string   `json:"character" firestore:"character"`         // 相關字符
+	Question      string   `json:"question" firestore:"question"`           // 題目內容
+	Options       []string `json:"options" firestore:"options"`             // 選項（選擇題用）
+	ImageURL      string   `json:"imageUrl" firestore:"imageUrl"`           // 題目圖片（筆順題用）
+	CorrectAnswer string   `json:"correctAnswer" firestore:"correctAnswer"` // 正確答案
+	Explanation   string   `json:"explanation" firestore:"explanation"`     // 解釋說明
+	Difficulty    int      `json:"difficulty" firestore:"difficulty"`       // 難度等級
+	CreatedAt     int64    `json:"createdAt" firestore:"createdAt"`         // 創建時間
 }
 
 // PracticeSession 練習會話結構
 type PracticeSession struct {
-	ID           string             `json:"id"`           // 會話ID
-	UserID       string             `json:"userId"`       // 用戶ID
-	Type         string             `json:"type"`         // 練習類型
-	Questions    []PracticeQuestion `json:"questions"`    // 題目列表
-	Answers      []PracticeAnswer   `json:"answers"`      // 答案列表
-	Score        int                `json:"score"`        // 得分
-	TotalScore   int                `json:"totalScore"`   // 總分
-	StartTime    int64              `json:"startTime"`    // 開始時間
-	EndTime      int64              `json:"endTime"`      // 結束時間
-	Completed    bool               `json:"completed"`    // 是否完成
+	ID         string             `json:"id" firestore:"id"`                 // 會話ID
+	UserID     string             `json:"userId" firestore:"userId"`         // 用戶ID
+	Type       string             `json:"type" firestore:"type"`             // 練習類型
+	Questions  []PracticeQuestion `json:"questions" firestore:"questions"`   // 題目列表
+	Answers    []PracticeAnswer   `json:"answers" firestore:"answers"`       // 答案列表
+	Score      int                `json:"score" firestore:"score"`           // 得分
+	TotalScore int                `json:"totalScore" firestore:"totalScore"` // 總分
+	StartTime  int64              `json:"startTime" firestore:"startTime"`   // 開始時間
+	EndTime    int64              `json:"endTime" firestore:"endTime"`       // 結束時間
+	Completed  bool               `json:"completed" firestore:"completed"`   // 是否完成
+	Learned    []string           `json:"learned" firestore:"learned"`       // 已學過的字（造句評分用）
+	Levels     []int              `json:"levels" firestore:"levels"`         // 每題出題時的難度（記錄難度變化）
+	TypeLevels map[string]int     `json:"typeLevels" firestore:"typeLevels"` // 開始練習時用戶各題型的等級
+	TimeLimit  int64              `json:"timeLimit" firestore:"timeLimit"`   // 挑戰模式的總時間限制（秒），0 表示不限時
+	Points     int                `json:"points" firestore:"points"`         // 挑戰模式得分（答對的基本分加上時間獎勵）
+	GroupID    string             `json:"groupId" firestore:"groupId"`       // 在班級群組中開始的練習（班級統計用）
+}
+
+// UserKey 練習紀錄（複習、統計、題型等級、挑戰紀錄）的鍵：班級群組中的練習與私訊分開記錄
+func (s *PracticeSession) UserKey() string {
+	return UserStateKey(s.UserID, s.GroupID)
+}
+
+// TimedOut 挑戰模式在 at（毫秒）時是否已超過時間限制
+func (s *PracticeSession) TimedOut(at int64) bool {
+	return s.TimeLimit > 0 && at > s.StartTime+s.TimeLimit*1000
+}
+
+// RemainingSeconds 挑戰模式在 at（毫秒）時剩下的秒數
+func (s *PracticeSession) RemainingSeconds(at int64) int64 {
+	remaining := (s.StartTime + s.TimeLimit*1000 - at) / 1000
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// CurrentQuestion 取得目前待作答的題目（全部作答完畢時回傳 nil）
+func (s *PracticeSession) CurrentQuestion() *PracticeQuestion {
+	if len(s.Answers) >= len(s.Questions) {
+		return nil
+	}
+	return &s.Questions[len(s.Answers)]
 }
 
 // PracticeAnswer 練習答案結構
 type PracticeAnswer struct {
-	QuestionID   string `json:"questionId"`   // 題目ID
-	UserAnswer   string `json:"userAnswer"`   // 用戶答案
-	CorrectAnswer string `json:"correctAnswer"` // 正確答案
-	IsCorrect    bool   `json:"isCorrect"`    // 是否正確
-	TimeSpent    int64  `json:"timeSpent"`    // 花費時間（毫秒）
-	AnsweredAt   int64  `json:"answeredAt"`   // 答題時間
+	QuestionID    string `json:"questionId" firestore:"questionId"`       // 題目ID
+	QuestionType  string `json:"questionType" firestore:"questionType"`   // 題目類型
+	Character     string `json:"character" firestore:"character"`         // 相關字符
+	UserAnswer    string `json:"userAnswer" firestore:"userAnswer"`       // 用戶答案
+	CorrectAnswer string `json:"correctAnswer" firestore:"correctAnswer"` // 正確答案
+	IsCorrect     bool   `json:"isCorrect" firestore:"isCorrect"`         // 是否正確
+	TimeSpent     int64  `json:"timeSpent" firestore:"timeSpent"`         // 花費時間（毫秒）
+	AnsweredAt    int64  `json:"answeredAt" firestore:"answeredAt"`       // 答題時間
 }
 
 // PracticeStats 練習統計結構
 type PracticeStats struct {
-	UserID              string  `json:"userId"`              // 用戶ID
-	TotalSessions       int     `json:"totalSessions"`       // 總練習次數
-	TotalQuestions      int     `json:"totalQuestions"`      // 總題目數
-	CorrectAnswers      int     `json:"correctAnswers"`      // 正確答案數
-	AccuracyRate        float64 `json:"accuracyRate"`        // 正確率
-	AverageScore        float64 `json:"averageScore"`        // 平均分數
-	TotalTimeSpent      int64   `json:"totalTimeSpent"`      // 總花費時間
-	AverageTimePerQuestion int64 `json:"averageTimePerQuestion"` // 平均每題時間
-	LastPracticeTime    int64   `json:"lastPracticeTime"`    // 最後練習時間
-	Streak              int     `json:"streak"`              // 連續練習天數
-	BestStreak          int     `json:"bestStreak"`          // 最佳連續天數
+	UserID                 string         `json:"userId" firestore:"userId"`                                 // 用戶ID
+	TotalSessions          int            `json:"totalSessions" firestore:"totalSessions"`                   // 總練習次數
+	TotalQuestions         int            `json:"totalQuestions" firestore:"totalQuestions"`                 // 總題目數
+	CorrectAnswers         int            `json:"correctAnswers" firestore:"correctAnswers"`                 // 正確答案數
+	AccuracyRate           float64        `json:"accuracyRate" firestore:"accuracyRate"`                     // 正確率
+	AverageScore           float64        `json:"averageScore" firestore:"averageScore"`                     // 平均分數
+	TotalTimeSpent         int64          `json:"totalTimeSpent" firestore:"totalTimeSpent"`                 // 總花費時間
+	AverageTimePerQuestion int64          `json:"averageTimePerQuestion" firestore:"averageTimePerQuestion"` // 平均每題時間
+	LastPracticeTime       int64          `json:"lastPracticeTime" firestore:"lastPracticeTime"`             // 最後練習時間
+	LastPracticeDate       string         `json:"lastPracticeDate" firestore:"lastPracticeDate"`             // 最後練習日期 (YYYY-MM-DD，台北時間)
+	Streak                 int            `json:"streak" firestore:"streak"`                                 // 連續練習天數
+	BestStreak             int            `json:"bestStreak" firestore:"bestStreak"`                         // 最佳連續天數
+	Levels                 map[string]int `json:"levels" firestore:"levels"`                                 // 各題型的難度等級（1-4）
 }
 
 // PracticeType 練習類型枚舉
@@ -60,6 +98,13 @@ const (
 	PracticeTypeStroke   PracticeType = "stroke"   // 筆畫練習
 	PracticeTypeSentence PracticeType = "sentence" // 造句練習
 	PracticeTypeMixed    PracticeType = "mixed"    // 混合練習
+
+	PracticeTypeStrokeOrder PracticeType = "stroke_order" // 筆順練習：標示的是第幾筆
+	PracticeTypeNextStroke  PracticeType = "next_stroke"  // 筆順練習：下一筆是哪一個
+
+	PracticeTypeRadical          PracticeType = "radical"           // 部首練習：這個字的部首是什麼
+	PracticeTypeRadicalCharacter PracticeType = "radical_character" // 部首練習：哪個字是這個部首
+	PracticeTypeCloze            PracticeType = "cloze"             // 填空練習：例句或語詞中挖空的字
 )
 
 // QuestionDifficulty 題目難度枚舉
@@ -74,12 +119,32 @@ const (
 
 // PracticeConfig 練習配置結構
 type PracticeConfig struct {
-	Type           PracticeType       `json:"type"`           // 練習類型
-	Difficulty     QuestionDifficulty `json:"difficulty"`     // 難度等級
-	QuestionCount  int                `json:"questionCount"`  // 題目數量
-	TimeLimit      int64              `json:"timeLimit"`      // 時間限制（秒）
-	RandomOrder    bool               `json:"randomOrder"`    // 是否隨機順序
-	ShowExplanation bool              `json:"showExplanation"` // 是否顯示解釋
-	Grade          *int               `json:"grade"`          // 指定年級（可選）
-	Publisher      string             `json:"publisher"`      // 指定出版社（可選）
-}
\ No newline at end of file
+	Type            PracticeType       `json:"type"`            // 練習類型
+	Difficulty      QuestionDifficulty `json:"difficulty"`      // 難度等級
+	QuestionCount   int                `json:"questionCount"`   // 題目數量
+	TimeLimit       int64              `json:"timeLimit"`       // 時間限制（秒）
+	RandomOrder     bool               `json:"randomOrder"`     // 是否隨機順序
+	ShowExplanation bool               `json:"showExplanation"` // 是否顯示解釋
+	Grade           *int               `json:"grade"`           // 指定年級（可選）
+	Publisher       string             `json:"publisher"`       // 指定出版社（可選）
+	Learned         []string           `json:"learned"`         // 已學過的字（部首題、填空題的錯誤選項來源，可選）
+	LearnedWords    []string           `json:"learnedWords"`    // 已學過的語詞（填空題的題目來源，可選）
+	StartTime       int64              `json:"startTime"`       // 開始時間（毫秒，例如觸發練習的事件時間；未設定時為目前時間）
+	GroupID         string             `json:"groupId"`         // 在班級群組中開始時的群組ID（可選）
+}
+
+// ChallengeRecord 挑戰模式的一次成績
+type ChallengeRecord struct {
+	SessionID  string `json:"sessionId" firestore:"sessionId"`   // 練習會話ID
+	Points     int    `json:"points" firestore:"points"`         // 得分（含時間獎勵）
+	Correct    int    `json:"correct" firestore:"correct"`       // 答對題數
+	Total      int    `json:"total" firestore:"total"`           // 題數
+	Duration   int64  `json:"duration" firestore:"duration"`     // 花費時間（毫秒）
+	AchievedAt int64  `json:"achievedAt" firestore:"achievedAt"` // 完成時間
+}
+
+// ChallengeBoard 挑戰模式的個人最佳紀錄
+type ChallengeBoard struct {
+	UserID  string            `json:"userId" firestore:"userId"`   // 用戶ID
+	Records []ChallengeRecord `json:"records" firestore:"records"` // 依得分由高到低排列
+}
@@ -0,0 +1,32 @@
+package models
+
+// WeeklyReport 每週學習報告（給家長）
+type WeeklyReport struct {
+	UserID             string                          `json:"userId"`             // 用戶ID
+	PeriodStart        int64                           `json:"periodStart"`        // 統計起始時間
+	PeriodEnd          int64                           `json:"periodEnd"`          // 統計結束時間
+	Progress           *LearningProgress               `json:"progress"`           // 本學期學習進度
+	CurrentLesson      int                             `json:"currentLesson"`      // 目前課次
+	LearnedThisWeek    []string                        `json:"learnedThisWeek"`    // 本週學到的字
+	UpcomingCharacters []string                        `json:"upcomingCharacters"` // 下週（下一課）要學的字
+	UpcomingLesson     string                          `json:"upcomingLesson"`     // 下一課標題
+	SessionsThisWeek   int                             `json:"sessionsThisWeek"`   // 本週練習次數
+	AccuracyByType     map[string]QuestionTypeAccuracy `json:"accuracyByType"`     // 各題型正確率
+	Stats              *PracticeStats                  `json:"stats"`              // 累計練習統計（含連續天數）
+	PracticedToday     bool                            `json:"practicedToday"`     // 今天是否已練習（連續天數是否延續中）
+	MostMissed         []MissedCharacter               `json:"mostMissed"`         // 最常答錯的字
+}
+
+// QuestionTypeAccuracy 題型正確率
+type QuestionTypeAccuracy struct {
+	Type     string  `json:"type"`     // 題目類型
+	Total    int     `json:"total"`    // 作答題數
+	Correct  int     `json:"correct"`  // 答對題數
+	Accuracy float64 `json:"accuracy"` // 正確率 (0-100)
+}
+
+// MissedCharacter 答錯統計
+type MissedCharacter struct {
+	Character  string `json:"character"`  // 字
+	WrongCount int    `json:"wrongCount"` // 答錯次數
+}
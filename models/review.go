@@ -0,0 +1,17 @@
+package models
+
+// ReviewItem 間隔複習項目（Leitner 盒子法）
+type ReviewItem struct {
+	ID             string `json:"id" firestore:"id"`                         // 文檔ID (userID_character)
+	UserID         string `json:"userId" firestore:"userId"`                 // 用戶ID
+	Character      string `json:"character" firestore:"character"`           // 複習的字
+	Box            int    `json:"box" firestore:"box"`                       // 盒子編號（0 開始，越大間隔越長）
+	NextReviewAt   int64  `json:"nextReviewAt" firestore:"nextReviewAt"`     // 下次複習時間
+	LastReviewedAt int64  `json:"lastReviewedAt" firestore:"lastReviewedAt"` // 上次複習時間
+	CorrectCount   int    `json:"correctCount" firestore:"correctCount"`     // 答對次數
+	WrongCount     int    `json:"wrongCount" firestore:"wrongCount"`         // 答錯次數
+	CreatedAt      int64  `json:"createdAt" firestore:"createdAt"`           // 創建時間
+}
+
+// ReviewIntervalsInDays 各盒子對應的複習間隔（天）
+var ReviewIntervalsInDays = []int{1, 2, 4, 7, 15, 30}
@@ -0,0 +1,22 @@
+package models
+
+// StrokeData 筆順資料，格式與 Make Me a Hanzi 的 graphics.txt 相容
+// 座標為 1024×1024，y 軸向上，原點在基線下方 124 單位（畫面座標 y' = 900 - y）
+type StrokeData struct {
+	Character string        `json:"character"` // 字
+	Strokes   []string      `json:"strokes"`   // 依筆順排列的每一筆輪廓（SVG path）
+	Medians   [][][]float64 `json:"medians"`   // 每一筆的中線（書寫方向的點列）
+}
+
+// StrokeCount 筆畫數
+func (d *StrokeData) StrokeCount() int {
+	return len(d.Strokes)
+}
+
+// Median 第 index 筆的中線點列，資料不完整時回傳 nil
+func (d *StrokeData) Median(index int) [][]float64 {
+	if index < 0 || index >= len(d.Medians) {
+		return nil
+	}
+	return d.Medians[index]
+}
@@ -0,0 +1,32 @@
+package models
+
+// Subscription 用戶推播訂閱設定
+type Subscription struct {
+	UserID         string   `json:"userId" firestore:"userId"`                 // 用戶ID
+	DailyCharacter bool     `json:"dailyCharacter" firestore:"dailyCharacter"` // 訂閱每日一字
+	ReviewReminder bool     `json:"reviewReminder" firestore:"reviewReminder"` // 訂閱複習提醒
+	WeeklyReport   bool     `json:"weeklyReport" firestore:"weeklyReport"`     // 訂閱家長學習週報
+	QuietStart     int      `json:"quietStart" firestore:"quietStart"`         // 勿擾開始時間（0-23 時，-1 表示未設定）
+	QuietEnd       int      `json:"quietEnd" firestore:"quietEnd"`             // 勿擾結束時間（0-23 時，不含）
+	PendingDaily   bool     `json:"pendingDaily" firestore:"pendingDaily"`     // 因勿擾時段延後的每日一字
+	LastDailyDate  string   `json:"lastDailyDate" firestore:"lastDailyDate"`   // 最後推播每日一字的日期 (YYYY-MM-DD)
+	PushedChars    []string `json:"pushedChars" firestore:"pushedChars"`       // 已推播過的字
+	CreatedAt      int64    `json:"createdAt" firestore:"createdAt"`           // 創建時間
+	UpdatedAt      int64    `json:"updatedAt" firestore:"updatedAt"`           // 更新時間
+}
+
+// HasQuietHours 是否設定勿擾時段
+func (s *Subscription) HasQuietHours() bool {
+	return s.QuietStart >= 0 && s.QuietEnd >= 0 && s.QuietStart != s.QuietEnd
+}
+
+// InQuietHours 判斷指定時刻（0-23）是否在勿擾時段內，支援跨午夜（例如 21-7）
+func (s *Subscription) InQuietHours(hour int) bool {
+	if !s.HasQuietHours() {
+		return false
+	}
+	if s.QuietStart < s.QuietEnd {
+		return hour >= s.QuietStart && hour < s.QuietEnd
+	}
+	return hour >= s.QuietStart || hour < s.QuietEnd
+}
@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestInQuietHours(t *testing.T) {
+	tests := []struct {
+		start, end int
+		hour       int
+		want       bool
+	}{
+		{-1, -1, 23, false},
+		{9, 9, 9, false},
+		{13, 15, 12, false},
+		{13, 15, 13, true},
+		{13, 15, 15, false},
+		// 跨午夜
+		{21, 7, 22, true},
+		{21, 7, 0, true},
+		{21, 7, 6, true},
+		{21, 7, 7, false},
+		{21, 7, 20, false},
+	}
+
+	for _, tt := range tests {
+		subscription := &Subscription{QuietStart: tt.start, QuietEnd: tt.end}
+		if got := subscription.InQuietHours(tt.hour); got != tt.want {
+			t.Errorf("InQuietHours(%d) with %d-%d = %v, want %v", tt.hour, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+// UserState 用戶狀態管理
+type UserState struct {
+	Mode      string // "cumulative_query"、"practice" 或 ""
+	Publisher string
+	Grade     int
+	Semester  int
+	Lesson    int
+	Step      int // 0: 等待出版社, 1: 等待年級, 2: 等待學期, 3: 等待課次, 4: 等待查詢字詞
+	// 用戶偏好設定（記憶半年）
+	PreferredPublisher string
+	PreferredGrade     int
+	PreferredSemester  int
+	PreferredLesson    int // 目前進度的課次（退出查詢後仍保留，供每日一字與學習報告使用）
+	// 進行中的練習
+	PracticeSessionID string
+	// 上次累積字詞查詢中尚未學過的字（供印字帖使用）
+	LastUnlearnedCharacters []string
+	// 狀態所屬的用戶與群組（群組中的狀態以群組區分；儲存以便取消好友或離開群組時清除）
+	UserID  string
+	GroupID string
+	// 上次更新狀態的時間（毫秒），群組中的模式閒置過久即結束
+	UpdatedAt int64
+}
+
+// ClearMode 離開目前的模式，保留偏好設定
+func (s *UserState) ClearMode() {
+	s.Mode = ""
+	s.Publisher = ""
+	s.Grade = 0
+	s.Semester = 0
+	s.Lesson = 0
+	s.Step = 0
+	s.PracticeSessionID = ""
+}
+
+// UserStateKey 用戶狀態與練習紀錄的鍵：群組中以群組與用戶區分，私訊中即為用戶 ID
+func UserStateKey(userID string, groupID string) string {
+	if groupID == "" {
+		return userID
+	}
+	return groupID + "_" + userID
+}
@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestUserStateKey(t *testing.T) {
+	if got := UserStateKey("U1", ""); got != "U1" {
+		t.Errorf("UserStateKey without group = %q, want U1", got)
+	}
+	if got := UserStateKey("U1", "C1"); got != "C1_U1" {
+		t.Errorf("UserStateKey in group = %q, want C1_U1", got)
+	}
+	// 班級群組中的練習紀錄與私訊分開
+	session := &PracticeSession{UserID: "U1", GroupID: "C1"}
+	if got := session.UserKey(); got != "C1_U1" {
+		t.Errorf("UserKey = %q, want C1_U1", got)
+	}
+}
+
+func TestUserStateClearMode(t *testing.T) {
+	state := &UserState{Mode: "cumulative_query", Publisher: "康軒", Grade: 2, Step: 4, PracticeSessionID: "s1", PreferredPublisher: "康軒", PreferredGrade: 2}
+	state.ClearMode()
+	if state.Mode != "" || state.Publisher != "" || state.Grade != 0 || state.Step != 0 || state.PracticeSessionID != "" {
+		t.Errorf("ClearMode left mode fields: %+v", state)
+	}
+	if state.PreferredPublisher != "康軒" || state.PreferredGrade != 2 {
+		t.Errorf("ClearMode should keep preferences: %+v", state)
+	}
+}
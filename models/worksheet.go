@@ -0,0 +1,47 @@
+package models
+
+// 字帖格線樣式
+const (
+	WorksheetGridTian = "tian" // 田字格
+	WorksheetGridMi   = "mi"   // 米字格
+)
+
+// 字帖內容來源
+const (
+	WorksheetSourceLesson    = "lesson"    // 單一課或連續幾課的生字
+	WorksheetSourceUnlearned = "unlearned" // 上次查詢中尚未學過的字
+	WorksheetSourceReview    = "review"    // 到期需要複習的字
+)
+
+// WorksheetSelection 字帖內容選擇
+type WorksheetSelection struct {
+	Source     string `json:"source"`     // 內容來源，空白表示尚未選擇
+	FromLesson int    `json:"fromLesson"` // 起始課次（Source 為 lesson 時）
+	ToLesson   int    `json:"toLesson"`   // 結束課次（Source 為 lesson 時，單一課時與起始相同）
+}
+
+// WorksheetOptions 字帖版面選項
+type WorksheetOptions struct {
+	Grid       string `json:"grid"`       // 格線樣式（tian / mi）
+	TraceCells int    `json:"traceCells"` // 每行描紅格數（範字之後）
+	Zhuyin     bool   `json:"zhuyin"`     // 範字旁是否標注音
+}
+
+// DefaultWorksheetOptions 預設字帖版面：田字格、描紅 3 格、標注音
+func DefaultWorksheetOptions() WorksheetOptions {
+	return WorksheetOptions{Grid: WorksheetGridTian, TraceCells: 3, Zhuyin: true}
+}
+
+// WorksheetRequest 字帖內容，會編碼在簽章下載網址中
+type WorksheetRequest struct {
+	Title      string           `json:"title"`      // 字帖標題
+	Characters []string         `json:"characters"` // 要練習的字
+	Options    WorksheetOptions `json:"options"`    // 版面選項
+	ExpiresAt  int64            `json:"expiresAt"`  // 下載網址到期時間（Unix 秒）
+}
+
+// WorksheetEntry 字帖中的一行：範字與注音
+type WorksheetEntry struct {
+	Character string `json:"character"` // 範字
+	Phonetic  string `json:"phonetic"`  // 注音
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"chinese-learning-linebot/models"
+)
+
+const (
+	challengeRecordCollection = "challenge_records"
+
+	// 個人最佳紀錄保留的筆數
+	maxChallengeRecords = 5
+
+	defaultChallengeQuestionCount = 10
+	defaultChallengeTimeLimit     = 120 // 秒
+
+	// 答對一題 100 分，10 秒內答對每快 1 秒再加 5 分
+	challengeCorrectPoints       = 100
+	challengeSpeedBonusSeconds   = 10
+	challengeSpeedBonusPerSecond = 5
+
+	// 時間到後再等一下才由排程結算，讓最後送出的答案先處理
+	challengeExpiryGrace = 30 * time.Second
+	// 排程只檢查這段時間內開始的挑戰
+	challengeExpiryWindow = time.Hour
+)
+
+// ChallengeConfig 挑戰模式的練習設定：題數取自 PRACTICE_MAX_QUESTIONS_PER_SESSION，總時間取自 PRACTICE_CHALLENGE_TIME_LIMIT_SECONDS
+// 有時間限制的混合練習只出可以點選答案的題型，且不會在作答中調整難度（見 sessionPracticeTypes）
+func ChallengeConfig() models.PracticeConfig {
+	return models.PracticeConfig{
+		Type:          models.PracticeTypeMixed,
+		QuestionCount: envInt("PRACTICE_MAX_QUESTIONS_PER_SESSION", defaultChallengeQuestionCount),
+		TimeLimit:     int64(envInt("PRACTICE_CHALLENGE_TIME_LIMIT_SECONDS", defaultChallengeTimeLimit)),
+	}
+}
+
+// 讀取正整數環境變數，未設定或格式錯誤時使用預設值
+func envInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// 挑戰模式一題的得分，timeSpent 為作答花費的毫秒數
+func challengePoints(isCorrect bool, timeSpent int64) int {
+	if !isCorrect {
+		return 0
+	}
+	points := challengeCorrectPoints
+	if seconds := int(timeSpent / 1000); seconds < challengeSpeedBonusSeconds {
+		points += (challengeSpeedBonusSeconds - seconds) * challengeSpeedBonusPerSecond
+	}
+	return points
+}
+
+// GetChallengeBoard 取得用戶挑戰模式的個人最佳紀錄，userID 為 models.UserStateKey 的鍵
+func (s *PracticeService) GetChallengeBoard(userID string) (*models.ChallengeBoard, error) {
+	doc, err := s.firebaseClient.Firestore.Collection(challengeRecordCollection).Doc(userID).Get(s.firebaseClient.Ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &models.ChallengeBoard{UserID: userID}, nil
+		}
+		return nil, fmt.Errorf("failed to get challenge records: %w", err)
+	}
+
+	var board models.ChallengeBoard
+	if err := doc.DataTo(&board); err != nil {
+		return nil, fmt.Errorf("failed to parse challenge records: %v", err)
+	}
+	board.UserID = userID
+	return &board, nil
+}
+
+// RecordChallenge 記錄挑戰成績並更新個人最佳紀錄，回傳紀錄與這次的名次（沒進入紀錄時為 0）
+func (s *PracticeService) RecordChallenge(session *models.PracticeSession) (*models.ChallengeBoard, int, error) {
+	board, err := s.GetChallengeBoard(session.UserKey())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	duration := session.TimeLimit * 1000
+	if len(session.Answers) > 0 {
+		if elapsed := session.Answers[len(session.Answers)-1].AnsweredAt - session.StartTime; elapsed < duration {
+			duration = elapsed
+		}
+	}
+	record := models.ChallengeRecord{
+		SessionID:  session.ID,
+		Points:     session.Points,
+		Correct:    session.Score,
+		Total:      session.TotalScore,
+		Duration:   duration,
+		AchievedAt: time.Now().Unix(),
+	}
+
+	// 同分時用時較短的排前面
+	board.Records = append(board.Records, record)
+	sort.SliceStable(board.Records, func(i, j int) bool {
+		if board.Records[i].Points != board.Records[j].Points {
+			return board.Records[i].Points > board.Records[j].Points
+		}
+		return board.Records[i].Duration < board.Records[j].Duration
+	})
+	if len(board.Records) > maxChallengeRecords {
+		board.Records = board.Records[:maxChallengeRecords]
+	}
+
+	rank := 0
+	for i, existing := range board.Records {
+		if existing.SessionID == session.ID {
+			rank = i + 1
+			break
+		}
+	}
+
+	if _, err := s.firebaseClient.Firestore.Collection(challengeRecordCollection).Doc(session.UserKey()).Set(s.firebaseClient.Ctx, board); err != nil {
+		return board, rank, fmt.Errorf("failed to save challenge records: %w", err)
+	}
+	return board, rank, nil
+}
+
+// ListExpiredChallenges 取得最近開始、已超過時間限制但還沒結算的挑戰（用戶時間到後沒再傳訊息）
+func (s *PracticeService) ListExpiredChallenges(now time.Time) ([]*models.PracticeSession, error) {
+	docs, err := s.firebaseClient.Firestore.Collection(practiceSessionCollection).
+		Where("startTime", ">=", now.Add(-challengeExpiryWindow).UnixMilli()).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query challenge sessions: %w", err)
+	}
+
+	var sessions []*models.PracticeSession
+	for _, doc := range docs {
+		var session models.PracticeSession
+		if err := doc.DataTo(&session); err != nil {
+			continue
+		}
+		if session.Completed || !session.TimedOut(now.Add(-challengeExpiryGrace).UnixMilli()) {
+			continue
+		}
+		session.ID = doc.Ref.ID
+		sessions = append(sessions, &session)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+func TestChallengePoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		isCorrect bool
+		timeSpent int64
+		want      int
+	}{
+		{"wrong answer", false, 1000, 0},
+		{"instant answer", true, 0, 150},
+		{"fast answer", true, 3500, 135},
+		{"at the bonus limit", true, 10000, 100},
+		{"slow answer", true, 45000, 100},
+	}
+
+	for _, tt := range tests {
+		if got := challengePoints(tt.isCorrect, tt.timeSpent); got != tt.want {
+			t.Errorf("%s: challengePoints = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChallengeUsesQuickReplyTypes(t *testing.T) {
+	session := &models.PracticeSession{Type: string(models.PracticeTypeMixed), TimeLimit: 120}
+	for difficulty := models.DifficultyEasy; difficulty <= models.DifficultyExpert; difficulty++ {
+		types := sessionPracticeTypes(session, difficulty)
+		if len(types) == 0 {
+			t.Errorf("difficulty %d has no challenge types", difficulty)
+		}
+		for _, practiceType := range types {
+			if practiceType == models.PracticeTypeSentence {
+				t.Errorf("difficulty %d includes sentence questions in a challenge", difficulty)
+			}
+		}
+	}
+
+	// 一般練習仍會出造句
+	practice := &models.PracticeSession{Type: string(models.PracticeTypeMixed)}
+	found := false
+	for _, practiceType := range sessionPracticeTypes(practice, models.DifficultyHard) {
+		found = found || practiceType == models.PracticeTypeSentence
+	}
+	if !found {
+		t.Errorf("practice at hard difficulty should include sentence questions")
+	}
+}
+
+func TestChallengeDoesNotAdapt(t *testing.T) {
+	useCharacterSeed(t, testCharacterIndex().characters)
+	service := testPracticeService()
+	service.characterService = NewCharacterService(nil)
+
+	// 連續答對兩題，一般練習的第三題會升到中等難度
+	newSession := func(timeLimit int64) *models.PracticeSession {
+		session := sessionWithAnswers([]int{1, 1, 1}, []bool{true, true})
+		session.TimeLimit = timeLimit
+		for i, char := range []string{"森", "林", "木"} {
+			session.Questions = append(session.Questions, models.PracticeQuestion{ID: fmt.Sprintf("q%d", i), Character: char})
+		}
+		return session
+	}
+
+	practice := newSession(0)
+	if hint := service.adaptNextQuestion(practice); hint == "" || practice.Levels[2] != int(models.DifficultyMedium) {
+		t.Fatalf("adaptNextQuestion in a practice = %q (level %d), want a harder question", hint, practice.Levels[2])
+	}
+
+	challenge := newSession(120)
+	if hint := service.adaptNextQuestion(challenge); hint != "" || challenge.Levels[2] != int(models.DifficultyEasy) || challenge.Questions[2].ID != "q2" {
+		t.Errorf("adaptNextQuestion in a challenge = %q (level %d, question %s), want no change", hint, challenge.Levels[2], challenge.Questions[2].ID)
+	}
+}
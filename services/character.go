@@ -2,6 +2,11 @@ package services
 
 import (
 	"fmt"
+	"log"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
 
 	"chinese-learning-linebot/config"
 	"chinese-learning-linebot/models"
@@ -9,16 +14,32 @@ import (
 
 type CharacterService struct {
 	firebaseClient *config.FirebaseClient
+	source         CharacterSource // 搜索索引的資料來源
 }
 
 func NewCharacterService(firebaseClient *config.FirebaseClient) *CharacterService {
+	// 沒有 Firestore 時改用 CHARACTER_DATA_PATH 載入的字詞資料
+	var source CharacterSource = seededCharacters()
+	if firebaseClient != nil {
+		source = NewFirestoreCharacterSource(firebaseClient)
+	}
 	return &CharacterService{
 		firebaseClient: firebaseClient,
+		source:         source,
 	}
 }
 
 func (s *CharacterService) LookupCharacter(char string) (*models.CharacterInfo, error) {
 	// 查詢單個字符
+	if s.firebaseClient == nil {
+		character := lookupCharacterSeed(char)
+		if character == nil {
+			return nil, fmt.Errorf("character not found: %s", char)
+		}
+		character.StrokeOrder = lookupStrokeDataset(char)
+		return character, nil
+	}
+
 	doc, err := s.firebaseClient.Firestore.Collection("characters").Doc(char).Get(s.firebaseClient.Ctx)
 	if err != nil {
 		return nil, fmt.Errorf("character not found: %v", err)
@@ -31,6 +52,7 @@ func (s *CharacterService) LookupCharacter(char string) (*models.CharacterInfo,
 
 	// 設置字符本身
 	character.Character = char
+	character.StrokeOrder = lookupStrokeDataset(char)
 
 	// 查詢該字符出現的課程
 	lessons, err := s.getLessonsForCharacter(char)
@@ -63,20 +85,126 @@ func (s *CharacterService) getLessonsForCharacter(char string) ([]string, error)
 	return lessons, nil
 }
 
-func (s *CharacterService) SearchCharacters(keyword string, limit int) ([]*models.CharacterInfo, error) {
-	// 模糊搜索字符（可以根據注音、部首等搜索）
-	if limit <= 0 {
-		limit = 10
+// GetCharacters 一次讀取多個字的資料（不含出現課程），查不到的字不會出現在結果中
+func (s *CharacterService) GetCharacters(chars []string) (map[string]*models.CharacterInfo, error) {
+	result := make(map[string]*models.CharacterInfo)
+	if len(chars) == 0 {
+		return result, nil
+	}
+	if s.firebaseClient == nil {
+		for _, char := range chars {
+			if character := lookupCharacterSeed(char); character != nil {
+				result[char] = character
+			}
+		}
+		return result, nil
+	}
+
+	collection := s.firebaseClient.Firestore.Collection("characters")
+	var refs []*firestore.DocumentRef
+	for _, char := range chars {
+		refs = append(refs, collection.Doc(char))
 	}
 
-	// 這裡可以實現更複雜的搜索邏輯
-	// 目前先實現簡單的精確匹配
-	character, err := s.LookupCharacter(keyword)
+	docs, err := s.firebaseClient.Firestore.GetAll(s.firebaseClient.Ctx, refs)
 	if err != nil {
-		return []*models.CharacterInfo{}, nil
+		return nil, fmt.Errorf("failed to get characters: %v", err)
+	}
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		var character models.CharacterInfo
+		if err := doc.DataTo(&character); err != nil {
+			continue
+		}
+		character.Character = doc.Ref.ID
+		result[character.Character] = &character
+	}
+	return result, nil
+}
+
+// SearchCharacters 依注音、部首、筆畫範圍搜索字詞，可限定在已學過的字之中
+// 限定範圍時只讀取這些字；不含注音時直接查詢 Firestore；注音搜索（不分聲調、破音字）才使用完整的字詞索引
+func (s *CharacterService) SearchCharacters(criteria models.CharacterSearchCriteria) (*models.CharacterSearchResult, error) {
+	if criteria.Within != nil {
+		characters, err := s.GetCharacters(criteria.Within)
+		if err != nil {
+			return nil, err
+		}
+		within := make([]*models.CharacterInfo, 0, len(characters))
+		for _, character := range characters {
+			within = append(within, character)
+		}
+		return NewCharacterIndex(within).Search(criteria), nil
 	}
 
-	return []*models.CharacterInfo{character}, nil
+	if s.firebaseClient != nil && criteria.Zhuyin == "" {
+		result, err := s.queryCharacters(criteria)
+		if err == nil {
+			return result, nil
+		}
+		// 例如缺少複合索引時，改用字詞索引
+		log.Printf("Error querying characters, falling back to the character index: %v", err)
+	}
+
+	index, err := loadCharacterIndex(s.source)
+	if err != nil {
+		return nil, err
+	}
+	return index.Search(criteria), nil
+}
+
+// 以部首與筆畫範圍查詢 Firestore，排序與字詞索引相同（筆畫數、再依字）
+// 同時指定部首與筆畫範圍時，需要 characters 集合 radical + strokeCount 的複合索引
+func (s *CharacterService) queryCharacters(criteria models.CharacterSearchCriteria) (*models.CharacterSearchResult, error) {
+	if criteria.Page <= 0 {
+		criteria.Page = 1
+	}
+	if criteria.PageSize <= 0 {
+		criteria.PageSize = 10
+	}
+
+	query := s.firebaseClient.Firestore.Collection("characters").Query
+	if criteria.Radical != "" {
+		query = query.Where("radical", "==", strings.TrimSuffix(criteria.Radical, "部"))
+	}
+	if criteria.MinStrokes > 0 {
+		query = query.Where("strokeCount", ">=", criteria.MinStrokes)
+	}
+	if criteria.MaxStrokes > 0 {
+		query = query.Where("strokeCount", "<=", criteria.MaxStrokes)
+	}
+	query = query.OrderBy("strokeCount", firestore.Asc).OrderBy(firestore.DocumentID, firestore.Asc)
+
+	counts, err := query.NewAggregationQuery().WithCount("total").Get(s.firebaseClient.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count characters: %v", err)
+	}
+	total, ok := counts["total"].(*firestorepb.Value)
+	if !ok {
+		return nil, fmt.Errorf("unexpected count result: %v", counts["total"])
+	}
+
+	docs, err := query.Offset((criteria.Page - 1) * criteria.PageSize).Limit(criteria.PageSize).Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query characters: %v", err)
+	}
+
+	result := &models.CharacterSearchResult{
+		Total:    int(total.GetIntegerValue()),
+		Page:     criteria.Page,
+		PageSize: criteria.PageSize,
+	}
+	for _, doc := range docs {
+		var character models.CharacterInfo
+		if err := doc.DataTo(&character); err != nil {
+			continue
+		}
+		character.Character = doc.Ref.ID
+		result.Characters = append(result.Characters, &character)
+	}
+	return result, nil
 }
 
 func (s *CharacterService) GetRandomCharacters(count int) ([]*models.CharacterInfo, error) {
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+)
+
+// 字詞索引重新載入的間隔
+const characterIndexTTL = 30 * time.Minute
+
+// CharacterSource 提供建立索引所需的全部字詞資料
+type CharacterSource interface {
+	LoadCharacters() ([]*models.CharacterInfo, error)
+	// CacheKey 區分不同資料來源的索引快取，同一份資料須回傳相同的值
+	CacheKey() string
+}
+
+// FirestoreCharacterSource 從 Firestore 的 characters 集合載入字詞
+type FirestoreCharacterSource struct {
+	firebaseClient *config.FirebaseClient
+}
+
+func NewFirestoreCharacterSource(firebaseClient *config.FirebaseClient) *FirestoreCharacterSource {
+	return &FirestoreCharacterSource{firebaseClient: firebaseClient}
+}
+
+func (s *FirestoreCharacterSource) LoadCharacters() ([]*models.CharacterInfo, error) {
+	docs, err := s.firebaseClient.Firestore.Collection("characters").Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load characters: %v", err)
+	}
+
+	characters := make([]*models.CharacterInfo, 0, len(docs))
+	for _, doc := range docs {
+		var character models.CharacterInfo
+		if err := doc.DataTo(&character); err != nil {
+			continue
+		}
+		character.Character = doc.Ref.ID
+		characters = append(characters, &character)
+	}
+	return characters, nil
+}
+
+func (s *FirestoreCharacterSource) CacheKey() string {
+	return fmt.Sprintf("firestore:%p", s.firebaseClient)
+}
+
+// MemoryCharacterSource 記憶體中的字詞資料（沒有 Firestore 時或匯入資料時使用）
+type MemoryCharacterSource []*models.CharacterInfo
+
+func (s MemoryCharacterSource) LoadCharacters() ([]*models.CharacterInfo, error) {
+	return s, nil
+}
+
+func (s MemoryCharacterSource) CacheKey() string {
+	if len(s) == 0 {
+		return "memory:empty"
+	}
+	return fmt.Sprintf("memory:%p:%d", s[0], len(s))
+}
+
+// 沒有 Firestore 時使用的字詞資料，由 LoadCharacterSeed 載入
+var (
+	characterSeedMu sync.RWMutex
+	characterSeed   MemoryCharacterSource
+)
+
+// LoadCharacterSeed 載入字詞資料檔（CharacterInfo 的 JSON 陣列，與 characters 集合的欄位相同），回傳載入的字數
+func LoadCharacterSeed(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read character data: %w", err)
+	}
+	var characters []*models.CharacterInfo
+	if err := json.Unmarshal(data, &characters); err != nil {
+		return 0, fmt.Errorf("invalid character data: %w", err)
+	}
+
+	seed := make(MemoryCharacterSource, 0, len(characters))
+	for _, character := range characters {
+		if character != nil && character.Character != "" {
+			seed = append(seed, character)
+		}
+	}
+
+	characterSeedMu.Lock()
+	characterSeed = seed
+	characterSeedMu.Unlock()
+	return len(seed), nil
+}
+
+// 目前載入的字詞資料
+func seededCharacters() MemoryCharacterSource {
+	characterSeedMu.RLock()
+	defer characterSeedMu.RUnlock()
+	return characterSeed
+}
+
+// 在字詞資料中查字，找不到時回傳 nil
+func lookupCharacterSeed(char string) *models.CharacterInfo {
+	for _, character := range seededCharacters() {
+		if character.Character == char {
+			copied := *character
+			return &copied
+		}
+	}
+	return nil
+}
+
+// CharacterIndex 依注音、部首、筆畫建立的字詞索引
+type CharacterIndex struct {
+	characters []*models.CharacterInfo
+	byZhuyin   map[string][]int // 含聲調的注音
+	byBare     map[string][]int // 去除聲調的注音
+	byRadical  map[string][]int
+}
+
+// NewCharacterIndex 建立索引，字詞依筆畫數、再依字排序
+func NewCharacterIndex(characters []*models.CharacterInfo) *CharacterIndex {
+	sorted := make([]*models.CharacterInfo, len(characters))
+	copy(sorted, characters)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].StrokeCount != sorted[j].StrokeCount {
+			return sorted[i].StrokeCount < sorted[j].StrokeCount
+		}
+		return sorted[i].Character < sorted[j].Character
+	})
+
+	index := &CharacterIndex{
+		characters: sorted,
+		byZhuyin:   make(map[string][]int),
+		byBare:     make(map[string][]int),
+		byRadical:  make(map[string][]int),
+	}
+	for i, character := range sorted {
+		for _, reading := range characterReadings(character) {
+			index.byZhuyin[reading] = appendUnique(index.byZhuyin[reading], i)
+			bare := StripZhuyinTone(reading)
+			index.byBare[bare] = appendUnique(index.byBare[bare], i)
+		}
+		if character.Radical != "" {
+			index.byRadical[character.Radical] = append(index.byRadical[character.Radical], i)
+		}
+	}
+	return index
+}
+
+// Search 依條件搜索並分頁
+func (idx *CharacterIndex) Search(criteria models.CharacterSearchCriteria) *models.CharacterSearchResult {
+	if criteria.Page <= 0 {
+		criteria.Page = 1
+	}
+	if criteria.PageSize <= 0 {
+		criteria.PageSize = 10
+	}
+
+	// 先以注音或部首縮小範圍，兩者皆無時掃描全部
+	var candidates []int
+	narrowed := false
+	if criteria.Zhuyin != "" {
+		zhuyin := strings.TrimSpace(criteria.Zhuyin)
+		if StripZhuyinTone(zhuyin) == zhuyin {
+			candidates = idx.byBare[zhuyin]
+		} else {
+			candidates = idx.byZhuyin[normalizeZhuyin(zhuyin)]
+		}
+		narrowed = true
+	}
+	if criteria.Radical != "" {
+		radicalMatches := idx.byRadical[strings.TrimSuffix(criteria.Radical, "部")]
+		if narrowed {
+			candidates = intersectSorted(candidates, radicalMatches)
+		} else {
+			candidates = radicalMatches
+		}
+		narrowed = true
+	}
+	if !narrowed {
+		candidates = make([]int, len(idx.characters))
+		for i := range candidates {
+			candidates[i] = i
+		}
+	}
+
+	var within map[string]bool
+	if criteria.Within != nil {
+		within = make(map[string]bool, len(criteria.Within))
+		for _, char := range criteria.Within {
+			within[char] = true
+		}
+	}
+
+	var matches []*models.CharacterInfo
+	for _, i := range candidates {
+		character := idx.characters[i]
+		if criteria.MinStrokes > 0 && character.StrokeCount < criteria.MinStrokes {
+			continue
+		}
+		if criteria.MaxStrokes > 0 && character.StrokeCount > criteria.MaxStrokes {
+			continue
+		}
+		if within != nil && !within[character.Character] {
+			continue
+		}
+		matches = append(matches, character)
+	}
+
+	result := &models.CharacterSearchResult{
+		Total:    len(matches),
+		Page:     criteria.Page,
+		PageSize: criteria.PageSize,
+	}
+	start := (criteria.Page - 1) * criteria.PageSize
+	if start < len(matches) {
+		end := start + criteria.PageSize
+		if end > len(matches) {
+			end = len(matches)
+		}
+		result.Characters = matches[start:end]
+	}
+	return result
+}
+
+// StripZhuyinTone 去除注音的聲調符號
+func StripZhuyinTone(zhuyin string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case 'ˉ', 'ˊ', 'ˇ', 'ˋ', '˙', ' ':
+			return -1
+		}
+		return r
+	}, zhuyin)
+}
+
+// 統一注音寫法：去除空白與一聲符號，輕聲符號移到最前面
+func normalizeZhuyin(zhuyin string) string {
+	zhuyin = strings.Map(func(r rune) rune {
+		if r == 'ˉ' || r == ' ' {
+			return -1
+		}
+		return r
+	}, zhuyin)
+	if strings.HasSuffix(zhuyin, "˙") {
+		zhuyin = "˙" + strings.TrimSuffix(zhuyin, "˙")
+	}
+	return zhuyin
+}
+
+// 字的所有讀音（破音字的每個讀音都可搜到）
+func characterReadings(character *models.CharacterInfo) []string {
+	var readings []string
+	for _, reading := range character.AllReadings() {
+		if reading.Phonetic != "" {
+			readings = append(readings, normalizeZhuyin(reading.Phonetic))
+		}
+	}
+	return readings
+}
+
+func appendUnique(indices []int, i int) []int {
+	if len(indices) > 0 && indices[len(indices)-1] == i {
+		return indices
+	}
+	return append(indices, i)
+}
+
+// 兩個遞增序列的交集
+func intersectSorted(a, b []int) []int {
+	var result []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// 共用的字詞索引快取（依資料來源區分），定期從資料來源重新載入
+type cachedCharacterIndex struct {
+	index    *CharacterIndex
+	loadedAt time.Time
+}
+
+var (
+	characterIndexMu    sync.Mutex
+	characterIndexCache = make(map[string]*cachedCharacterIndex)
+)
+
+// loadCharacterIndex 取得資料來源快取的索引，過期時從資料來源重建
+func loadCharacterIndex(source CharacterSource) (*CharacterIndex, error) {
+	characterIndexMu.Lock()
+	defer characterIndexMu.Unlock()
+
+	key := source.CacheKey()
+	cached := characterIndexCache[key]
+	if cached != nil && time.Since(cached.loadedAt) < characterIndexTTL {
+		return cached.index, nil
+	}
+
+	characters, err := source.LoadCharacters()
+	if err != nil {
+		if cached != nil {
+			// 重新載入失敗時沿用舊索引
+			return cached.index, nil
+		}
+		return nil, err
+	}
+	cached = &cachedCharacterIndex{index: NewCharacterIndex(characters), loadedAt: time.Now()}
+	characterIndexCache[key] = cached
+	return cached.index, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+func testCharacterIndex() *CharacterIndex {
+	return NewCharacterIndex([]*models.CharacterInfo{
+		{Character: "森", Phonetic: "ㄙㄣ", Radical: "木", StrokeCount: 12},
+		{Character: "林", Phonetic: "ㄌㄧㄣˊ", Radical: "木", StrokeCount: 8},
+		{Character: "木", Phonetic: "ㄇㄨˋ", Radical: "木", StrokeCount: 4},
+		{Character: "目", Phonetic: "ㄇㄨˋ", Radical: "目", StrokeCount: 5},
+		{Character: "沐", Phonetic: "ㄇㄨˋ", Radical: "水", StrokeCount: 7},
+		{Character: "媽", Phonetic: "ㄇㄚ", Radical: "女", StrokeCount: 13},
+		{Character: "嗎", Phonetic: "˙ㄇㄚ", Radical: "口", StrokeCount: 13},
+		{Character: "麻", Phonetic: "ㄇㄚˊ", Radical: "麻", StrokeCount: 11},
+	})
+}
+
+func searchCharacters(index *CharacterIndex, criteria models.CharacterSearchCriteria) []string {
+	var chars []string
+	for _, character := range index.Search(criteria).Characters {
+		chars = append(chars, character.Character)
+	}
+	return chars
+}
+
+func TestCharacterIndexSearch(t *testing.T) {
+	index := testCharacterIndex()
+	tests := []struct {
+		name     string
+		criteria models.CharacterSearchCriteria
+		want     []string
+	}{
+		// 結果依筆畫數排序
+		{"tone-less zhuyin", models.CharacterSearchCriteria{Zhuyin: "ㄇㄨ"}, []string{"木", "目", "沐"}},
+		{"tone-less matches every tone", models.CharacterSearchCriteria{Zhuyin: "ㄇㄚ"}, []string{"麻", "嗎", "媽"}},
+		{"toned zhuyin", models.CharacterSearchCriteria{Zhuyin: "ㄇㄚˊ"}, []string{"麻"}},
+		{"first tone mark", models.CharacterSearchCriteria{Zhuyin: "ㄇㄚˉ"}, []string{"媽"}},
+		{"neutral tone written last", models.CharacterSearchCriteria{Zhuyin: "ㄇㄚ˙"}, []string{"嗎"}},
+		{"radical", models.CharacterSearchCriteria{Radical: "木部"}, []string{"木", "林", "森"}},
+		{"zhuyin and radical", models.CharacterSearchCriteria{Zhuyin: "ㄇㄨ", Radical: "木"}, []string{"木"}},
+		{"stroke range", models.CharacterSearchCriteria{MinStrokes: 5, MaxStrokes: 8}, []string{"目", "沐", "林"}},
+		{"minimum strokes", models.CharacterSearchCriteria{MinStrokes: 13}, []string{"嗎", "媽"}},
+		{"within learned characters", models.CharacterSearchCriteria{Radical: "木", Within: []string{"森", "木"}}, []string{"木", "森"}},
+		{"no match", models.CharacterSearchCriteria{Zhuyin: "ㄅㄚ"}, nil},
+	}
+
+	for _, tt := range tests {
+		if got := searchCharacters(index, tt.criteria); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Search = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCharacterIndexPaging(t *testing.T) {
+	index := testCharacterIndex()
+	result := index.Search(models.CharacterSearchCriteria{MinStrokes: 1, Page: 2, PageSize: 3})
+	if result.Total != 8 || result.Page != 2 || len(result.Characters) != 3 || result.Characters[0].Character != "林" {
+		t.Errorf("page 2 = %+v", result)
+	}
+	if got := searchCharacters(index, models.CharacterSearchCriteria{MinStrokes: 1, Page: 3, PageSize: 3}); !reflect.DeepEqual(got, []string{"嗎", "媽"}) {
+		t.Errorf("last page = %v", got)
+	}
+	if got := searchCharacters(index, models.CharacterSearchCriteria{MinStrokes: 1, Page: 4, PageSize: 3}); got != nil {
+		t.Errorf("page past the end = %v, want empty", got)
+	}
+	// 預設第 1 頁、每頁 10 筆
+	if result := index.Search(models.CharacterSearchCriteria{MinStrokes: 1}); result.Page != 1 || result.PageSize != 10 || len(result.Characters) != 8 {
+		t.Errorf("default paging = %+v", result)
+	}
+}
+
+func TestStripZhuyinTone(t *testing.T) {
+	tests := map[string]string{"ㄇㄨˋ": "ㄇㄨ", "˙ㄇㄚ": "ㄇㄚ", "ㄌㄧㄣˊ": "ㄌㄧㄣ", "ㄙㄣ": "ㄙㄣ"}
+	for zhuyin, want := range tests {
+		if got := StripZhuyinTone(zhuyin); got != want {
+			t.Errorf("StripZhuyinTone(%q) = %q, want %q", zhuyin, got, want)
+		}
+	}
+}
+
+// 測試期間以 characters 作為沒有 Firestore 時的字詞資料
+func useCharacterSeed(t *testing.T, characters []*models.CharacterInfo) {
+	t.Helper()
+	characterSeedMu.Lock()
+	previous := characterSeed
+	characterSeed = MemoryCharacterSource(characters)
+	characterSeedMu.Unlock()
+	t.Cleanup(func() {
+		characterSeedMu.Lock()
+		characterSeed = previous
+		characterSeedMu.Unlock()
+	})
+}
+
+func TestSearchCharactersWithin(t *testing.T) {
+	useCharacterSeed(t, testCharacterIndex().characters)
+
+	s := NewCharacterService(nil)
+	result, err := s.SearchCharacters(models.CharacterSearchCriteria{Radical: "木", Within: []string{"森", "木", "目", "樹"}})
+	if err != nil {
+		t.Fatalf("SearchCharacters: %v", err)
+	}
+	var chars []string
+	for _, character := range result.Characters {
+		chars = append(chars, character.Character)
+	}
+	// 只在限定的字中搜索，沒有資料的字略過，依筆畫排序
+	if want := []string{"木", "森"}; !reflect.DeepEqual(chars, want) || result.Total != 2 {
+		t.Errorf("SearchCharacters within = %v (total %d), want %v", chars, result.Total, want)
+	}
+
+	found, err := s.GetCharacters([]string{"林", "樹"})
+	if err != nil || len(found) != 1 || found["林"] == nil {
+		t.Errorf("GetCharacters without Firestore = %v, %v", found, err)
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+)
+
+const classroomCollection = "classrooms"
+
+type ClassroomService struct {
+	firebaseClient *config.FirebaseClient
+}
+
+func NewClassroomService(firebaseClient *config.FirebaseClient) *ClassroomService {
+	return &ClassroomService{
+		firebaseClient: firebaseClient,
+	}
+}
+
+// GetClassroom 取得群組登記的班級，沒有登記時回傳 nil
+func (s *ClassroomService) GetClassroom(groupID string) (*models.Classroom, error) {
+	doc, err := s.firebaseClient.Firestore.Collection(classroomCollection).Doc(groupID).Get(s.firebaseClient.Ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get classroom: %w", err)
+	}
+
+	var classroom models.Classroom
+	if err := doc.DataTo(&classroom); err != nil {
+		return nil, fmt.Errorf("failed to parse classroom: %v", err)
+	}
+	classroom.GroupID = groupID
+	return &classroom, nil
+}
+
+// SaveClassroom 儲存班級設定（登記或更新課次）
+func (s *ClassroomService) SaveClassroom(classroom *models.Classroom) error {
+	now := time.Now().Unix()
+	if classroom.CreatedAt == 0 {
+		classroom.CreatedAt = now
+	}
+	classroom.UpdatedAt = now
+
+	_, err := s.firebaseClient.Firestore.Collection(classroomCollection).Doc(classroom.GroupID).Set(s.firebaseClient.Ctx, classroom)
+	if err != nil {
+		return fmt.Errorf("failed to save classroom: %w", err)
+	}
+	return nil
+}
+
+// DeleteClassroom 刪除班級（機器人被移出群組時）
+func (s *ClassroomService) DeleteClassroom(groupID string) error {
+	if _, err := s.firebaseClient.Firestore.Collection(classroomCollection).Doc(groupID).Delete(s.firebaseClient.Ctx); err != nil {
+		return fmt.Errorf("failed to delete classroom: %w", err)
+	}
+	return nil
+}
+
+// AddMember 記錄在群組中使用機器人的成員（老師不列入）
+func (s *ClassroomService) AddMember(classroom *models.Classroom, userID string) error {
+	if userID == "" || userID == classroom.TeacherID || containsString(classroom.Members, userID) {
+		return nil
+	}
+	_, err := s.firebaseClient.Firestore.Collection(classroomCollection).Doc(classroom.GroupID).Update(s.firebaseClient.Ctx, []firestore.Update{
+		{Path: "members", Value: firestore.ArrayUnion(userID)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add classroom member: %w", err)
+	}
+	classroom.Members = append(classroom.Members, userID)
+	return nil
+}
+
+// GetTeacherClassrooms 取得老師登記的所有班級
+func (s *ClassroomService) GetTeacherClassrooms(teacherID string) ([]*models.Classroom, error) {
+	docs, err := s.firebaseClient.Firestore.Collection(classroomCollection).
+		Where("teacherId", "==", teacherID).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query classrooms: %w", err)
+	}
+
+	var classrooms []*models.Classroom
+	for _, doc := range docs {
+		var classroom models.Classroom
+		if err := doc.DataTo(&classroom); err != nil {
+			continue
+		}
+		classroom.GroupID = doc.Ref.ID
+		classrooms = append(classrooms, &classroom)
+	}
+	sort.Slice(classrooms, func(i, j int) bool {
+		return classrooms[i].CreatedAt < classrooms[j].CreatedAt
+	})
+	return classrooms, nil
+}
+
+// GetReport 統計班級在群組中完成的練習：全班正確率、最常答錯的字與各成員的參與情形
+func (s *ClassroomService) GetReport(classroom *models.Classroom, since time.Time) (*models.ClassroomReport, error) {
+	sessions, err := NewPracticeService(s.firebaseClient).GetGroupSessionsSince(classroom.GroupID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ClassroomReport{
+		Classroom:   classroom,
+		PeriodStart: since.Unix(),
+		PeriodEnd:   time.Now().Unix(),
+		Sessions:    len(sessions),
+	}
+	report.AccuracyByType, report.MostMissed = summarizeAnswers(sessions)
+
+	participants := make(map[string]*models.ClassroomParticipant)
+	participant := func(userID string) *models.ClassroomParticipant {
+		if participants[userID] == nil {
+			participants[userID] = &models.ClassroomParticipant{UserID: userID}
+		}
+		return participants[userID]
+	}
+	for _, member := range classroom.Members {
+		participant(member)
+	}
+	for _, session := range sessions {
+		entry := participant(session.UserID)
+		entry.Sessions++
+		for _, answer := range session.Answers {
+			entry.Questions++
+			report.Questions++
+			if answer.IsCorrect {
+				entry.Correct++
+				report.Correct++
+			}
+		}
+	}
+
+	// 練習較多的成員排前面，沒有練習的成員排最後
+	for _, entry := range participants {
+		report.Participants = append(report.Participants, *entry)
+	}
+	sort.Slice(report.Participants, func(i, j int) bool {
+		a, b := report.Participants[i], report.Participants[j]
+		if a.Sessions != b.Sessions {
+			return a.Sessions > b.Sessions
+		}
+		return a.UserID < b.UserID
+	})
+	return report, nil
+}
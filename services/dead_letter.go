@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+)
+
+const deadLetterCollection = "dead_letters"
+
+// 沒有 Firestore 時保留在記憶體中的最大筆數
+const maxInMemoryDeadLetters = 100
+
+type DeadLetterService struct {
+	firebaseClient *config.FirebaseClient
+
+	mu       sync.Mutex
+	inMemory []*models.DeadLetter
+}
+
+func NewDeadLetterService(firebaseClient *config.FirebaseClient) *DeadLetterService {
+	return &DeadLetterService{
+		firebaseClient: firebaseClient,
+	}
+}
+
+// Record 記錄處理失敗的事件
+// 儲存失敗時退回記憶體保存，確保失敗事件不會遺失於日誌之外
+func (s *DeadLetterService) Record(letter *models.DeadLetter) {
+	if letter.OccurredAt == 0 {
+		letter.OccurredAt = time.Now().Unix()
+	}
+
+	if s.firebaseClient != nil {
+		ref := s.firebaseClient.Firestore.Collection(deadLetterCollection).NewDoc()
+		letter.ID = ref.ID
+		_, err := ref.Set(s.firebaseClient.Ctx, letter)
+		if err == nil {
+			return
+		}
+		log.Printf("Error saving dead letter: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if letter.ID == "" {
+		letter.ID = fmt.Sprintf("mem_%d", time.Now().UnixNano())
+	}
+	s.inMemory = append(s.inMemory, letter)
+	if len(s.inMemory) > maxInMemoryDeadLetters {
+		s.inMemory = s.inMemory[len(s.inMemory)-maxInMemoryDeadLetters:]
+	}
+}
+
+// ListRecent 取得最近的失敗事件（新到舊）
+func (s *DeadLetterService) ListRecent(limit int) ([]*models.DeadLetter, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var letters []*models.DeadLetter
+
+	if s.firebaseClient != nil {
+		docs, err := s.firebaseClient.Firestore.Collection(deadLetterCollection).
+			OrderBy("occurredAt", firestore.Desc).
+			Limit(limit).
+			Documents(s.firebaseClient.Ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query dead letters: %v", err)
+		}
+		for _, doc := range docs {
+			var letter models.DeadLetter
+			if err := doc.DataTo(&letter); err != nil {
+				continue
+			}
+			letter.ID = doc.Ref.ID
+			letters = append(letters, &letter)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.inMemory) - 1; i >= 0 && len(letters) < limit; i-- {
+		letters = append(letters, s.inMemory[i])
+	}
+
+	return letters, nil
+}
+
+// MarkResolved 標記失敗事件為已處理
+func (s *DeadLetterService) MarkResolved(id string) error {
+	s.mu.Lock()
+	for _, letter := range s.inMemory {
+		if letter.ID == id {
+			letter.Resolved = true
+			s.mu.Unlock()
+			return nil
+		}
+	}
+	s.mu.Unlock()
+
+	if s.firebaseClient == nil {
+		return fmt.Errorf("dead letter not found: %s", id)
+	}
+
+	_, err := s.firebaseClient.Firestore.Collection(deadLetterCollection).Doc(id).Update(s.firebaseClient.Ctx, []firestore.Update{
+		{Path: "resolved", Value: true},
+	})
+	return err
+}
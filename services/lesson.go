@@ -163,4 +163,129 @@ func getStringFromData(data map[string]interface{}, key string) string {
 		return value
 	}
 	return ""
-}
\ No newline at end of file
+}
+// GetLesson 取得指定課次的課程資訊
+func (s *LessonService) GetLesson(publisher string, grade int, semester int, lesson int) (*models.LessonInfo, error) {
+	docs, err := s.firebaseClient.Firestore.Collection("lessons").
+		Where("publisher", "==", publisher).
+		Where("grade", "==", grade).
+		Where("semester", "==", semester).
+		Where("lesson", "==", lesson).
+		Limit(1).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lesson: %v", err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("lesson not found: %s %d-%d L%d", publisher, grade, semester, lesson)
+	}
+
+	data := docs[0].Data()
+	info := &models.LessonInfo{
+		ID:         docs[0].Ref.ID,
+		Title:      getStringFromData(data, "title"),
+		Unit:       getStringFromData(data, "unit"),
+		Publisher:  publisher,
+		Grade:      grade,
+		Semester:   semester,
+		Order:      lesson,
+		Characters: getCharactersFromData(data),
+		Words:      getStringSliceFromData(data, "words"),
+	}
+	info.CharacterCount = len(info.Characters)
+
+	return info, nil
+}
+
+// 解析文檔中的字串陣列欄位
+func getStringSliceFromData(data map[string]interface{}, key string) []string {
+	var values []string
+	items, ok := data[key].([]interface{})
+	if !ok {
+		return values
+	}
+	for _, item := range items {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// 解析課程文檔中的字符（支援字串陣列或 {character: ...} 物件陣列）
+func getCharactersFromData(data map[string]interface{}) []string {
+	var characters []string
+	chars, ok := data["characters"].([]interface{})
+	if !ok {
+		return characters
+	}
+	for _, char := range chars {
+		switch value := char.(type) {
+		case string:
+			characters = append(characters, value)
+		case map[string]interface{}:
+			if charStr, ok := value["character"].(string); ok {
+				characters = append(characters, charStr)
+			}
+		}
+	}
+	return characters
+}
+
+// FindIntroducingLesson 找出指定出版社中最早教到該字的課次，沒有任何課教到時回傳 nil
+func (s *LessonService) FindIntroducingLesson(publisher string, char string) (*models.LessonInfo, error) {
+	docs, err := s.firebaseClient.Firestore.Collection("lessons").
+		Where("publisher", "==", publisher).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lessons: %v", err)
+	}
+
+	var earliest *models.LessonInfo
+	for _, doc := range docs {
+		data := doc.Data()
+		grade, _ := data["grade"].(int64)
+		semester, _ := data["semester"].(int64)
+		lesson, _ := data["lesson"].(int64)
+
+		characters := getCharactersFromData(data)
+		found := false
+		for _, c := range characters {
+			if c == char {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		candidate := &models.LessonInfo{
+			ID:             doc.Ref.ID,
+			Title:          getStringFromData(data, "title"),
+			Unit:           getStringFromData(data, "unit"),
+			Publisher:      publisher,
+			Grade:          int(grade),
+			Semester:       int(semester),
+			Order:          int(lesson),
+			Characters:     characters,
+			CharacterCount: len(characters),
+			Words:          getStringSliceFromData(data, "words"),
+		}
+		if earliest == nil || lessonBefore(candidate, earliest) {
+			earliest = candidate
+		}
+	}
+	return earliest, nil
+}
+
+// 依年級、學期、課次比較先後
+func lessonBefore(a, b *models.LessonInfo) bool {
+	if a.Grade != b.Grade {
+		return a.Grade < b.Grade
+	}
+	if a.Semester != b.Semester {
+		return a.Semester < b.Semester
+	}
+	return a.Order < b.Order
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+func TestLessonBefore(t *testing.T) {
+	lesson := func(grade, semester, order int) *models.LessonInfo {
+		return &models.LessonInfo{Grade: grade, Semester: semester, Order: order}
+	}
+	tests := []struct {
+		a, b *models.LessonInfo
+		want bool
+	}{
+		{lesson(1, 1, 5), lesson(1, 1, 6), true},
+		{lesson(1, 2, 1), lesson(1, 1, 12), false},
+		{lesson(1, 2, 12), lesson(2, 1, 1), true},
+		{lesson(2, 1, 3), lesson(2, 1, 3), false},
+	}
+	for _, tt := range tests {
+		if got := lessonBefore(tt.a, tt.b); got != tt.want {
+			t.Errorf("lessonBefore(%+v, %+v) = %v, want %v", *tt.a, *tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGetStringSliceFromData(t *testing.T) {
+	data := map[string]interface{}{"words": []interface{}{"森林", 3, "散步"}, "title": "森林"}
+	if got := getStringSliceFromData(data, "words"); !reflect.DeepEqual(got, []string{"森林", "散步"}) {
+		t.Errorf("words = %v", got)
+	}
+	if got := getStringSliceFromData(data, "title"); got != nil {
+		t.Errorf("non-array field = %v, want nil", got)
+	}
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/google/uuid"
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"golang.org/x/time/rate"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/utils"
+)
+
+const (
+	messageQuotaCollection = "message_quota"
+
+	// LINE Messaging API 限制
+	maxMulticastRecipients = 500 // 每次 multicast 最多收件人數
+	maxMessagesPerRequest  = 5   // 每次請求最多訊息數
+
+	maxSendAttempts  = 4
+	initialSendDelay = 500 * time.Millisecond
+
+	// 廣播前估算人數時，最多往前找幾天的好友統計
+	followerStatisticsLookback = 3
+)
+
+// MessagingService 主動推播訊息服務（push、multicast、broadcast）
+type MessagingService struct {
+	bot            *linebot.Client
+	firebaseClient *config.FirebaseClient
+
+	pushLimiter      *rate.Limiter
+	multicastLimiter *rate.Limiter
+	broadcastLimiter *rate.Limiter
+
+	mu    sync.Mutex
+	quota *models.MessageQuota
+}
+
+func NewMessagingService(bot *linebot.Client, firebaseClient *config.FirebaseClient) *MessagingService {
+	return &MessagingService{
+		bot:            bot,
+		firebaseClient: firebaseClient,
+		// 依照 LINE 官方速率限制設定（push 2,000 次/秒、multicast 200 次/秒、broadcast 60 次/小時）
+		pushLimiter:      rate.NewLimiter(rate.Limit(2000), 100),
+		multicastLimiter: rate.NewLimiter(rate.Limit(200), 10),
+		broadcastLimiter: rate.NewLimiter(rate.Every(time.Hour/60), 1),
+	}
+}
+
+// Push 推播訊息給單一用戶
+func (s *MessagingService) Push(ctx context.Context, to string, messages ...linebot.SendingMessage) error {
+	if err := validateMessages(messages); err != nil {
+		return err
+	}
+	if err := s.reserveQuota(ctx, 1); err != nil {
+		return err
+	}
+
+	retryKey := uuid.NewString()
+	err := s.sendWithRetry(ctx, s.pushLimiter, func() error {
+		_, err := s.bot.PushMessage(to, messages...).WithContext(ctx).WithRetryKey(retryKey).Do()
+		return err
+	})
+	if err != nil {
+		s.releaseQuota(1)
+	}
+	return err
+}
+
+// Multicast 推播訊息給多位用戶（自動依每批 500 人分批發送）
+func (s *MessagingService) Multicast(ctx context.Context, to []string, messages ...linebot.SendingMessage) error {
+	if err := validateMessages(messages); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(to); start += maxMulticastRecipients {
+		end := start + maxMulticastRecipients
+		if end > len(to) {
+			end = len(to)
+		}
+		batch := to[start:end]
+
+		if err := s.reserveQuota(ctx, int64(len(batch))); err != nil {
+			return err
+		}
+
+		retryKey := uuid.NewString()
+		err := s.sendWithRetry(ctx, s.multicastLimiter, func() error {
+			_, err := s.bot.Multicast(batch, messages...).WithContext(ctx).WithRetryKey(retryKey).Do()
+			return err
+		})
+		if err != nil {
+			s.releaseQuota(int64(len(batch)))
+			return fmt.Errorf("multicast batch %d-%d failed: %w", start, end, err)
+		}
+	}
+
+	return nil
+}
+
+// Broadcast 推播訊息給所有好友
+// 實際消耗則數取決於好友數，以前一天可觸及的好友數預留額度
+func (s *MessagingService) Broadcast(ctx context.Context, messages ...linebot.SendingMessage) error {
+	if err := validateMessages(messages); err != nil {
+		return err
+	}
+	audience, err := s.broadcastAudience(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.reserveQuota(ctx, audience); err != nil {
+		return err
+	}
+
+	retryKey := uuid.NewString()
+	err = s.sendWithRetry(ctx, s.broadcastLimiter, func() error {
+		_, err := s.bot.BroadcastMessage(messages...).WithContext(ctx).WithRetryKey(retryKey).Do()
+		return err
+	})
+	if err != nil {
+		s.releaseQuota(audience)
+	}
+	return err
+}
+
+// 廣播會送達的人數：LINE 的好友統計只到前一天，且當天稍晚才會產生，尚未產生時改用更早的統計
+func (s *MessagingService) broadcastAudience(ctx context.Context) (int64, error) {
+	now := time.Now().In(taipeiLocation())
+	for days := 1; days <= followerStatisticsLookback; days++ {
+		date := now.AddDate(0, 0, -days).Format("20060102")
+		followers, err := s.bot.GetNumberFollowers(date).WithContext(ctx).Do()
+		if err != nil {
+			return 0, utils.NewTransientError("failed to get number of followers", err)
+		}
+		if followers.Status == "ready" {
+			return followers.TargetedReaches, nil
+		}
+	}
+	return 0, utils.NewTransientError("follower statistics not ready",
+		fmt.Errorf("no statistics in the last %d days", followerStatisticsLookback))
+}
+
+// GetQuota 取得本月額度使用狀況
+func (s *MessagingService) GetQuota(ctx context.Context) (*models.MessageQuota, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadQuotaLocked(ctx); err != nil {
+		return nil, err
+	}
+	quota := *s.quota
+	return &quota, nil
+}
+
+// RefreshQuota 從 LINE API 同步本月額度與使用量
+func (s *MessagingService) RefreshQuota(ctx context.Context) (*models.MessageQuota, error) {
+	quotaResp, err := s.bot.GetMessageQuota().WithContext(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message quota: %w", err)
+	}
+	consumption, err := s.bot.GetMessageConsumption().WithContext(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message consumption: %w", err)
+	}
+
+	quota := &models.MessageQuota{
+		Month:     currentQuotaMonth(),
+		Limit:     -1,
+		Used:      consumption.TotalUsage,
+		UpdatedAt: time.Now().Unix(),
+	}
+	if quotaResp.Type == "limited" {
+		quota.Limit = quotaResp.Value
+	}
+
+	s.mu.Lock()
+	s.quota = quota
+	s.mu.Unlock()
+	s.saveQuota(ctx, quota)
+
+	result := *quota
+	return &result, nil
+}
+
+// 預留額度，額度不足時回傳永久性錯誤
+func (s *MessagingService) reserveQuota(ctx context.Context, count int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadQuotaLocked(ctx); err != nil {
+		return err
+	}
+
+	remaining := s.quota.Remaining()
+	if remaining == 0 || (remaining > 0 && count > remaining) {
+		return utils.NewPermanentError("monthly message quota exceeded",
+			fmt.Errorf("need %d, remaining %d of %d", count, remaining, s.quota.Limit))
+	}
+
+	if count > 0 {
+		s.quota.Used += count
+		s.quota.UpdatedAt = time.Now().Unix()
+		s.incrementStoredQuota(ctx, count)
+	}
+	return nil
+}
+
+// 發送失敗時歸還預留的額度
+func (s *MessagingService) releaseQuota(count int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.quota == nil {
+		return
+	}
+	s.quota.Used -= count
+	if s.quota.Used < 0 {
+		s.quota.Used = 0
+	}
+	s.incrementStoredQuota(context.Background(), -count)
+}
+
+// 載入本月額度（跨月時重新計算）
+func (s *MessagingService) loadQuotaLocked(ctx context.Context) error {
+	month := currentQuotaMonth()
+	if s.quota != nil && s.quota.Month == month {
+		return nil
+	}
+
+	quota := &models.MessageQuota{Month: month, Limit: -1}
+	if s.firebaseClient != nil {
+		doc, err := s.firebaseClient.Firestore.Collection(messageQuotaCollection).Doc(month).Get(ctx)
+		if err == nil {
+			if err := doc.DataTo(quota); err != nil {
+				log.Printf("Error parsing message quota: %v", err)
+			}
+		}
+	}
+	// 環境變數設定的上限優先
+	if limit := quotaLimitFromEnv(); limit >= 0 {
+		quota.Limit = limit
+	}
+	s.quota = quota
+	return nil
+}
+
+func (s *MessagingService) incrementStoredQuota(ctx context.Context, count int64) {
+	if s.firebaseClient == nil {
+		return
+	}
+	_, err := s.firebaseClient.Firestore.Collection(messageQuotaCollection).Doc(s.quota.Month).Set(ctx, map[string]interface{}{
+		"month":     s.quota.Month,
+		"limit":     s.quota.Limit,
+		"used":      firestore.Increment(count),
+		"updatedAt": time.Now().Unix(),
+	}, firestore.MergeAll)
+	if err != nil {
+		log.Printf("Error updating message quota: %v", err)
+	}
+}
+
+func (s *MessagingService) saveQuota(ctx context.Context, quota *models.MessageQuota) {
+	if s.firebaseClient == nil {
+		return
+	}
+	if _, err := s.firebaseClient.Firestore.Collection(messageQuotaCollection).Doc(quota.Month).Set(ctx, quota); err != nil {
+		log.Printf("Error saving message quota: %v", err)
+	}
+}
+
+// 依速率限制發送，遇到 429、5xx 或網路錯誤時以指數退避重試
+// 同一次發送使用相同的 retry key，避免重試造成重複推播
+func (s *MessagingService) sendWithRetry(ctx context.Context, limiter *rate.Limiter, send func() error) error {
+	delay := initialSendDelay
+	var lastErr error
+
+	for attempt := 1; attempt <= maxSendAttempts; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return utils.NewTransientError("rate limiter wait cancelled", err)
+		}
+
+		lastErr = send()
+		if lastErr == nil {
+			return nil
+		}
+
+		var apiErr *linebot.APIError
+		var netErr net.Error
+		switch {
+		case errors.As(lastErr, &apiErr):
+			// 409 表示相同 retry key 的請求已被接受
+			if apiErr.Code == http.StatusConflict {
+				return nil
+			}
+			if apiErr.Code != http.StatusTooManyRequests && apiErr.Code < http.StatusInternalServerError {
+				return utils.NewPermanentError("LINE API rejected message", lastErr)
+			}
+		case errors.As(lastErr, &netErr):
+			// 連線失敗或逾時：請求可能已送達，以相同 retry key 重送不會重複推播
+			if ctx.Err() != nil {
+				return utils.NewTransientError("send cancelled", ctx.Err())
+			}
+		default:
+			return lastErr
+		}
+
+		if attempt < maxSendAttempts {
+			log.Printf("LINE API error, retrying in %v (attempt %d/%d): %v", delay, attempt, maxSendAttempts, lastErr)
+			select {
+			case <-ctx.Done():
+				return utils.NewTransientError("send cancelled", ctx.Err())
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+	}
+
+	return utils.NewTransientError("LINE API unavailable after retries", lastErr)
+}
+
+func validateMessages(messages []linebot.SendingMessage) error {
+	if len(messages) == 0 {
+		return fmt.Errorf("no messages to send")
+	}
+	if len(messages) > maxMessagesPerRequest {
+		return fmt.Errorf("too many messages: %d (max %d)", len(messages), maxMessagesPerRequest)
+	}
+	return nil
+}
+
+func currentQuotaMonth() string {
+	return time.Now().In(taipeiLocation()).Format("2006-01")
+}
+
+// 從 LINE_MONTHLY_MESSAGE_QUOTA 讀取每月額度上限（未設定時回傳 -1）
+func quotaLimitFromEnv() int64 {
+	value := os.Getenv("LINE_MONTHLY_MESSAGE_QUOTA")
+	if value == "" {
+		return -1
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		return -1
+	}
+	return limit
+}
+
+// taipeiLocation 取得台北時區（LINE 額度以台灣時間計月）
+func taipeiLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		return time.FixedZone("Asia/Taipei", 8*60*60)
+	}
+	return loc
+}
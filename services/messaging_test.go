@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+	"golang.org/x/time/rate"
+
+	"chinese-learning-linebot/utils"
+)
+
+// 依序回傳指定錯誤的發送函式，並記錄呼叫次數
+func scriptedSend(calls *int, results ...error) func() error {
+	return func() error {
+		err := results[*calls]
+		*calls++
+		return err
+	}
+}
+
+func TestSendWithRetry(t *testing.T) {
+	s := NewMessagingService(nil, nil)
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	tests := []struct {
+		name      string
+		results   []error
+		wantCalls int
+		wantKind  utils.ErrorKind
+	}{
+		{"success", []error{nil}, 1, ""},
+		{"retries rate limit", []error{&linebot.APIError{Code: http.StatusTooManyRequests}, nil}, 2, ""},
+		// 相同 retry key 的請求已被接受，不再重送
+		{"conflict means accepted", []error{&linebot.APIError{Code: http.StatusConflict}}, 1, ""},
+		{"bad request is permanent", []error{&linebot.APIError{Code: http.StatusBadRequest}}, 1, utils.ErrorKindPermanent},
+		// 連線失敗或逾時也以相同 retry key 重送
+		{"retries network errors", []error{&url.Error{Op: "Post", URL: "https://api.line.me", Err: errors.New("connection reset")}, nil}, 2, ""},
+		{"retries timeouts", []error{&url.Error{Op: "Post", URL: "https://api.line.me", Err: context.DeadlineExceeded}, nil}, 2, ""},
+		{"other errors are not retried", []error{errors.New("invalid message")}, 1, utils.ErrorKindPermanent},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		err := s.sendWithRetry(context.Background(), limiter, scriptedSend(&calls, tt.results...))
+		if calls != tt.wantCalls {
+			t.Errorf("%s: send called %d times, want %d", tt.name, calls, tt.wantCalls)
+		}
+		if got := utils.ClassifyError(err); got != tt.wantKind {
+			t.Errorf("%s: error kind = %q, want %q (err %v)", tt.name, got, tt.wantKind, err)
+		}
+	}
+}
+
+func TestSendWithRetryStopsWhenCancelled(t *testing.T) {
+	s := NewMessagingService(nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := s.sendWithRetry(ctx, rate.NewLimiter(rate.Inf, 1), func() error {
+		calls++
+		cancel()
+		return &linebot.APIError{Code: http.StatusServiceUnavailable}
+	})
+	if calls != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled send: calls = %d, err = %v", calls, err)
+	}
+}
+
+func TestQuotaReserveAndRelease(t *testing.T) {
+	t.Setenv("LINE_MONTHLY_MESSAGE_QUOTA", "10")
+	s := NewMessagingService(nil, nil)
+	ctx := context.Background()
+
+	if err := s.reserveQuota(ctx, 8); err != nil {
+		t.Fatalf("reserve 8: %v", err)
+	}
+	err := s.reserveQuota(ctx, 3)
+	if utils.ClassifyError(err) != utils.ErrorKindPermanent {
+		t.Fatalf("reserve over quota: err = %v, want permanent error", err)
+	}
+
+	// 發送失敗歸還額度後可再預留
+	s.releaseQuota(8)
+	if err := s.reserveQuota(ctx, 3); err != nil {
+		t.Fatalf("reserve after release: %v", err)
+	}
+	quota, err := s.GetQuota(ctx)
+	if err != nil {
+		t.Fatalf("GetQuota: %v", err)
+	}
+	if quota.Used != 3 || quota.Remaining() != 7 {
+		t.Errorf("quota used = %d, remaining = %d; want 3, 7", quota.Used, quota.Remaining())
+	}
+}
+
+func TestQuotaUnlimited(t *testing.T) {
+	t.Setenv("LINE_MONTHLY_MESSAGE_QUOTA", "")
+	s := NewMessagingService(nil, nil)
+	if err := s.reserveQuota(context.Background(), 100000); err != nil {
+		t.Errorf("reserve without a limit: %v", err)
+	}
+}
+
+// 模擬 LINE API：前一天的好友統計尚未產生，前兩天可觸及 4 人；廣播回傳 status
+func fakeBroadcastAPI(t *testing.T, broadcastStatus int) *linebot.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/bot/insight/followers":
+			if r.URL.Query().Get("date") == time.Now().In(taipeiLocation()).AddDate(0, 0, -1).Format("20060102") {
+				w.Write([]byte(`{"status":"unready"}`))
+				return
+			}
+			w.Write([]byte(`{"status":"ready","followers":5,"targetedReaches":4,"blocks":1}`))
+		case "/v2/bot/message/broadcast":
+			w.WriteHeader(broadcastStatus)
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	bot, err := linebot.New("secret", "token", linebot.WithEndpointBase(server.URL))
+	if err != nil {
+		t.Fatalf("linebot.New: %v", err)
+	}
+	return bot
+}
+
+func TestBroadcastReservesAudience(t *testing.T) {
+	t.Setenv("LINE_MONTHLY_MESSAGE_QUOTA", "10")
+	ctx := context.Background()
+
+	s := NewMessagingService(fakeBroadcastAPI(t, http.StatusOK), nil)
+	if err := s.Broadcast(ctx, linebot.NewTextMessage("今日一字")); err != nil {
+		t.Fatalf("Broadcast: %v", err)
+	}
+	if quota, _ := s.GetQuota(ctx); quota.Used != 4 {
+		t.Errorf("quota used after broadcast = %d, want 4", quota.Used)
+	}
+
+	// 剩餘額度不足以送給所有好友時不發送
+	if err := s.reserveQuota(ctx, 3); err != nil {
+		t.Fatalf("reserve 3: %v", err)
+	}
+	if err := s.Broadcast(ctx, linebot.NewTextMessage("今日一字")); utils.ClassifyError(err) != utils.ErrorKindPermanent {
+		t.Errorf("Broadcast over quota: err = %v, want permanent error", err)
+	}
+
+	// 發送失敗時歸還額度
+	failing := NewMessagingService(fakeBroadcastAPI(t, http.StatusBadRequest), nil)
+	if err := failing.Broadcast(ctx, linebot.NewTextMessage("今日一字")); err == nil {
+		t.Fatalf("Broadcast rejected by LINE succeeded")
+	}
+	if quota, _ := failing.GetQuota(ctx); quota.Used != 0 {
+		t.Errorf("quota used after failed broadcast = %d, want 0", quota.Used)
+	}
+}
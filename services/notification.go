@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/utils"
+)
+
+// 複習提醒最多列出的字數
+const maxReviewReminderChars = 10
+
+// NotificationService 排程推播（每日一字、複習提醒、學習週報）
+type NotificationService struct {
+	firebaseClient      *config.FirebaseClient
+	messagingService    *MessagingService
+	subscriptionService *SubscriptionService
+	lessonService       *LessonService
+	characterService    *CharacterService
+	reviewService       *ReviewService
+	reportService       *ReportService
+	location            *time.Location
+}
+
+func NewNotificationService(firebaseClient *config.FirebaseClient, messagingService *MessagingService) *NotificationService {
+	return &NotificationService{
+		firebaseClient:      firebaseClient,
+		messagingService:    messagingService,
+		subscriptionService: NewSubscriptionService(firebaseClient),
+		lessonService:       NewLessonService(firebaseClient),
+		characterService:    NewCharacterService(firebaseClient),
+		reviewService:       NewReviewService(firebaseClient),
+		reportService:       NewReportService(firebaseClient),
+		location:            taipeiLocation(),
+	}
+}
+
+// RegisterJobs 將推播工作註冊到排程器
+func (s *NotificationService) RegisterJobs(scheduler *Scheduler, dailySpec string, reviewSpec string, weeklyReportSpec string) error {
+	if err := scheduler.AddJob("daily_character", dailySpec, s.SendDailyCharacters); err != nil {
+		return err
+	}
+	// 每小時補送因勿擾時段延後的每日一字
+	if err := scheduler.AddJob("daily_character_pending", "0 * * * *", s.SendPendingDailyCharacters); err != nil {
+		return err
+	}
+	if err := scheduler.AddJob("review_reminder", reviewSpec, s.SendReviewReminders); err != nil {
+		return err
+	}
+	return scheduler.AddJob("weekly_report", weeklyReportSpec, s.SendWeeklyReports)
+}
+
+// SendDailyCharacters 推播每日一字給所有訂閱用戶
+func (s *NotificationService) SendDailyCharacters(ctx context.Context) {
+	subscriptions, err := s.subscriptionService.ListSubscribers("dailyCharacter")
+	if err != nil {
+		log.Printf("Error listing daily character subscribers: %v", err)
+		return
+	}
+
+	now := time.Now().In(s.location)
+	today := now.Format("2006-01-02")
+	for _, subscription := range subscriptions {
+		if ctx.Err() != nil {
+			return
+		}
+		if subscription.LastDailyDate == today {
+			continue
+		}
+		if subscription.InQuietHours(now.Hour()) {
+			if err := s.subscriptionService.MarkDailyPending(subscription.UserID); err != nil {
+				log.Printf("Error marking daily character pending for %s: %v", subscription.UserID, err)
+			}
+			continue
+		}
+		if err := s.deliverDailyCharacter(ctx, subscription, now); err != nil {
+			log.Printf("Error sending daily character to %s: %v", subscription.UserID, err)
+		}
+	}
+}
+
+// SendPendingDailyCharacters 補送勿擾時段結束後的每日一字
+func (s *NotificationService) SendPendingDailyCharacters(ctx context.Context) {
+	subscriptions, err := s.subscriptionService.ListSubscribers("pendingDaily")
+	if err != nil {
+		log.Printf("Error listing pending daily characters: %v", err)
+		return
+	}
+
+	now := time.Now().In(s.location)
+	today := now.Format("2006-01-02")
+	for _, subscription := range subscriptions {
+		if ctx.Err() != nil {
+			return
+		}
+		if !subscription.DailyCharacter || subscription.LastDailyDate == today || subscription.InQuietHours(now.Hour()) {
+			continue
+		}
+		if err := s.deliverDailyCharacter(ctx, subscription, now); err != nil {
+			log.Printf("Error sending pending daily character to %s: %v", subscription.UserID, err)
+		}
+	}
+}
+
+// SendReviewReminders 提醒訂閱用戶複習到期的字
+func (s *NotificationService) SendReviewReminders(ctx context.Context) {
+	subscriptions, err := s.subscriptionService.ListSubscribers("reviewReminder")
+	if err != nil {
+		log.Printf("Error listing review reminder subscribers: %v", err)
+		return
+	}
+
+	now := time.Now().In(s.location)
+	for _, subscription := range subscriptions {
+		if ctx.Err() != nil {
+			return
+		}
+		if subscription.InQuietHours(now.Hour()) {
+			continue
+		}
+
+		items, err := s.reviewService.GetDueItems(subscription.UserID, now)
+		if err != nil {
+			log.Printf("Error getting due reviews for %s: %v", subscription.UserID, err)
+			continue
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		var chars []string
+		for i, item := range items {
+			if i >= maxReviewReminderChars {
+				break
+			}
+			chars = append(chars, item.Character)
+		}
+		text := fmt.Sprintf("🔔 複習提醒\n\n今天有 %d 個字該複習了：\n%s", len(items), strings.Join(chars, "、"))
+		if len(items) > maxReviewReminderChars {
+			text += " …"
+		}
+		text += "\n\n💡 輸入「練習」開始複習"
+
+		if err := s.messagingService.Push(ctx, subscription.UserID, linebot.NewTextMessage(text)); err != nil {
+			log.Printf("Error sending review reminder to %s: %v", subscription.UserID, err)
+		}
+	}
+}
+
+// SendWeeklyReports 推播學習週報給訂閱的家長
+func (s *NotificationService) SendWeeklyReports(ctx context.Context) {
+	subscriptions, err := s.subscriptionService.ListSubscribers("weeklyReport")
+	if err != nil {
+		log.Printf("Error listing weekly report subscribers: %v", err)
+		return
+	}
+
+	now := time.Now().In(s.location)
+	for _, subscription := range subscriptions {
+		if ctx.Err() != nil {
+			return
+		}
+		if subscription.InQuietHours(now.Hour()) {
+			continue
+		}
+
+		state, err := s.getUserState(subscription.UserID)
+		if err != nil {
+			log.Printf("Error getting user state for weekly report %s: %v", subscription.UserID, err)
+			continue
+		}
+		report, err := s.reportService.BuildWeeklyReport(subscription.UserID, state, now)
+		if err != nil {
+			log.Printf("Error building weekly report for %s: %v", subscription.UserID, err)
+			continue
+		}
+
+		err = s.messagingService.Push(ctx, subscription.UserID, utils.CreateWeeklyReportFlex(report))
+		if err != nil && utils.ClassifyError(err) == utils.ErrorKindPermanent {
+			// Flex 訊息被拒絕時改送純文字版本
+			err = s.messagingService.Push(ctx, subscription.UserID, linebot.NewTextMessage(utils.CreateWeeklyReportText(report)))
+		}
+		if err != nil {
+			log.Printf("Error sending weekly report to %s: %v", subscription.UserID, err)
+		}
+	}
+}
+
+// 推播每日一字：從用戶下一課選出尚未推播過的字
+func (s *NotificationService) deliverDailyCharacter(ctx context.Context, subscription *models.Subscription, now time.Time) error {
+	state, err := s.getUserState(subscription.UserID)
+	if err != nil {
+		return err
+	}
+	if state.PreferredPublisher == "" || state.PreferredGrade == 0 || state.PreferredSemester == 0 {
+		return fmt.Errorf("user has no course preferences")
+	}
+
+	lesson, err := s.lessonService.GetLesson(state.PreferredPublisher, state.PreferredGrade, state.PreferredSemester, state.PreferredLesson+1)
+	if err != nil {
+		// 已經是本學期最後一課時，改用目前的課次
+		lesson, err = s.lessonService.GetLesson(state.PreferredPublisher, state.PreferredGrade, state.PreferredSemester, state.PreferredLesson)
+		if err != nil {
+			return fmt.Errorf("no lesson available for daily character: %w", err)
+		}
+	}
+	if len(lesson.Characters) == 0 {
+		return fmt.Errorf("lesson %s has no characters", lesson.ID)
+	}
+
+	character := pickDailyCharacter(lesson.Characters, subscription.PushedChars, now)
+	info, err := s.characterService.LookupCharacter(character)
+	if err != nil {
+		// 字典中沒有資料時仍推播字本身
+		info = &models.CharacterInfo{Character: character}
+	}
+
+	if err := s.messagingService.Push(ctx, subscription.UserID, utils.CreateDailyCharacterFlex(info, lesson.Title)); err != nil {
+		return err
+	}
+
+	if err := s.subscriptionService.MarkDailyDelivered(subscription.UserID, now.Format("2006-01-02"), character); err != nil {
+		log.Printf("Error marking daily character delivered for %s: %v", subscription.UserID, err)
+	}
+	if err := s.reviewService.AddCharacter(subscription.UserID, character); err != nil {
+		log.Printf("Error adding review item for %s: %v", subscription.UserID, err)
+	}
+	return nil
+}
+
+func (s *NotificationService) getUserState(userID string) (*models.UserState, error) {
+	doc, err := s.firebaseClient.Firestore.Collection("user_states").Doc(userID).Get(s.firebaseClient.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user state: %w", err)
+	}
+	var state models.UserState
+	if err := doc.DataTo(&state); err != nil {
+		return nil, fmt.Errorf("failed to parse user state: %v", err)
+	}
+	return &state, nil
+}
+
+// 依課文順序選出第一個尚未推播的字；全部推播過時依日期輪替
+func pickDailyCharacter(characters []string, pushed []string, now time.Time) string {
+	pushedSet := make(map[string]bool, len(pushed))
+	for _, char := range pushed {
+		pushedSet[char] = true
+	}
+	for _, char := range characters {
+		if !pushedSet[char] {
+			return char
+		}
+	}
+	return characters[now.YearDay()%len(characters)]
+}
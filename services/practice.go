@@ -2,9 +2,15 @@ package services
 
 import (
 	"fmt"
+	"log"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"chinese-learning-linebot/config"
 	"chinese-learning-linebot/models"
 )
@@ -12,6 +18,7 @@ import (
 type PracticeService struct {
 	firebaseClient   *config.FirebaseClient
 	characterService *CharacterService
+	strokeService    *StrokeService
 	questionCache    map[string]*models.PracticeQuestion
 }
 
@@ -19,6 +26,7 @@ func NewPracticeService(firebaseClient *config.FirebaseClient) *PracticeService
 	return &PracticeService{
 		firebaseClient:   firebaseClient,
 		characterService: NewCharacterService(firebaseClient),
+		strokeService:    NewStrokeService(),
 		questionCache:    make(map[string]*models.PracticeQuestion),
 	}
 }
@@ -30,16 +38,40 @@ func (s *PracticeService) GeneratePhoneticQuestion() (*models.PracticeQuestion,
 		return nil, fmt.Errorf("failed to get random character")
 	}
 
-	char := characters[0]
+	return s.buildPhoneticQuestion(characters[0]), nil
+}
+
+func (s *PracticeService) buildPhoneticQuestion(char *models.CharacterInfo) *models.PracticeQuestion {
 	questionID := fmt.Sprintf("phonetic_%d", time.Now().UnixNano())
 
+	answer := char.Phonetic
+	questionText := fmt.Sprintf("請選擇「%s」的正確注音：", char.Character)
+	explanation := fmt.Sprintf("「%s」的注音是「%s」", char.Character, char.Phonetic)
+	options := []string{}
+
+	// 破音字：在例詞中問讀音，其他讀音作為選項
+	if reading, word := pickReadingInWord(char); reading != nil {
+		answer = reading.Phonetic
+		questionText = fmt.Sprintf("「%s」的「%s」怎麼唸？", word, char.Character)
+		explanation = fmt.Sprintf("「%s」的「%s」唸「%s」", word, char.Character, reading.Phonetic)
+		if reading.Meaning != "" {
+			explanation += fmt.Sprintf("，意思是%s", reading.Meaning)
+		}
+		for _, other := range char.Readings {
+			if other.Phonetic != answer && !containsString(options, other.Phonetic) {
+				options = append(options, other.Phonetic)
+			}
+		}
+		explanation += fmt.Sprintf("（另有讀音：%s）", strings.Join(options, "、"))
+	}
+	options = append([]string{answer}, options...)
+
 	// 生成錯誤選項（簡化版本）
 	wrongOptions := []string{"ㄅㄚ", "ㄆㄧ", "ㄇㄛ", "ㄈㄟ"}
-	options := []string{char.Phonetic}
 
 	// 添加錯誤選項，確保不重複
 	for _, wrong := range wrongOptions {
-		if wrong != char.Phonetic && len(options) < 4 {
+		if !containsString(options, wrong) && len(options) < 4 {
 			options = append(options, wrong)
 		}
 	}
@@ -53,7 +85,7 @@ func (s *PracticeService) GeneratePhoneticQuestion() (*models.PracticeQuestion,
 	// 找到正確答案的位置
 	correctIndex := 0
 	for i, option := range options {
-		if option == char.Phonetic {
+		if option == answer {
 			correctIndex = i
 			break
 		}
@@ -63,16 +95,49 @@ func (s *PracticeService) GeneratePhoneticQuestion() (*models.PracticeQuestion,
 		ID:            questionID,
 		Type:          "phonetic",
 		Character:     char.Character,
-		Question:      fmt.Sprintf("請選擇「%s」的正確注音：", char.Character),
+		Question:      questionText,
 		Options:       options,
 		CorrectAnswer: fmt.Sprintf("%d", correctIndex),
-		Explanation:   fmt.Sprintf("「%s」的注音是「%s」", char.Character, char.Phonetic),
+		Explanation:   explanation,
 	}
 
 	// 緩存問題
 	s.questionCache[questionID] = question
 
-	return question, nil
+	return question
+}
+
+// 破音字隨機挑一個有例詞的讀音與例詞，不是破音字時回傳 nil
+func pickReadingInWord(char *models.CharacterInfo) (*models.CharacterReading, string) {
+	if !char.IsPolyphonic() {
+		return nil, ""
+	}
+	type candidate struct {
+		reading *models.CharacterReading
+		word    string
+	}
+	var candidates []candidate
+	for i := range char.Readings {
+		for _, word := range char.Readings[i].Words {
+			if strings.Contains(word, char.Character) && len([]rune(word)) > 1 {
+				candidates = append(candidates, candidate{reading: &char.Readings[i], word: word})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ""
+	}
+	picked := candidates[rand.Intn(len(candidates))]
+	return picked.reading, picked.word
+}
+
+func containsString(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *PracticeService) GenerateStrokeQuestion() (*models.PracticeQuestion, error) {
@@ -82,11 +147,14 @@ func (s *PracticeService) GenerateStrokeQuestion() (*models.PracticeQuestion, er
 		return nil, fmt.Errorf("failed to get random character")
 	}
 
-	char := characters[0]
+	return s.buildStrokeQuestion(characters[0]), nil
+}
+
+func (s *PracticeService) buildStrokeQuestion(char *models.CharacterInfo) *models.PracticeQuestion {
 	questionID := fmt.Sprintf("stroke_%d", time.Now().UnixNano())
 
 	// 生成錯誤選項（正確答案±1-3）
-	correctStrokes := char.StrokeCount
+	correctStrokes := StrokeCountOf(char)
 	options := []string{fmt.Sprintf("%d", correctStrokes)}
 
 	// 添加錯誤選項
@@ -132,7 +200,373 @@ func (s *PracticeService) GenerateStrokeQuestion() (*models.PracticeQuestion, er
 	// 緩存問題
 	s.questionCache[questionID] = question
 
-	return question, nil
+	return question
+}
+
+// 取得出筆順題用的筆順資料，沒有資料或無法提供圖片時回傳 nil
+func (s *PracticeService) strokeDataForQuestion(char *models.CharacterInfo, minStrokes int) *models.StrokeData {
+	data := char.StrokeOrder
+	if data == nil {
+		data = s.strokeService.GetStrokeData(char.Character)
+	}
+	if data == nil || data.StrokeCount() < minStrokes || s.strokeService.ImageURL(char.Character, StrokeStepsFile) == "" {
+		return nil
+	}
+	return data
+}
+
+// 筆順題：標示其中一筆，問這是第幾筆；沒有筆順資料時改出筆畫數題
+func (s *PracticeService) buildStrokeOrderQuestion(char *models.CharacterInfo) *models.PracticeQuestion {
+	data := s.strokeDataForQuestion(char, 2)
+	if data == nil {
+		return s.buildStrokeQuestion(char)
+	}
+	questionID := fmt.Sprintf("stroke_order_%d", time.Now().UnixNano())
+
+	// 錯誤選項取最接近正確筆序的筆數，較有鑑別度
+	total := data.StrokeCount()
+	target := rand.Intn(total) + 1
+	var others []int
+	for i := 1; i <= total; i++ {
+		if i != target {
+			others = append(others, i)
+		}
+	}
+	rand.Shuffle(len(others), func(i, j int) {
+		others[i], others[j] = others[j], others[i]
+	})
+	sort.SliceStable(others, func(i, j int) bool {
+		return absInt(others[i]-target) < absInt(others[j]-target)
+	})
+	if len(others) > 3 {
+		others = others[:3]
+	}
+	strokes := append([]int{target}, others...)
+	sort.Ints(strokes)
+
+	var options []string
+	correctIndex := 0
+	for i, stroke := range strokes {
+		options = append(options, fmt.Sprintf("第%d筆", stroke))
+		if stroke == target {
+			correctIndex = i
+		}
+	}
+
+	question := &models.PracticeQuestion{
+		ID:            questionID,
+		Type:          string(models.PracticeTypeStrokeOrder),
+		Character:     char.Character,
+		Question:      fmt.Sprintf("圖中紅色的筆畫是「%s」的第幾筆？", char.Character),
+		Options:       options,
+		ImageURL:      s.strokeService.HighlightImageURL(char.Character, target),
+		CorrectAnswer: fmt.Sprintf("%d", correctIndex),
+		Explanation:   fmt.Sprintf("「%s」共 %d 畫，紅色的是第 %d 筆\n輸入「筆順 %s」可以看完整筆順", char.Character, total, target, char.Character),
+	}
+
+	// 緩存問題
+	s.questionCache[questionID] = question
+
+	return question
+}
+
+// 下一筆題：已寫好前幾筆，從數張圖中選出正確的下一筆；沒有筆順資料時改出筆畫數題
+func (s *PracticeService) buildNextStrokeQuestion(char *models.CharacterInfo) *models.PracticeQuestion {
+	data := s.strokeDataForQuestion(char, 3)
+	if data == nil {
+		return s.buildStrokeQuestion(char)
+	}
+	questionID := fmt.Sprintf("next_stroke_%d", time.Now().UnixNano())
+
+	// 已寫筆數至少要留下兩筆未寫，才有錯誤選項
+	total := data.StrokeCount()
+	written := rand.Intn(total - 1)
+	next := written + 1
+	var later []int
+	for i := next + 1; i <= total; i++ {
+		later = append(later, i)
+	}
+	rand.Shuffle(len(later), func(i, j int) {
+		later[i], later[j] = later[j], later[i]
+	})
+	if len(later) > MaxNextStrokeChoices-1 {
+		later = later[:MaxNextStrokeChoices-1]
+	}
+	candidates := append([]int{next}, later...)
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	var options []string
+	correctIndex := 0
+	for i, candidate := range candidates {
+		options = append(options, fmt.Sprintf("%d", i+1))
+		if candidate == next {
+			correctIndex = i
+		}
+	}
+
+	prompt := fmt.Sprintf("「%s」已經寫好黑色的前 %d 筆，下一筆是哪一張圖的紅色筆畫？", char.Character, written)
+	if written == 0 {
+		prompt = fmt.Sprintf("「%s」的第一筆是哪一張圖的紅色筆畫？", char.Character)
+	}
+
+	question := &models.PracticeQuestion{
+		ID:            questionID,
+		Type:          string(models.PracticeTypeNextStroke),
+		Character:     char.Character,
+		Question:      prompt,
+		Options:       options,
+		ImageURL:      s.strokeService.NextStrokeImageURL(char.Character, written, candidates),
+		CorrectAnswer: fmt.Sprintf("%d", correctIndex),
+		Explanation:   fmt.Sprintf("答案是第 %d 張圖，「%s」的第 %d 筆\n輸入「筆順 %s」可以看完整筆順", correctIndex+1, char.Character, next, char.Character),
+	}
+
+	// 緩存問題
+	s.questionCache[questionID] = question
+
+	return question
+}
+
+// 出題時參考的已學內容
+type learnedVocabulary struct {
+	characters []*models.CharacterInfo // 錯誤選項的來源：已學過的字，不足時以常見字補足
+	words      []string                // 已學過的語詞（填空題的題目來源）
+}
+
+// 載入已學過的字詞資料，已學的字太少或部首種類不足時以筆畫較少的常見字補足
+func (s *PracticeService) loadLearnedVocabulary(config models.PracticeConfig) *learnedVocabulary {
+	learned := &learnedVocabulary{words: config.LearnedWords}
+	radicals := make(map[string]bool)
+	if len(config.Learned) > 0 {
+		result, err := s.characterService.SearchCharacters(models.CharacterSearchCriteria{Within: config.Learned, PageSize: len(config.Learned)})
+		if err != nil {
+			log.Printf("Error loading learned characters for practice: %v", err)
+		} else {
+			learned.characters = result.Characters
+			for _, character := range result.Characters {
+				if character.Radical != "" {
+					radicals[character.Radical] = true
+				}
+			}
+		}
+	}
+	if len(learned.characters) >= minLearnedPoolSize && len(radicals) >= 4 {
+		return learned
+	}
+
+	result, err := s.characterService.SearchCharacters(models.CharacterSearchCriteria{PageSize: fallbackPoolSize})
+	if err != nil {
+		log.Printf("Error loading characters for practice: %v", err)
+		return learned
+	}
+	learned.characters = append(learned.characters, result.Characters...)
+	return learned
+}
+
+// 部首題：問字的部首，錯誤選項取自其他字的部首；沒有部首資料時改出注音題
+func (s *PracticeService) buildRadicalQuestion(char *models.CharacterInfo, pool []*models.CharacterInfo) *models.PracticeQuestion {
+	if char.Radical == "" {
+		return s.buildPhoneticQuestion(char)
+	}
+
+	options := []string{char.Radical}
+	for _, i := range rand.Perm(len(pool)) {
+		if len(options) >= 4 {
+			break
+		}
+		if pool[i].Radical != "" && !containsString(options, pool[i].Radical) {
+			options = append(options, pool[i].Radical)
+		}
+	}
+	if len(options) < 2 {
+		return s.buildPhoneticQuestion(char)
+	}
+	questionID := fmt.Sprintf("radical_%d", time.Now().UnixNano())
+
+	rand.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	correctIndex := 0
+	for i, option := range options {
+		if option == char.Radical {
+			correctIndex = i
+			break
+		}
+	}
+
+	question := &models.PracticeQuestion{
+		ID:            questionID,
+		Type:          string(models.PracticeTypeRadical),
+		Character:     char.Character,
+		Question:      fmt.Sprintf("「%s」的部首是什麼？", char.Character),
+		Options:       options,
+		CorrectAnswer: fmt.Sprintf("%d", correctIndex),
+		Explanation:   fmt.Sprintf("「%s」的部首是「%s」", char.Character, char.Radical),
+	}
+
+	// 緩存問題
+	s.questionCache[questionID] = question
+
+	return question
+}
+
+// 部首找字題：給部首，從幾個字中選出屬於這個部首的字；錯誤選項為其他部首的字
+func (s *PracticeService) buildRadicalCharacterQuestion(char *models.CharacterInfo, pool []*models.CharacterInfo) *models.PracticeQuestion {
+	if char.Radical == "" {
+		return s.buildPhoneticQuestion(char)
+	}
+
+	options := []string{char.Character}
+	for _, i := range rand.Perm(len(pool)) {
+		if len(options) >= 4 {
+			break
+		}
+		candidate := pool[i]
+		if candidate.Radical != "" && candidate.Radical != char.Radical && !containsString(options, candidate.Character) {
+			options = append(options, candidate.Character)
+		}
+	}
+	if len(options) < 2 {
+		return s.buildPhoneticQuestion(char)
+	}
+	questionID := fmt.Sprintf("radical_character_%d", time.Now().UnixNano())
+
+	rand.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	correctIndex := 0
+	for i, option := range options {
+		if option == char.Character {
+			correctIndex = i
+			break
+		}
+	}
+
+	question := &models.PracticeQuestion{
+		ID:            questionID,
+		Type:          string(models.PracticeTypeRadicalCharacter),
+		Character:     char.Character,
+		Question:      fmt.Sprintf("哪個字的部首是「%s」？", char.Radical),
+		Options:       options,
+		CorrectAnswer: fmt.Sprintf("%d", correctIndex),
+		Explanation:   fmt.Sprintf("「%s」的部首是「%s」", char.Character, char.Radical),
+	}
+
+	// 緩存問題
+	s.questionCache[questionID] = question
+
+	return question
+}
+
+// 填空題：從例句或語詞中挖空這個字，錯誤選項為字形或讀音相近的已學字；沒有例句與語詞時改出注音題
+func (s *PracticeService) buildClozeQuestion(char *models.CharacterInfo, learned *learnedVocabulary) *models.PracticeQuestion {
+	contexts := clozeContexts(char, learned.words)
+	if len(contexts) == 0 {
+		return s.buildPhoneticQuestion(char)
+	}
+	context := contexts[rand.Intn(len(contexts))]
+
+	// 依相似度排序，同分時隨機；換成錯誤選項後仍是學過的語詞的字不採用
+	candidates := make([]*models.CharacterInfo, 0, len(learned.characters))
+	similarity := make(map[string]int)
+	for _, i := range rand.Perm(len(learned.characters)) {
+		candidate := learned.characters[i]
+		if candidate.Character == char.Character || containsString(learned.words, strings.ReplaceAll(context, char.Character, candidate.Character)) {
+			continue
+		}
+		candidates = append(candidates, candidate)
+		similarity[candidate.Character] = clozeSimilarity(char, candidate)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return similarity[candidates[i].Character] > similarity[candidates[j].Character]
+	})
+
+	options := []string{char.Character}
+	for _, candidate := range candidates {
+		if len(options) >= 4 {
+			break
+		}
+		if !containsString(options, candidate.Character) {
+			options = append(options, candidate.Character)
+		}
+	}
+	if len(options) < 2 {
+		return s.buildPhoneticQuestion(char)
+	}
+	questionID := fmt.Sprintf("cloze_%d", time.Now().UnixNano())
+
+	rand.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	correctIndex := 0
+	for i, option := range options {
+		if option == char.Character {
+			correctIndex = i
+			break
+		}
+	}
+
+	question := &models.PracticeQuestion{
+		ID:            questionID,
+		Type:          string(models.PracticeTypeCloze),
+		Character:     char.Character,
+		Question:      fmt.Sprintf("請選出（　）裡的字：\n\n%s", strings.ReplaceAll(context, char.Character, "（　）")),
+		Options:       options,
+		CorrectAnswer: fmt.Sprintf("%d", correctIndex),
+		Explanation:   fmt.Sprintf("答案是「%s」：%s", char.Character, context),
+	}
+
+	// 緩存問題
+	s.questionCache[questionID] = question
+
+	return question
+}
+
+// 填空題的題目來源：字的例句、例詞，以及含有這個字的已學語詞
+func clozeContexts(char *models.CharacterInfo, learnedWords []string) []string {
+	var sources []string
+	sources = append(sources, char.Examples...)
+	for _, reading := range char.Readings {
+		sources = append(sources, reading.Words...)
+	}
+	sources = append(sources, learnedWords...)
+
+	var contexts []string
+	for _, text := range sources {
+		text = strings.TrimSpace(text)
+		if text == char.Character || !strings.Contains(text, char.Character) || containsString(contexts, text) {
+			continue
+		}
+		contexts = append(contexts, text)
+	}
+	return contexts
+}
+
+// 錯誤選項與答案的相似度：讀音相同（不論聲調）最容易混淆，其次是同部首、筆畫數相近
+func clozeSimilarity(char *models.CharacterInfo, candidate *models.CharacterInfo) int {
+	score := 0
+	for _, reading := range characterReadings(char) {
+		for _, other := range characterReadings(candidate) {
+			if StripZhuyinTone(reading) == StripZhuyinTone(other) {
+				score = 3
+			}
+		}
+	}
+	if char.Radical != "" && candidate.Radical == char.Radical {
+		score += 2
+	}
+	if char.StrokeCount > 0 && candidate.StrokeCount > 0 && absInt(char.StrokeCount-candidate.StrokeCount) <= 2 {
+		score++
+	}
+	return score
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
 }
 
 func (s *PracticeService) GenerateSentenceQuestion() (*models.PracticeQuestion, error) {
@@ -142,7 +576,10 @@ func (s *PracticeService) GenerateSentenceQuestion() (*models.PracticeQuestion,
 		return nil, fmt.Errorf("failed to get random character")
 	}
 
-	char := characters[0]
+	return s.buildSentenceQuestion(characters[0]), nil
+}
+
+func (s *PracticeService) buildSentenceQuestion(char *models.CharacterInfo) *models.PracticeQuestion {
 	questionID := fmt.Sprintf("sentence_%d", time.Now().UnixNano())
 
 	question := &models.PracticeQuestion{
@@ -157,7 +594,7 @@ func (s *PracticeService) GenerateSentenceQuestion() (*models.PracticeQuestion,
 	// 緩存問題
 	s.questionCache[questionID] = question
 
-	return question, nil
+	return question
 }
 
 func (s *PracticeService) CheckAnswer(questionID, answer string) (bool, string, error) {
@@ -165,29 +602,31 @@ func (s *PracticeService) CheckAnswer(questionID, answer string) (bool, string,
 	if !exists {
 		return false, "問題已過期，請重新開始練習", nil
 	}
+	isCorrect, explanation := checkAnswer(question, answer, nil)
+	return isCorrect, explanation, nil
+}
 
+// 批改答案，選擇題的 answer 為選項索引；有練習會話時，造句依已學過的字計分並檢查是否與先前的句子重複
+func checkAnswer(question *models.PracticeQuestion, answer string, session *models.PracticeSession) (bool, string) {
 	switch question.Type {
-	case "phonetic", "stroke":
-		isCorrect := answer == question.CorrectAnswer
-		return isCorrect, question.Explanation, nil
+	case "phonetic", "stroke", "stroke_order", "next_stroke", "radical", "radical_character", "cloze":
+		return answer == question.CorrectAnswer, question.Explanation
 
 	case "sentence":
-		// 造句題目前簡單判斷是否包含該字符
-		containsChar := false
-		for _, char := range answer {
-			if string(char) == question.Character {
-				containsChar = true
-				break
+		input := SentenceInput{Character: question.Character, Prompt: question.Question, Sentence: answer}
+		if session != nil {
+			input.Learned = session.Learned
+			for _, submitted := range session.Answers {
+				if submitted.QuestionType == question.Type {
+					input.Previous = append(input.Previous, submitted.UserAnswer)
+				}
 			}
 		}
-		if containsChar {
-			return true, fmt.Sprintf("很棒的句子！你成功使用了「%s」這個字。", question.Character), nil
-		} else {
-			return false, fmt.Sprintf("請確保句子中包含「%s」這個字。", question.Character), nil
-		}
+		evaluation := EvaluateSentence(input)
+		return evaluation.Accepted, evaluation.Feedback
 
 	default:
-		return false, "未知的問題類型", nil
+		return false, "未知的問題類型"
 	}
 }
 
@@ -207,4 +646,352 @@ func extractTimestampFromID(id string) int64 {
 	// 從ID中提取時間戳（簡化實現）
 	// 實際實現可能需要更複雜的解析
 	return time.Now().UnixNano() // 暫時返回當前時間
-}
\ No newline at end of file
+}
+
+const (
+	practiceSessionCollection = "practice_sessions"
+	practiceStatsCollection   = "practice_stats"
+
+	defaultPracticeQuestionCount = 5
+
+	// 已學過的字太少時，以筆畫較少的常見字補足錯誤選項
+	minLearnedPoolSize = 8
+	fallbackPoolSize   = 200
+)
+
+// StartSession 建立新的練習會話並儲存
+// focusCharacters 為優先出題的字（例如到期的複習項目），不足時以隨機字補足
+func (s *PracticeService) StartSession(userID string, config models.PracticeConfig, focusCharacters []string) (*models.PracticeSession, error) {
+	count := config.QuestionCount
+	if count <= 0 {
+		count = defaultPracticeQuestionCount
+	}
+	practiceType := config.Type
+	if practiceType == "" {
+		practiceType = models.PracticeTypeMixed
+	}
+
+	characters := s.loadCharacters(focusCharacters, count)
+	if len(characters) < count {
+		random, err := s.characterService.GetRandomCharacters(count)
+		if err != nil {
+			return nil, err
+		}
+		// 打亂順序，避免每次都從同一個字開始
+		rand.Shuffle(len(random), func(i, j int) {
+			random[i], random[j] = random[j], random[i]
+		})
+		if missing := count - len(characters); len(random) > missing {
+			random = random[:missing]
+		}
+		characters = append(characters, random...)
+	}
+	if len(characters) == 0 {
+		return nil, fmt.Errorf("no characters available for practice")
+	}
+
+	session := &models.PracticeSession{
+		UserID:    userID,
+		Type:      string(practiceType),
+		StartTime: config.StartTime,
+		Learned:   config.Learned,
+		TimeLimit: config.TimeLimit,
+		GroupID:   config.GroupID,
+	}
+	if session.StartTime == 0 {
+		session.StartTime = time.Now().UnixMilli()
+	}
+
+	// 從用戶各題型的等級決定起始難度，作答後再逐題調整（見 adaptNextQuestion）
+	stats, err := s.GetStats(session.UserKey())
+	if err != nil {
+		log.Printf("Error getting practice stats for difficulty: %v", err)
+	}
+	if stats != nil {
+		session.TypeLevels = stats.Levels
+	}
+	difficulty := startingDifficulty(config, session.TypeLevels)
+
+	// 從目前難度的隨機題型開始輪替，讓題數較少的練習也能出現各種題型
+	offset := rand.Intn(len(sessionPracticeTypes(session, difficulty)))
+	learned := s.loadLearnedVocabulary(config)
+	for i := 0; i < count; i++ {
+		question, err := s.buildLeveledQuestion(session, i, offset+i, difficulty, characters[i%len(characters)], learned)
+		if err != nil {
+			return nil, err
+		}
+		session.Questions = append(session.Questions, *question)
+		session.Levels = append(session.Levels, int(difficulty))
+	}
+	session.TotalScore = len(session.Questions)
+
+	ref := s.firebaseClient.Firestore.Collection(practiceSessionCollection).NewDoc()
+	session.ID = ref.ID
+	if _, err := ref.Set(s.firebaseClient.Ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save practice session: %w", err)
+	}
+
+	return session, nil
+}
+
+// 查詢字的資料，查不到的字略過
+func (s *PracticeService) loadCharacters(chars []string, limit int) []*models.CharacterInfo {
+	var characters []*models.CharacterInfo
+	for _, char := range chars {
+		if len(characters) >= limit {
+			break
+		}
+		info, err := s.characterService.LookupCharacter(char)
+		if err != nil {
+			continue
+		}
+		characters = append(characters, info)
+	}
+	return characters
+}
+
+// 依練習類型產生題目
+// 混合練習依難度輪替題型，learned 為部首題、填空題的錯誤選項及題目來源
+func (s *PracticeService) generateQuestion(practiceType models.PracticeType, index int, difficulty models.QuestionDifficulty, char *models.CharacterInfo, learned *learnedVocabulary) (*models.PracticeQuestion, error) {
+	if practiceType == models.PracticeTypeMixed {
+		practiceType = practiceTypeForDifficulty(difficulty, index)
+	}
+
+	switch practiceType {
+	case models.PracticeTypePhonetic:
+		return s.buildPhoneticQuestion(char), nil
+	case models.PracticeTypeStroke:
+		return s.buildStrokeQuestion(char), nil
+	case models.PracticeTypeSentence:
+		return s.buildSentenceQuestion(char), nil
+	case models.PracticeTypeStrokeOrder:
+		return s.buildStrokeOrderQuestion(char), nil
+	case models.PracticeTypeNextStroke:
+		return s.buildNextStrokeQuestion(char), nil
+	case models.PracticeTypeRadical:
+		return s.buildRadicalQuestion(char, learned.characters), nil
+	case models.PracticeTypeRadicalCharacter:
+		return s.buildRadicalCharacterQuestion(char, learned.characters), nil
+	case models.PracticeTypeCloze:
+		return s.buildClozeQuestion(char, learned), nil
+	default:
+		return nil, fmt.Errorf("unknown practice type: %s", practiceType)
+	}
+}
+
+// GetSession 取得練習會話
+func (s *PracticeService) GetSession(sessionID string) (*models.PracticeSession, error) {
+	doc, err := s.firebaseClient.Firestore.Collection(practiceSessionCollection).Doc(sessionID).Get(s.firebaseClient.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get practice session: %w", err)
+	}
+
+	var session models.PracticeSession
+	if err := doc.DataTo(&session); err != nil {
+		return nil, fmt.Errorf("failed to parse practice session: %v", err)
+	}
+	session.ID = doc.Ref.ID
+	return &session, nil
+}
+
+// SubmitAnswer 作答目前的題目並儲存會話，answeredAt 為作答時間（例如訊息事件的時間戳）
+// 選擇題的答案可為選項文字（快速回覆送出的內容）
+func (s *PracticeService) SubmitAnswer(session *models.PracticeSession, answer string, answeredAt time.Time) (bool, string, error) {
+	question := session.CurrentQuestion()
+	if question == nil {
+		return false, "", fmt.Errorf("practice session %s has no pending question", session.ID)
+	}
+
+	normalizedAnswer := answer
+	if len(question.Options) > 0 {
+		normalizedAnswer = ""
+		for i, option := range question.Options {
+			if option == answer {
+				normalizedAnswer = fmt.Sprintf("%d", i)
+				break
+			}
+		}
+	}
+
+	isCorrect, explanation := checkAnswer(question, normalizedAnswer, session)
+
+	now := answeredAt.UnixMilli()
+	previous := session.StartTime
+	if len(session.Answers) > 0 {
+		previous = session.Answers[len(session.Answers)-1].AnsweredAt
+	}
+
+	correctAnswer := question.CorrectAnswer
+	if len(question.Options) > 0 {
+		var index int
+		if _, err := fmt.Sscanf(question.CorrectAnswer, "%d", &index); err == nil && index < len(question.Options) {
+			correctAnswer = question.Options[index]
+		}
+	}
+
+	session.Answers = append(session.Answers, models.PracticeAnswer{
+		QuestionID:    question.ID,
+		QuestionType:  question.Type,
+		Character:     question.Character,
+		UserAnswer:    answer,
+		CorrectAnswer: correctAnswer,
+		IsCorrect:     isCorrect,
+		TimeSpent:     now - previous,
+		AnsweredAt:    now,
+	})
+	if isCorrect {
+		session.Score++
+	}
+	if session.TimeLimit > 0 {
+		session.Points += challengePoints(isCorrect, now-previous)
+	}
+	explanation += s.adaptNextQuestion(session)
+
+	if _, err := s.firebaseClient.Firestore.Collection(practiceSessionCollection).Doc(session.ID).Set(s.firebaseClient.Ctx, session); err != nil {
+		return isCorrect, explanation, fmt.Errorf("failed to save practice session: %w", err)
+	}
+
+	return isCorrect, explanation, nil
+}
+
+// CompleteSession 結束練習會話，更新統計與複習排程（班級群組中的練習記在 session.UserKey() 下）
+func (s *PracticeService) CompleteSession(session *models.PracticeSession) (*models.PracticeStats, error) {
+	session.Completed = true
+	session.EndTime = time.Now().UnixMilli()
+	if _, err := s.firebaseClient.Firestore.Collection(practiceSessionCollection).Doc(session.ID).Set(s.firebaseClient.Ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save practice session: %w", err)
+	}
+
+	reviewService := NewReviewService(s.firebaseClient)
+	for _, answer := range session.Answers {
+		if answer.Character == "" {
+			continue
+		}
+		if err := reviewService.RecordResult(session.UserKey(), answer.Character, answer.IsCorrect); err != nil {
+			log.Printf("Error recording review result: %v", err)
+		}
+	}
+
+	return s.updateStats(session)
+}
+
+// GetStats 取得用戶的練習統計，userID 為 models.UserStateKey 的鍵
+func (s *PracticeService) GetStats(userID string) (*models.PracticeStats, error) {
+	doc, err := s.firebaseClient.Firestore.Collection(practiceStatsCollection).Doc(userID).Get(s.firebaseClient.Ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return &models.PracticeStats{UserID: userID}, nil
+		}
+		return nil, fmt.Errorf("failed to get practice stats: %w", err)
+	}
+
+	var stats models.PracticeStats
+	if err := doc.DataTo(&stats); err != nil {
+		return nil, fmt.Errorf("failed to parse practice stats: %v", err)
+	}
+	stats.UserID = userID
+	return &stats, nil
+}
+
+// GetSessionsSince 取得指定時間之後完成的練習會話
+func (s *PracticeService) GetSessionsSince(userID string, since time.Time) ([]*models.PracticeSession, error) {
+	docs, err := s.firebaseClient.Firestore.Collection(practiceSessionCollection).
+		Where("userId", "==", userID).
+		Where("startTime", ">=", since.UnixMilli()).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query practice sessions: %w", err)
+	}
+
+	var sessions []*models.PracticeSession
+	for _, doc := range docs {
+		var session models.PracticeSession
+		if err := doc.DataTo(&session); err != nil {
+			continue
+		}
+		if !session.Completed {
+			continue
+		}
+		session.ID = doc.Ref.ID
+		sessions = append(sessions, &session)
+	}
+	return sessions, nil
+}
+
+// GetGroupSessionsSince 取得在班級群組中、指定時間之後完成的練習會話
+func (s *PracticeService) GetGroupSessionsSince(groupID string, since time.Time) ([]*models.PracticeSession, error) {
+	docs, err := s.firebaseClient.Firestore.Collection(practiceSessionCollection).
+		Where("groupId", "==", groupID).
+		Where("startTime", ">=", since.UnixMilli()).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query practice sessions: %w", err)
+	}
+
+	var sessions []*models.PracticeSession
+	for _, doc := range docs {
+		var session models.PracticeSession
+		if err := doc.DataTo(&session); err != nil {
+			continue
+		}
+		if !session.Completed {
+			continue
+		}
+		session.ID = doc.Ref.ID
+		sessions = append(sessions, &session)
+	}
+	return sessions, nil
+}
+
+// 累加練習統計並計算連續練習天數（以台北時間的日期為準）
+func (s *PracticeService) updateStats(session *models.PracticeSession) (*models.PracticeStats, error) {
+	stats, err := s.GetStats(session.UserKey())
+	if err != nil {
+		return nil, err
+	}
+
+	correct := 0
+	var timeSpent int64
+	for _, answer := range session.Answers {
+		if answer.IsCorrect {
+			correct++
+		}
+		timeSpent += answer.TimeSpent
+	}
+
+	stats.TotalSessions++
+	stats.TotalQuestions += len(session.Answers)
+	stats.CorrectAnswers += correct
+	stats.TotalTimeSpent += timeSpent
+	if stats.TotalQuestions > 0 {
+		stats.AccuracyRate = float64(stats.CorrectAnswers) / float64(stats.TotalQuestions) * 100
+		stats.AverageTimePerQuestion = stats.TotalTimeSpent / int64(stats.TotalQuestions)
+	}
+	sessionScore := 0.0
+	if session.TotalScore > 0 {
+		sessionScore = float64(session.Score) / float64(session.TotalScore) * 100
+	}
+	stats.AverageScore += (sessionScore - stats.AverageScore) / float64(stats.TotalSessions)
+
+	now := time.Now().In(taipeiLocation())
+	today := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	switch stats.LastPracticeDate {
+	case today:
+	case yesterday:
+		stats.Streak++
+	default:
+		stats.Streak = 1
+	}
+	if stats.Streak > stats.BestStreak {
+		stats.BestStreak = stats.Streak
+	}
+	stats.LastPracticeDate = today
+	stats.LastPracticeTime = now.Unix()
+	updateTypeLevels(stats, session)
+
+	if _, err := s.firebaseClient.Firestore.Collection(practiceStatsCollection).Doc(session.UserKey()).Set(s.firebaseClient.Ctx, stats); err != nil {
+		return nil, fmt.Errorf("failed to save practice stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"chinese-learning-linebot/models"
+)
+
+// 各難度出的題型，混合練習依目前難度從對應的題型中輪替；專家級沿用困難題型，改出較少見的字
+var practiceTypesByDifficulty = map[models.QuestionDifficulty][]models.PracticeType{
+	models.DifficultyEasy:   {models.PracticeTypePhonetic, models.PracticeTypeStroke},
+	models.DifficultyMedium: {models.PracticeTypeRadical, models.PracticeTypeStrokeOrder, models.PracticeTypeCloze},
+	models.DifficultyHard:   {models.PracticeTypeRadicalCharacter, models.PracticeTypeNextStroke, models.PracticeTypeSentence},
+}
+
+// 連續答對幾題升一級
+const levelUpStreak = 2
+
+// 難度對應的題型，專家級沿用困難題型
+func practiceTypesForDifficulty(difficulty models.QuestionDifficulty) []models.PracticeType {
+	difficulty = clampDifficulty(difficulty)
+	if difficulty > models.DifficultyHard {
+		difficulty = models.DifficultyHard
+	}
+	return practiceTypesByDifficulty[difficulty]
+}
+
+// 依難度輪替題型
+func practiceTypeForDifficulty(difficulty models.QuestionDifficulty, index int) models.PracticeType {
+	types := practiceTypesForDifficulty(difficulty)
+	return types[index%len(types)]
+}
+
+// 會話在此難度輪替的題型：挑戰模式限時作答，只出可以點選答案的題型（不出造句）
+func sessionPracticeTypes(session *models.PracticeSession, difficulty models.QuestionDifficulty) []models.PracticeType {
+	types := practiceTypesForDifficulty(difficulty)
+	if session.TimeLimit == 0 {
+		return types
+	}
+	var quickReply []models.PracticeType
+	for _, practiceType := range types {
+		if practiceType != models.PracticeTypeSentence {
+			quickReply = append(quickReply, practiceType)
+		}
+	}
+	return quickReply
+}
+
+func clampDifficulty(difficulty models.QuestionDifficulty) models.QuestionDifficulty {
+	if difficulty < models.DifficultyEasy {
+		return models.DifficultyEasy
+	}
+	if difficulty > models.DifficultyExpert {
+		return models.DifficultyExpert
+	}
+	return difficulty
+}
+
+// 用戶某題型的等級，沒有紀錄時從簡單開始
+func typeLevel(levels map[string]int, practiceType models.PracticeType) models.QuestionDifficulty {
+	if levels[string(practiceType)] == 0 {
+		return models.DifficultyEasy
+	}
+	return clampDifficulty(models.QuestionDifficulty(levels[string(practiceType)]))
+}
+
+// 練習開始的難度：有指定時依指定；單一題型依該題型的等級；
+// 混合練習從簡單開始，較簡單一級的題型都已升到更高等級時才往上一級開始
+func startingDifficulty(config models.PracticeConfig, levels map[string]int) models.QuestionDifficulty {
+	if config.Difficulty > 0 {
+		return clampDifficulty(config.Difficulty)
+	}
+	if config.Type != "" && config.Type != models.PracticeTypeMixed {
+		return typeLevel(levels, config.Type)
+	}
+
+	difficulty := models.DifficultyEasy
+	for difficulty < models.DifficultyExpert {
+		for _, practiceType := range practiceTypesForDifficulty(difficulty) {
+			if typeLevel(levels, practiceType) <= difficulty {
+				return difficulty
+			}
+		}
+		difficulty++
+	}
+	return difficulty
+}
+
+// 一題的難度：取練習目前的難度與用戶該題型等級中較高者，已熟練的題型改出較少見的字
+func questionDifficulty(difficulty models.QuestionDifficulty, practiceType models.PracticeType, levels map[string]int) models.QuestionDifficulty {
+	if level := typeLevel(levels, practiceType); level > difficulty {
+		return level
+	}
+	return difficulty
+}
+
+// 依作答結果決定下一題的難度：答錯降一級，在同一難度連續答對 levelUpStreak 題升一級
+func nextDifficulty(session *models.PracticeSession) models.QuestionDifficulty {
+	answered := len(session.Answers)
+	current := models.QuestionDifficulty(session.Levels[answered-1])
+	if !session.Answers[answered-1].IsCorrect {
+		return clampDifficulty(current - 1)
+	}
+
+	streak := 0
+	for i := answered - 1; i >= 0 && session.Answers[i].IsCorrect && session.Levels[i] == int(current); i-- {
+		streak++
+	}
+	if streak >= levelUpStreak {
+		return clampDifficulty(current + 1)
+	}
+	return current
+}
+
+// 調整混合練習的下一題：難度改變時依新難度重新出題，回傳給用戶的難度變化提示
+// 挑戰模式計時中不重新出題，避免作答之間還要查詢資料
+func (s *PracticeService) adaptNextQuestion(session *models.PracticeSession) string {
+	next := len(session.Answers)
+	if session.Type != string(models.PracticeTypeMixed) || session.TimeLimit > 0 || next == 0 || next >= len(session.Questions) || len(session.Levels) != len(session.Questions) {
+		return ""
+	}
+
+	previous := models.QuestionDifficulty(session.Levels[next-1])
+	difficulty := nextDifficulty(session)
+	if difficulty == models.QuestionDifficulty(session.Levels[next]) {
+		return ""
+	}
+
+	char, err := s.characterService.LookupCharacter(session.Questions[next].Character)
+	if err != nil {
+		return ""
+	}
+	learned := s.loadLearnedVocabulary(models.PracticeConfig{Learned: session.Learned})
+	question, err := s.buildLeveledQuestion(session, next, next, difficulty, char, learned)
+	if err != nil {
+		return ""
+	}
+	session.Questions[next] = *question
+	session.Levels[next] = int(difficulty)
+
+	switch {
+	case difficulty > previous:
+		return "\n\n📈 連續答對，題目變難了！"
+	case difficulty < previous:
+		return "\n\n💪 沒關係，先練習簡單一點的題目"
+	default:
+		return ""
+	}
+}
+
+// 依練習目前的難度出混合練習的第 position 題（rotation 為題型輪替的序號），題目難度另依用戶該題型的等級調整
+func (s *PracticeService) buildLeveledQuestion(session *models.PracticeSession, position int, rotation int, difficulty models.QuestionDifficulty, char *models.CharacterInfo, learned *learnedVocabulary) (*models.PracticeQuestion, error) {
+	practiceType := models.PracticeType(session.Type)
+	if practiceType == models.PracticeTypeMixed {
+		types := sessionPracticeTypes(session, difficulty)
+		practiceType = types[rotation%len(types)]
+	}
+	level := questionDifficulty(difficulty, practiceType, session.TypeLevels)
+	if level == models.DifficultyExpert {
+		if rare := rareLearnedCharacter(session, learned); rare != nil {
+			char = rare
+		}
+	}
+
+	question, err := s.generateQuestion(practiceType, rotation, difficulty, char, learned)
+	if err != nil {
+		return nil, err
+	}
+	// 同一會話中快速產生的題目可能取得相同時間戳，加上序號確保唯一
+	question.ID = fmt.Sprintf("%s_%d", question.ID, position)
+	question.CreatedAt = time.Now().UnixMilli()
+	question.Difficulty = int(level)
+	return question, nil
+}
+
+// 專家級改出已學過的字中較少見的字（使用頻率最低的四分之一），避開本次練習已出過的字
+func rareLearnedCharacter(session *models.PracticeSession, learned *learnedVocabulary) *models.CharacterInfo {
+	inLearned := make(map[string]bool, len(session.Learned))
+	for _, char := range session.Learned {
+		inLearned[char] = true
+	}
+	used := make(map[string]bool, len(session.Questions))
+	for _, question := range session.Questions {
+		used[question.Character] = true
+	}
+
+	var candidates []*models.CharacterInfo
+	for _, character := range learned.characters {
+		if inLearned[character.Character] && !used[character.Character] && character.Frequency > 0 {
+			candidates = append(candidates, character)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Frequency < candidates[j].Frequency
+	})
+	return candidates[rand.Intn((len(candidates)+3)/4)]
+}
+
+// 依本次練習各題型的正確率調整用戶的題型等級：全對（至少兩題）升一級，答對不到一半降一級
+func updateTypeLevels(stats *models.PracticeStats, session *models.PracticeSession) {
+	total := make(map[string]int)
+	correct := make(map[string]int)
+	for _, answer := range session.Answers {
+		total[answer.QuestionType]++
+		if answer.IsCorrect {
+			correct[answer.QuestionType]++
+		}
+	}
+
+	if stats.Levels == nil {
+		stats.Levels = make(map[string]int)
+	}
+	for questionType, count := range total {
+		level := models.QuestionDifficulty(stats.Levels[questionType])
+		if level == 0 {
+			level = models.DifficultyEasy
+		}
+		switch {
+		case count >= 2 && correct[questionType] == count:
+			level++
+		case correct[questionType]*2 < count:
+			level--
+		}
+		stats.Levels[questionType] = int(clampDifficulty(level))
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+// 依難度路徑與作答結果建立會話
+func sessionWithAnswers(levels []int, correct []bool) *models.PracticeSession {
+	session := &models.PracticeSession{Type: string(models.PracticeTypeMixed), Levels: levels}
+	for _, isCorrect := range correct {
+		session.Answers = append(session.Answers, models.PracticeAnswer{IsCorrect: isCorrect})
+	}
+	return session
+}
+
+func TestNextDifficulty(t *testing.T) {
+	tests := []struct {
+		name    string
+		levels  []int
+		correct []bool
+		want    models.QuestionDifficulty
+	}{
+		{"one correct stays", []int{1}, []bool{true}, models.DifficultyEasy},
+		{"streak levels up", []int{1, 1}, []bool{true, true}, models.DifficultyMedium},
+		{"streak counts only the current level", []int{1, 1, 2}, []bool{true, true, true}, models.DifficultyMedium},
+		{"wrong steps down", []int{2, 3}, []bool{true, false}, models.DifficultyMedium},
+		{"never below easy", []int{1}, []bool{false}, models.DifficultyEasy},
+		{"never above expert", []int{4, 4}, []bool{true, true}, models.DifficultyExpert},
+		{"streak broken by a mistake", []int{2, 2, 2}, []bool{true, false, true}, models.DifficultyMedium},
+	}
+
+	for _, tt := range tests {
+		if got := nextDifficulty(sessionWithAnswers(tt.levels, tt.correct)); got != tt.want {
+			t.Errorf("%s: nextDifficulty = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStartingDifficulty(t *testing.T) {
+	mixed := models.PracticeConfig{Type: models.PracticeTypeMixed}
+	tests := []struct {
+		name   string
+		config models.PracticeConfig
+		levels map[string]int
+		want   models.QuestionDifficulty
+	}{
+		{"no history", mixed, nil, models.DifficultyEasy},
+		{"explicit difficulty", models.PracticeConfig{Type: models.PracticeTypeMixed, Difficulty: models.DifficultyHard}, nil, models.DifficultyHard},
+		{"single type uses its own level", models.PracticeConfig{Type: models.PracticeTypeRadical}, map[string]int{"radical": 3, "phonetic": 1}, models.DifficultyHard},
+		// 只有一個簡單題型升級時，仍從簡單開始（不是取平均）
+		{"one easy type ahead", mixed, map[string]int{"phonetic": 4, "stroke": 1}, models.DifficultyEasy},
+		{"easy types mastered", mixed, map[string]int{"phonetic": 2, "stroke": 3}, models.DifficultyMedium},
+		{"medium types mastered", mixed, map[string]int{"phonetic": 2, "stroke": 2, "radical": 3, "stroke_order": 3, "cloze": 4}, models.DifficultyHard},
+		{"hard types mastered", mixed, map[string]int{"phonetic": 4, "stroke": 4, "radical": 4, "stroke_order": 4, "cloze": 4,
+			"radical_character": 4, "next_stroke": 4, "sentence": 4}, models.DifficultyExpert},
+	}
+
+	for _, tt := range tests {
+		if got := startingDifficulty(tt.config, tt.levels); got != tt.want {
+			t.Errorf("%s: startingDifficulty = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQuestionDifficultyUsesTypeLevel(t *testing.T) {
+	levels := map[string]int{"phonetic": 4}
+	if got := questionDifficulty(models.DifficultyEasy, models.PracticeTypePhonetic, levels); got != models.DifficultyExpert {
+		t.Errorf("mastered type = %d, want expert", got)
+	}
+	if got := questionDifficulty(models.DifficultyEasy, models.PracticeTypeStroke, levels); got != models.DifficultyEasy {
+		t.Errorf("new type = %d, want easy", got)
+	}
+	if got := questionDifficulty(models.DifficultyHard, models.PracticeTypeNextStroke, levels); got != models.DifficultyHard {
+		t.Errorf("session level = %d, want hard", got)
+	}
+}
+
+func TestPracticeTypeForDifficultyRotates(t *testing.T) {
+	for difficulty := models.DifficultyEasy; difficulty <= models.DifficultyExpert; difficulty++ {
+		types := practiceTypesForDifficulty(difficulty)
+		seen := make(map[models.PracticeType]bool)
+		for i := 0; i < len(types); i++ {
+			seen[practiceTypeForDifficulty(difficulty, i)] = true
+		}
+		if len(seen) != len(types) {
+			t.Errorf("difficulty %d rotates through %d of %d types", difficulty, len(seen), len(types))
+		}
+	}
+}
+
+func TestUpdateTypeLevels(t *testing.T) {
+	stats := &models.PracticeStats{Levels: map[string]int{"radical": 3}}
+	session := &models.PracticeSession{Answers: []models.PracticeAnswer{
+		{QuestionType: "phonetic", IsCorrect: true},
+		{QuestionType: "phonetic", IsCorrect: true},
+		{QuestionType: "radical", IsCorrect: false},
+		{QuestionType: "radical", IsCorrect: true},
+		{QuestionType: "radical", IsCorrect: false},
+		{QuestionType: "stroke", IsCorrect: true},
+	}}
+	updateTypeLevels(stats, session)
+
+	want := map[string]int{"phonetic": 2, "radical": 2, "stroke": 1}
+	for questionType, level := range want {
+		if stats.Levels[questionType] != level {
+			t.Errorf("level for %s = %d, want %d", questionType, stats.Levels[questionType], level)
+		}
+	}
+}
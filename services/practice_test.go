@@ -0,0 +1,251 @@
+package services
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+// 不連資料庫的練習服務，筆順圖片網址以測試網址為開頭
+func testPracticeService() *PracticeService {
+	return &PracticeService{
+		strokeService: &StrokeService{baseURL: "https://bot.example"},
+		questionCache: make(map[string]*models.PracticeQuestion),
+	}
+}
+
+// 筆數為 strokes 的字，筆順資料直接附在字上
+func testStrokeCharacter(strokes int) *models.CharacterInfo {
+	data := &models.StrokeData{Character: "森"}
+	for i := 0; i < strokes; i++ {
+		data.Strokes = append(data.Strokes, "M 0 0 L 10 0 L 10 10 Z")
+		data.Medians = append(data.Medians, [][]float64{{0, 0}, {10, 10}})
+	}
+	return &models.CharacterInfo{Character: "森", StrokeCount: strokes, StrokeOrder: data}
+}
+
+// 從圖片網址取出檔名
+func imageFile(t *testing.T, imageURL string) string {
+	t.Helper()
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		t.Fatalf("invalid image URL %q: %v", imageURL, err)
+	}
+	return parsed.Path[strings.LastIndex(parsed.Path, "/")+1:]
+}
+
+func TestBuildStrokeOrderQuestion(t *testing.T) {
+	s := testPracticeService()
+	for i := 0; i < 50; i++ {
+		question := s.buildStrokeOrderQuestion(testStrokeCharacter(6))
+		if question.Type != string(models.PracticeTypeStrokeOrder) || len(question.Options) != 4 {
+			t.Fatalf("question = %+v", question)
+		}
+
+		match := strokeHighlightFilePattern.FindStringSubmatch(imageFile(t, question.ImageURL))
+		if match == nil {
+			t.Fatalf("image URL %q is not a highlight image", question.ImageURL)
+		}
+		correct, _ := strconv.Atoi(question.CorrectAnswer)
+		if want := "第" + match[1] + "筆"; question.Options[correct] != want {
+			t.Errorf("correct option = %s, want %s", question.Options[correct], want)
+		}
+		if s.questionCache[question.ID] != question {
+			t.Errorf("question %s is not cached", question.ID)
+		}
+	}
+}
+
+func TestBuildNextStrokeQuestion(t *testing.T) {
+	s := testPracticeService()
+	for i := 0; i < 50; i++ {
+		question := s.buildNextStrokeQuestion(testStrokeCharacter(6))
+		if question.Type != string(models.PracticeTypeNextStroke) {
+			t.Fatalf("question type = %s", question.Type)
+		}
+
+		match := strokeNextFilePattern.FindStringSubmatch(imageFile(t, question.ImageURL))
+		if match == nil {
+			t.Fatalf("image URL %q is not a next-stroke image", question.ImageURL)
+		}
+		written, _ := strconv.Atoi(match[1])
+		candidates := strings.Split(match[2], "_")
+		if len(candidates) != len(question.Options) || len(candidates) > MaxNextStrokeChoices || len(candidates) < 2 {
+			t.Fatalf("%d candidates for %d options", len(candidates), len(question.Options))
+		}
+		// 正確選項的圖是已寫筆數的下一筆，其餘候選都是更後面的筆畫
+		correct, _ := strconv.Atoi(question.CorrectAnswer)
+		for j, candidate := range candidates {
+			stroke, _ := strconv.Atoi(candidate)
+			if j == correct && stroke != written+1 {
+				t.Errorf("correct candidate is stroke %d after %d written", stroke, written)
+			}
+			if j != correct && stroke <= written+1 {
+				t.Errorf("wrong candidate is stroke %d after %d written", stroke, written)
+			}
+		}
+	}
+}
+
+func TestStrokeQuestionsFallBackWithoutStrokeData(t *testing.T) {
+	s := testPracticeService()
+	plain := &models.CharacterInfo{Character: "人", StrokeCount: 2}
+	if question := s.buildStrokeOrderQuestion(plain); question.Type != string(models.PracticeTypeStroke) {
+		t.Errorf("stroke order question without data has type %s", question.Type)
+	}
+	// 下一筆題至少要三筆才有錯誤選項
+	if question := s.buildNextStrokeQuestion(testStrokeCharacter(2)); question.Type != string(models.PracticeTypeStroke) {
+		t.Errorf("next stroke question for two strokes has type %s", question.Type)
+	}
+	// 沒有圖片網址時也不出筆順題
+	s.strokeService = &StrokeService{}
+	if question := s.buildStrokeOrderQuestion(testStrokeCharacter(6)); question.Type != string(models.PracticeTypeStroke) {
+		t.Errorf("stroke order question without image URL has type %s", question.Type)
+	}
+}
+
+// 部首題的字庫：木部三字、水部兩字、口部一字
+func testRadicalPool() []*models.CharacterInfo {
+	var pool []*models.CharacterInfo
+	for _, entry := range [][2]string{{"林", "木"}, {"森", "木"}, {"樹", "木"}, {"河", "水"}, {"海", "水"}, {"吃", "口"}} {
+		pool = append(pool, &models.CharacterInfo{Character: entry[0], Radical: entry[1]})
+	}
+	return pool
+}
+
+func TestBuildRadicalQuestion(t *testing.T) {
+	s := testPracticeService()
+	char := &models.CharacterInfo{Character: "林", Radical: "木", Phonetic: "ㄌㄧㄣˊ"}
+	for i := 0; i < 20; i++ {
+		question := s.buildRadicalQuestion(char, testRadicalPool())
+		if question.Type != string(models.PracticeTypeRadical) {
+			t.Fatalf("question type = %s", question.Type)
+		}
+		// 三種部首，不重複
+		if len(question.Options) != 3 {
+			t.Fatalf("options = %v, want the three distinct radicals", question.Options)
+		}
+		correct, _ := strconv.Atoi(question.CorrectAnswer)
+		if question.Options[correct] != "木" {
+			t.Errorf("correct option = %s, want 木", question.Options[correct])
+		}
+	}
+}
+
+func TestBuildRadicalCharacterQuestion(t *testing.T) {
+	s := testPracticeService()
+	char := &models.CharacterInfo{Character: "林", Radical: "木", Phonetic: "ㄌㄧㄣˊ"}
+	radicals := make(map[string]string)
+	for _, character := range testRadicalPool() {
+		radicals[character.Character] = character.Radical
+	}
+	for i := 0; i < 20; i++ {
+		question := s.buildRadicalCharacterQuestion(char, testRadicalPool())
+		if question.Type != string(models.PracticeTypeRadicalCharacter) || len(question.Options) != 4 {
+			t.Fatalf("question = %+v", question)
+		}
+		// 只有正確答案是木部，錯誤選項不能是同部首的字
+		correct, _ := strconv.Atoi(question.CorrectAnswer)
+		for j, option := range question.Options {
+			if (j == correct) != (radicals[option] == "木") {
+				t.Errorf("option %s (radical %s) at %d, correct %d", option, radicals[option], j, correct)
+			}
+		}
+	}
+}
+
+func TestRadicalQuestionsFallBackToPhonetic(t *testing.T) {
+	s := testPracticeService()
+	noRadical := &models.CharacterInfo{Character: "林", Phonetic: "ㄌㄧㄣˊ"}
+	if question := s.buildRadicalQuestion(noRadical, testRadicalPool()); question.Type != string(models.PracticeTypePhonetic) {
+		t.Errorf("radical question without radical has type %s", question.Type)
+	}
+	// 字庫裡沒有其他部首，湊不出錯誤選項
+	char := &models.CharacterInfo{Character: "林", Radical: "木", Phonetic: "ㄌㄧㄣˊ"}
+	sameRadical := testRadicalPool()[:3]
+	if question := s.buildRadicalQuestion(char, sameRadical); question.Type != string(models.PracticeTypePhonetic) {
+		t.Errorf("radical question without distractors has type %s", question.Type)
+	}
+	if question := s.buildRadicalCharacterQuestion(char, sameRadical); question.Type != string(models.PracticeTypePhonetic) {
+		t.Errorf("radical character question without distractors has type %s", question.Type)
+	}
+}
+
+func TestClozeContexts(t *testing.T) {
+	char := &models.CharacterInfo{
+		Character: "林",
+		Examples:  []string{"森林裡有小鳥。", "林"},
+		Readings:  []models.CharacterReading{{Phonetic: "ㄌㄧㄣˊ", Words: []string{"樹林", "森林裡有小鳥。"}}},
+	}
+	got := clozeContexts(char, []string{" 山林 ", "河水", "樹林"})
+	want := []string{"森林裡有小鳥。", "樹林", "山林"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clozeContexts = %v, want %v", got, want)
+	}
+}
+
+func TestClozeSimilarity(t *testing.T) {
+	char := &models.CharacterInfo{Character: "林", Phonetic: "ㄌㄧㄣˊ", Radical: "木", StrokeCount: 8}
+	tests := []struct {
+		candidate *models.CharacterInfo
+		want      int
+	}{
+		// 同音不同調、同部首、筆畫相近
+		{&models.CharacterInfo{Character: "檁", Phonetic: "ㄌㄧㄣˇ", Radical: "木", StrokeCount: 17}, 5},
+		{&models.CharacterInfo{Character: "淋", Phonetic: "ㄌㄧㄣˊ", Radical: "水", StrokeCount: 11}, 3},
+		{&models.CharacterInfo{Character: "松", Phonetic: "ㄙㄨㄥ", Radical: "木", StrokeCount: 8}, 3},
+		{&models.CharacterInfo{Character: "河", Phonetic: "ㄏㄜˊ", Radical: "水", StrokeCount: 8}, 1},
+		{&models.CharacterInfo{Character: "鳥", Phonetic: "ㄋㄧㄠˇ", Radical: "鳥", StrokeCount: 11}, 0},
+	}
+	for _, tt := range tests {
+		if got := clozeSimilarity(char, tt.candidate); got != tt.want {
+			t.Errorf("clozeSimilarity(林, %s) = %d, want %d", tt.candidate.Character, got, tt.want)
+		}
+	}
+}
+
+func TestBuildClozeQuestion(t *testing.T) {
+	s := testPracticeService()
+	char := &models.CharacterInfo{Character: "林", Phonetic: "ㄌㄧㄣˊ", Radical: "木", StrokeCount: 8}
+	learned := &learnedVocabulary{
+		words: []string{"樹林", "樹木"},
+		characters: []*models.CharacterInfo{
+			char,
+			{Character: "木", Phonetic: "ㄇㄨˋ", Radical: "木", StrokeCount: 4},
+			{Character: "淋", Phonetic: "ㄌㄧㄣˊ", Radical: "水", StrokeCount: 11},
+			{Character: "星", Phonetic: "ㄒㄧㄥ", Radical: "日", StrokeCount: 9},
+			{Character: "松", Phonetic: "ㄙㄨㄥ", Radical: "木", StrokeCount: 8},
+			{Character: "魚", Phonetic: "ㄩˊ", Radical: "魚", StrokeCount: 11},
+		},
+	}
+	for i := 0; i < 20; i++ {
+		question := s.buildClozeQuestion(char, learned)
+		if question.Type != string(models.PracticeTypeCloze) {
+			t.Fatalf("question type = %s", question.Type)
+		}
+		if !strings.Contains(question.Question, "樹（　）") {
+			t.Errorf("question = %q, want the blanked word", question.Question)
+		}
+		// 「樹木」也是學過的語詞，木不能當錯誤選項；最不相似的魚排不進前三
+		want := map[string]bool{"林": true, "淋": true, "松": true, "星": true}
+		for _, option := range question.Options {
+			if !want[option] {
+				t.Errorf("options = %v, want 林、淋、松、星", question.Options)
+				break
+			}
+		}
+		correct, _ := strconv.Atoi(question.CorrectAnswer)
+		if len(question.Options) != 4 || question.Options[correct] != "林" {
+			t.Errorf("options = %v, correct %d", question.Options, correct)
+		}
+	}
+
+	// 沒有例句與語詞時改出注音題
+	if question := s.buildClozeQuestion(char, &learnedVocabulary{characters: learned.characters}); question.Type != string(models.PracticeTypePhonetic) {
+		t.Errorf("cloze question without contexts has type %s", question.Type)
+	}
+}
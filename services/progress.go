@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+)
+
+const lessonProgressCollection = "lesson_progress"
+
+// ProgressService 記錄用戶課次進度的歷史
+type ProgressService struct {
+	firebaseClient *config.FirebaseClient
+}
+
+func NewProgressService(firebaseClient *config.FirebaseClient) *ProgressService {
+	return &ProgressService{
+		firebaseClient: firebaseClient,
+	}
+}
+
+// RecordLesson 記錄用戶目前的課次
+func (s *ProgressService) RecordLesson(userID string, publisher string, grade int, semester int, lesson int) error {
+	record := &models.LessonProgressRecord{
+		UserID:     userID,
+		Publisher:  publisher,
+		Grade:      grade,
+		Semester:   semester,
+		Lesson:     lesson,
+		RecordedAt: time.Now().Unix(),
+	}
+	_, _, err := s.firebaseClient.Firestore.Collection(lessonProgressCollection).Add(s.firebaseClient.Ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to record lesson progress: %w", err)
+	}
+	return nil
+}
+
+// GetLatestBefore 取得指定時間之前最後一筆課次紀錄（沒有紀錄時回傳 nil）
+func (s *ProgressService) GetLatestBefore(userID string, before time.Time) (*models.LessonProgressRecord, error) {
+	docs, err := s.firebaseClient.Firestore.Collection(lessonProgressCollection).
+		Where("userId", "==", userID).
+		Where("recordedAt", "<", before.Unix()).
+		OrderBy("recordedAt", firestore.Desc).
+		Limit(1).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lesson progress: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	var record models.LessonProgressRecord
+	if err := docs[0].DataTo(&record); err != nil {
+		return nil, fmt.Errorf("failed to parse lesson progress: %v", err)
+	}
+	return &record, nil
+}
+
+// GetRecordsSince 取得指定時間之後的課次紀錄（舊到新）
+func (s *ProgressService) GetRecordsSince(userID string, since time.Time) ([]*models.LessonProgressRecord, error) {
+	docs, err := s.firebaseClient.Firestore.Collection(lessonProgressCollection).
+		Where("userId", "==", userID).
+		Where("recordedAt", ">=", since.Unix()).
+		OrderBy("recordedAt", firestore.Asc).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lesson progress: %w", err)
+	}
+
+	var records []*models.LessonProgressRecord
+	for _, doc := range docs {
+		var record models.LessonProgressRecord
+		if err := doc.DataTo(&record); err != nil {
+			continue
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+)
+
+// 週報中列出最常答錯的字數
+const maxMostMissedCharacters = 5
+
+// ReportService 產生家長學習週報
+type ReportService struct {
+	firebaseClient  *config.FirebaseClient
+	lessonService   *LessonService
+	practiceService *PracticeService
+	progressService *ProgressService
+}
+
+func NewReportService(firebaseClient *config.FirebaseClient) *ReportService {
+	return &ReportService{
+		firebaseClient:  firebaseClient,
+		lessonService:   NewLessonService(firebaseClient),
+		practiceService: NewPracticeService(firebaseClient),
+		progressService: NewProgressService(firebaseClient),
+	}
+}
+
+// BuildWeeklyReport 產生截至 now 為止最近七天的學習週報
+func (s *ReportService) BuildWeeklyReport(userID string, state *models.UserState, now time.Time) (*models.WeeklyReport, error) {
+	if state.PreferredPublisher == "" || state.PreferredGrade == 0 || state.PreferredSemester == 0 {
+		return nil, fmt.Errorf("user has no course preferences")
+	}
+
+	now = now.In(taipeiLocation())
+	weekStart := now.AddDate(0, 0, -7)
+	report := &models.WeeklyReport{
+		UserID:         userID,
+		PeriodStart:    weekStart.Unix(),
+		PeriodEnd:      now.Unix(),
+		CurrentLesson:  state.PreferredLesson,
+		AccuracyByType: make(map[string]models.QuestionTypeAccuracy),
+	}
+
+	semester := state.PreferredSemester
+	progress, err := s.lessonService.GetLearningProgress(state.PreferredPublisher, state.PreferredGrade, &semester)
+	if err != nil {
+		log.Printf("Error getting learning progress for report: %v", err)
+	} else {
+		progress.CompletedLessons = state.PreferredLesson
+		if progress.TotalLessons > 0 {
+			progress.ProgressPercentage = float64(state.PreferredLesson) / float64(progress.TotalLessons) * 100
+			if progress.ProgressPercentage > 100 {
+				progress.ProgressPercentage = 100
+			}
+		}
+		progress.LastUpdated = now.Unix()
+		report.Progress = progress
+	}
+
+	report.LearnedThisWeek = s.charactersLearnedSince(userID, state, weekStart)
+
+	if upcoming, err := s.lessonService.GetLesson(state.PreferredPublisher, state.PreferredGrade, state.PreferredSemester, state.PreferredLesson+1); err == nil {
+		report.UpcomingCharacters = upcoming.Characters
+		report.UpcomingLesson = upcoming.Title
+	}
+
+	sessions, err := s.practiceService.GetSessionsSince(userID, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	report.SessionsThisWeek = len(sessions)
+	report.AccuracyByType, report.MostMissed = summarizeAnswers(sessions)
+
+	stats, err := s.practiceService.GetStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	report.Stats = stats
+	report.PracticedToday = stats.LastPracticeDate == now.Format("2006-01-02")
+
+	return report, nil
+}
+
+// 依本週課次紀錄推算本週學到的字：上週最後的課次之後，到目前課次為止的每一課
+func (s *ReportService) charactersLearnedSince(userID string, state *models.UserState, since time.Time) []string {
+	baseline := 0
+	previous, err := s.progressService.GetLatestBefore(userID, since)
+	if err != nil {
+		log.Printf("Error getting lesson progress for report: %v", err)
+		return nil
+	}
+
+	sameTerm := func(record *models.LessonProgressRecord) bool {
+		return record.Publisher == state.PreferredPublisher && record.Grade == state.PreferredGrade && record.Semester == state.PreferredSemester
+	}
+
+	if previous != nil && sameTerm(previous) {
+		baseline = previous.Lesson
+	} else {
+		// 本週之前沒有同學期的紀錄時，以本週第一筆紀錄的前一課為基準
+		records, err := s.progressService.GetRecordsSince(userID, since)
+		if err != nil {
+			log.Printf("Error getting lesson progress for report: %v", err)
+			return nil
+		}
+		baseline = state.PreferredLesson
+		for _, record := range records {
+			if sameTerm(record) && record.Lesson-1 < baseline {
+				baseline = record.Lesson - 1
+			}
+		}
+	}
+
+	var characters []string
+	seen := make(map[string]bool)
+	for lessonNumber := baseline + 1; lessonNumber <= state.PreferredLesson; lessonNumber++ {
+		lesson, err := s.lessonService.GetLesson(state.PreferredPublisher, state.PreferredGrade, state.PreferredSemester, lessonNumber)
+		if err != nil {
+			continue
+		}
+		for _, char := range lesson.Characters {
+			if !seen[char] {
+				seen[char] = true
+				characters = append(characters, char)
+			}
+		}
+	}
+	return characters
+}
+
+// 統計各題型正確率與最常答錯的字
+func summarizeAnswers(sessions []*models.PracticeSession) (map[string]models.QuestionTypeAccuracy, []models.MissedCharacter) {
+	accuracy := make(map[string]models.QuestionTypeAccuracy)
+	wrongCounts := make(map[string]int)
+
+	for _, session := range sessions {
+		for _, answer := range session.Answers {
+			questionType := answer.QuestionType
+			if questionType == "" {
+				questionType = session.Type
+			}
+			entry := accuracy[questionType]
+			entry.Type = questionType
+			entry.Total++
+			if answer.IsCorrect {
+				entry.Correct++
+			} else if answer.Character != "" {
+				wrongCounts[answer.Character]++
+			}
+			accuracy[questionType] = entry
+		}
+	}
+
+	for questionType, entry := range accuracy {
+		if entry.Total > 0 {
+			entry.Accuracy = float64(entry.Correct) / float64(entry.Total) * 100
+		}
+		accuracy[questionType] = entry
+	}
+
+	var missed []models.MissedCharacter
+	for char, count := range wrongCounts {
+		missed = append(missed, models.MissedCharacter{Character: char, WrongCount: count})
+	}
+	sort.Slice(missed, func(i, j int) bool {
+		if missed[i].WrongCount != missed[j].WrongCount {
+			return missed[i].WrongCount > missed[j].WrongCount
+		}
+		return missed[i].Character < missed[j].Character
+	})
+	if len(missed) > maxMostMissedCharacters {
+		missed = missed[:maxMostMissedCharacters]
+	}
+
+	return accuracy, missed
+}
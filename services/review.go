@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+)
+
+const reviewCollection = "review_items"
+
+// ReviewService 間隔複習排程
+type ReviewService struct {
+	firebaseClient *config.FirebaseClient
+}
+
+func NewReviewService(firebaseClient *config.FirebaseClient) *ReviewService {
+	return &ReviewService{
+		firebaseClient: firebaseClient,
+	}
+}
+
+// AddCharacter 加入複習項目（已存在時不變動）
+func (s *ReviewService) AddCharacter(userID string, character string) error {
+	id := reviewItemID(userID, character)
+	ref := s.firebaseClient.Firestore.Collection(reviewCollection).Doc(id)
+
+	_, err := ref.Get(s.firebaseClient.Ctx)
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to get review item: %w", err)
+	}
+
+	now := time.Now()
+	item := &models.ReviewItem{
+		ID:           id,
+		UserID:       userID,
+		Character:    character,
+		Box:          0,
+		NextReviewAt: now.AddDate(0, 0, models.ReviewIntervalsInDays[0]).Unix(),
+		CreatedAt:    now.Unix(),
+	}
+	if _, err := ref.Set(s.firebaseClient.Ctx, item); err != nil {
+		return fmt.Errorf("failed to save review item: %w", err)
+	}
+	return nil
+}
+
+// RecordResult 記錄複習結果：答對升一盒、答錯回到第一盒
+func (s *ReviewService) RecordResult(userID string, character string, correct bool) error {
+	ref := s.firebaseClient.Firestore.Collection(reviewCollection).Doc(reviewItemID(userID, character))
+	now := time.Now()
+
+	doc, err := ref.Get(s.firebaseClient.Ctx)
+	item := &models.ReviewItem{
+		ID:        ref.ID,
+		UserID:    userID,
+		Character: character,
+		CreatedAt: now.Unix(),
+	}
+	if err == nil {
+		if err := doc.DataTo(item); err != nil {
+			return fmt.Errorf("failed to parse review item: %v", err)
+		}
+	} else if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to get review item: %w", err)
+	}
+
+	if correct {
+		item.CorrectCount++
+		if item.Box < len(models.ReviewIntervalsInDays)-1 {
+			item.Box++
+		}
+	} else {
+		item.WrongCount++
+		item.Box = 0
+	}
+	item.LastReviewedAt = now.Unix()
+	item.NextReviewAt = now.AddDate(0, 0, models.ReviewIntervalsInDays[item.Box]).Unix()
+
+	if _, err := ref.Set(s.firebaseClient.Ctx, item); err != nil {
+		return fmt.Errorf("failed to save review item: %w", err)
+	}
+	return nil
+}
+
+// GetDueItems 取得到期需要複習的項目（最早到期的在前）
+func (s *ReviewService) GetDueItems(userID string, now time.Time) ([]*models.ReviewItem, error) {
+	docs, err := s.firebaseClient.Firestore.Collection(reviewCollection).
+		Where("userId", "==", userID).
+		Where("nextReviewAt", "<=", now.Unix()).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review items: %w", err)
+	}
+
+	var items []*models.ReviewItem
+	for _, doc := range docs {
+		var item models.ReviewItem
+		if err := doc.DataTo(&item); err != nil {
+			continue
+		}
+		items = append(items, &item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].NextReviewAt < items[j].NextReviewAt
+	})
+
+	return items, nil
+}
+
+func reviewItemID(userID string, character string) string {
+	return fmt.Sprintf("%s_%s", userID, character)
+}
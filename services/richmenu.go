@@ -0,0 +1,238 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+)
+
+// 圖文選單的 alias，用於切換與查詢選單 ID
+const (
+	RichMenuAliasMain     = "main-menu"
+	RichMenuAliasPractice = "practice-menu"
+)
+
+// RichMenuButton 圖文選單上的按鈕
+type RichMenuButton struct {
+	Label   string // 按鈕名稱（顯示於 postback 的 displayText，需與選單圖片上的文字一致）
+	Command string // 點擊後執行的文字指令
+}
+
+// RichMenuLayout 圖文選單版面定義
+type RichMenuLayout struct {
+	AliasID     string
+	Name        string
+	ChatBarText string
+	Width       int
+	Height      int
+	Columns     int
+	Rows        int
+	Buttons     []RichMenuButton // 由左至右、由上至下排列
+	ImageEnv    string           // 選單圖片路徑的環境變數（圖片需畫出各按鈕的名稱）
+}
+
+// MainRichMenuLayout 主選單：常用功能
+func MainRichMenuLayout() RichMenuLayout {
+	return RichMenuLayout{
+		AliasID:     RichMenuAliasMain,
+		Name:        "中文學習小幫手主選單",
+		ChatBarText: "功能選單",
+		Width:       2500,
+		Height:      1686,
+		Columns:     3,
+		Rows:        2,
+		ImageEnv:    "RICH_MENU_MAIN_IMAGE",
+		Buttons: []RichMenuButton{
+			{Label: "查詢累積字詞", Command: "查詢累積字詞"},
+			{Label: "練習", Command: "練習"},
+			{Label: "印字帖", Command: "印字帖"},
+			{Label: "平板學寫字", Command: "平板學寫字"},
+			{Label: "我的設定", Command: "我的設定"},
+			{Label: "幫助", Command: "幫助"},
+		},
+	}
+}
+
+// PracticeRichMenuLayout 練習模式選單：練習進行中時切換
+func PracticeRichMenuLayout() RichMenuLayout {
+	return RichMenuLayout{
+		AliasID:     RichMenuAliasPractice,
+		Name:        "中文學習小幫手練習選單",
+		ChatBarText: "練習中",
+		Width:       2500,
+		Height:      843,
+		Columns:     3,
+		Rows:        1,
+		ImageEnv:    "RICH_MENU_PRACTICE_IMAGE",
+		Buttons: []RichMenuButton{
+			{Label: "結束練習", Command: "退出"},
+			{Label: "學習週報", Command: "學習週報"},
+			{Label: "幫助", Command: "幫助"},
+		},
+	}
+}
+
+// RichMenu 轉換為 LINE API 的圖文選單物件
+func (l RichMenuLayout) RichMenu() linebot.RichMenu {
+	cellWidth := l.Width / l.Columns
+	cellHeight := l.Height / l.Rows
+
+	var areas []linebot.AreaDetail
+	for i, button := range l.Buttons {
+		col := i % l.Columns
+		row := i / l.Columns
+		width := cellWidth
+		if col == l.Columns-1 {
+			width = l.Width - cellWidth*col
+		}
+		height := cellHeight
+		if row == l.Rows-1 {
+			height = l.Height - cellHeight*row
+		}
+
+		areas = append(areas, linebot.AreaDetail{
+			Bounds: linebot.RichMenuBounds{X: col * cellWidth, Y: row * cellHeight, Width: width, Height: height},
+			Action: linebot.RichMenuAction{
+				Type:        linebot.RichMenuActionTypePostback,
+				Data:        RichMenuPostbackData(button.Command),
+				DisplayText: button.Label,
+			},
+		})
+	}
+
+	return linebot.RichMenu{
+		Size:        linebot.RichMenuSize{Width: l.Width, Height: l.Height},
+		Selected:    false,
+		Name:        l.Name,
+		ChatBarText: l.ChatBarText,
+		Areas:       areas,
+	}
+}
+
+// RichMenuPostbackData 圖文選單按鈕的 postback 資料
+func RichMenuPostbackData(command string) string {
+	values := url.Values{}
+	values.Set("action", "command")
+	values.Set("text", command)
+	return values.Encode()
+}
+
+// RichMenuService 圖文選單的建立與切換
+type RichMenuService struct {
+	bot *linebot.Client
+
+	mu      sync.Mutex
+	idCache map[string]string // alias → rich menu ID
+}
+
+func NewRichMenuService(bot *linebot.Client) *RichMenuService {
+	return &RichMenuService{
+		bot:     bot,
+		idCache: make(map[string]string),
+	}
+}
+
+// Provision 建立並上傳所有圖文選單，設定 alias，並將主選單設為預設
+// 同名的舊選單會被刪除，可重複執行；任一選單沒有設定圖片時不會建立任何選單
+func (s *RichMenuService) Provision() error {
+	layouts := []RichMenuLayout{MainRichMenuLayout(), PracticeRichMenuLayout()}
+	for _, layout := range layouts {
+		if os.Getenv(layout.ImageEnv) == "" {
+			return fmt.Errorf("%s is not set: rich menu %s needs an image showing its button labels", layout.ImageEnv, layout.Name)
+		}
+	}
+
+	existing, err := s.bot.GetRichMenuList().Do()
+	if err != nil {
+		return fmt.Errorf("failed to list rich menus: %w", err)
+	}
+
+	var mainID string
+	for _, layout := range layouts {
+		richMenuID, err := s.provisionLayout(layout)
+		if err != nil {
+			return err
+		}
+		if layout.AliasID == RichMenuAliasMain {
+			mainID = richMenuID
+		}
+
+		// 刪除同名的舊選單
+		for _, menu := range existing {
+			if menu.Name == layout.Name && menu.RichMenuID != richMenuID {
+				if _, err := s.bot.DeleteRichMenu(menu.RichMenuID).Do(); err != nil {
+					log.Printf("Error deleting old rich menu %s: %v", menu.RichMenuID, err)
+				}
+			}
+		}
+	}
+
+	if _, err := s.bot.SetDefaultRichMenu(mainID).Do(); err != nil {
+		return fmt.Errorf("failed to set default rich menu: %w", err)
+	}
+	return nil
+}
+
+func (s *RichMenuService) provisionLayout(layout RichMenuLayout) (string, error) {
+	resp, err := s.bot.CreateRichMenu(layout.RichMenu()).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to create rich menu %s: %w", layout.Name, err)
+	}
+
+	if _, err := s.bot.UploadRichMenuImage(resp.RichMenuID, os.Getenv(layout.ImageEnv)).Do(); err != nil {
+		return "", fmt.Errorf("failed to upload rich menu image for %s: %w", layout.Name, err)
+	}
+
+	// alias 已存在時改為更新
+	if _, err := s.bot.CreateRichMenuAlias(layout.AliasID, resp.RichMenuID).Do(); err != nil {
+		if _, err := s.bot.UpdateRichMenuAlias(layout.AliasID, resp.RichMenuID).Do(); err != nil {
+			return "", fmt.Errorf("failed to set rich menu alias %s: %w", layout.AliasID, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.idCache[layout.AliasID] = resp.RichMenuID
+	s.mu.Unlock()
+
+	log.Printf("Rich menu %s provisioned: %s", layout.AliasID, resp.RichMenuID)
+	return resp.RichMenuID, nil
+}
+
+// SwitchToPractice 將用戶的選單切換為練習模式選單
+func (s *RichMenuService) SwitchToPractice(userID string) error {
+	richMenuID, err := s.resolveAlias(RichMenuAliasPractice)
+	if err != nil {
+		return err
+	}
+	_, err = s.bot.LinkUserRichMenu(userID, richMenuID).Do()
+	return err
+}
+
+// SwitchToDefault 解除用戶專屬選單，恢復預設主選單
+func (s *RichMenuService) SwitchToDefault(userID string) error {
+	_, err := s.bot.UnlinkUserRichMenu(userID).Do()
+	return err
+}
+
+func (s *RichMenuService) resolveAlias(aliasID string) (string, error) {
+	s.mu.Lock()
+	richMenuID, ok := s.idCache[aliasID]
+	s.mu.Unlock()
+	if ok {
+		return richMenuID, nil
+	}
+
+	resp, err := s.bot.GetRichMenuAlias(aliasID).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to get rich menu alias %s: %w", aliasID, err)
+	}
+
+	s.mu.Lock()
+	s.idCache[aliasID] = resp.RichMenuID
+	s.mu.Unlock()
+	return resp.RichMenuID, nil
+}
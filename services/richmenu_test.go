@@ -0,0 +1,34 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRichMenuLayoutAreas(t *testing.T) {
+	for _, layout := range []RichMenuLayout{MainRichMenuLayout(), PracticeRichMenuLayout()} {
+		menu := layout.RichMenu()
+		if len(menu.Areas) != len(layout.Buttons) || len(layout.Buttons) != layout.Columns*layout.Rows {
+			t.Errorf("%s: %d areas for %d buttons in a %dx%d grid", layout.AliasID, len(menu.Areas), len(layout.Buttons), layout.Columns, layout.Rows)
+			continue
+		}
+
+		// 按鈕區域剛好鋪滿整個選單，不重疊也不超出
+		total := 0
+		for i, area := range menu.Areas {
+			bounds := area.Bounds
+			if bounds.X < 0 || bounds.Y < 0 || bounds.X+bounds.Width > layout.Width || bounds.Y+bounds.Height > layout.Height {
+				t.Errorf("%s: area %d out of bounds: %+v", layout.AliasID, i, bounds)
+			}
+			total += bounds.Width * bounds.Height
+
+			values, err := url.ParseQuery(area.Action.Data)
+			if err != nil || values.Get("action") != "command" || values.Get("text") != layout.Buttons[i].Command {
+				t.Errorf("%s: area %d postback data = %q", layout.AliasID, i, area.Action.Data)
+			}
+		}
+		if total != layout.Width*layout.Height {
+			t.Errorf("%s: areas cover %d of %d pixels", layout.AliasID, total, layout.Width*layout.Height)
+		}
+	}
+}
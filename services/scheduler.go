@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CronSchedule 解析後的 cron 排程（分 時 日 月 星期）
+type CronSchedule struct {
+	minutes  uint64 // bit 0-59
+	hours    uint64 // bit 0-23
+	days     uint64 // bit 1-31
+	months   uint64 // bit 1-12
+	weekdays uint64 // bit 0-6（0 為星期日）
+
+	// 日與星期都有指定時，依 cron 慣例任一符合即可
+	dayRestricted     bool
+	weekdayRestricted bool
+}
+
+// ParseCronSpec 解析五欄位 cron 表達式，支援 *、數字、範圍 (a-b)、列表 (a,b) 與間隔 (*/n、a-b/n)
+func ParseCronSpec(spec string) (*CronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron spec %q: expected 5 fields", spec)
+	}
+
+	schedule := &CronSchedule{}
+	var err error
+	if schedule.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %v", err)
+	}
+	if schedule.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %v", err)
+	}
+	if schedule.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day field: %v", err)
+	}
+	if schedule.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month field: %v", err)
+	}
+	// 星期允許 7 代表星期日
+	if schedule.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid weekday field: %v", err)
+	}
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+	schedule.dayRestricted = fields[2] != "*"
+	schedule.weekdayRestricted = fields[4] != "*"
+
+	return schedule, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:idx]
+		}
+
+		start, end := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			start = value
+			if step == 1 {
+				end = value
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("value out of range in %q (%d-%d)", part, min, max)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next 取得 t 之後的下一次觸發時間（以 t 的時區計算）
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	// 最多往後搜尋五年，避免不可能的排程（例如 2 月 30 日）造成無限迴圈
+	limit := next.AddDate(5, 0, 0)
+
+	for next.Before(limit) {
+		if c.months&(1<<uint(next.Month())) == 0 {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !c.matchDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if c.hours&(1<<uint(next.Hour())) == 0 {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if c.minutes&(1<<uint(next.Minute())) == 0 {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+
+	return time.Time{}
+}
+
+func (c *CronSchedule) matchDay(t time.Time) bool {
+	dayMatch := c.days&(1<<uint(t.Day())) != 0
+	weekdayMatch := c.weekdays&(1<<uint(t.Weekday())) != 0
+	if c.dayRestricted && c.weekdayRestricted {
+		return dayMatch || weekdayMatch
+	}
+	return dayMatch && weekdayMatch
+}
+
+type scheduledJob struct {
+	name     string
+	schedule *CronSchedule
+	run      func(ctx context.Context)
+}
+
+// Scheduler 行程內的排程器，依 cron 表達式於指定時區執行工作
+type Scheduler struct {
+	location *time.Location
+	jobs     []*scheduledJob
+	wg       sync.WaitGroup
+}
+
+func NewScheduler(location *time.Location) *Scheduler {
+	if location == nil {
+		location = taipeiLocation()
+	}
+	return &Scheduler{
+		location: location,
+	}
+}
+
+// AddJob 註冊排程工作
+func (s *Scheduler) AddJob(name string, spec string, run func(ctx context.Context)) error {
+	schedule, err := ParseCronSpec(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	s.jobs = append(s.jobs, &scheduledJob{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Start 啟動所有排程工作，ctx 取消時停止
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait 等待所有排程工作結束
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job *scheduledJob) {
+	defer s.wg.Done()
+
+	for {
+		now := time.Now().In(s.location)
+		next := job.schedule.Next(now)
+		if next.IsZero() {
+			log.Printf("Scheduler: job %s has no upcoming run, stopping", job.name)
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runJob(ctx, job)
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job *scheduledJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Scheduler: job %s panicked: %v\n%s", job.name, r, debug.Stack())
+		}
+	}()
+
+	start := time.Now()
+	log.Printf("Scheduler: running job %s", job.name)
+	job.run(ctx)
+	log.Printf("Scheduler: job %s finished in %v", job.name, time.Since(start))
+}
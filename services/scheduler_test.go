@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronSpecErrors(t *testing.T) {
+	for _, spec := range []string{
+		"", "0 7 * *", "60 7 * * *", "0 24 * * *", "0 7 0 * *", "0 7 * 13 *", "0 7 * * 8",
+		"0 7-5 * * *", "*/0 * * * *", "a 7 * * *",
+	} {
+		if _, err := ParseCronSpec(spec); err == nil {
+			t.Errorf("ParseCronSpec(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	taipei := taipeiLocation()
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, taipei)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 7 * * *", at(2026, 10, 18, 6, 59), at(2026, 10, 18, 7, 0)},
+		{"0 7 * * *", at(2026, 10, 18, 7, 0), at(2026, 10, 19, 7, 0)},
+		// 跨月、跨年
+		{"0 7 * * *", at(2026, 10, 31, 8, 0), at(2026, 11, 1, 7, 0)},
+		{"0 7 * * *", at(2026, 12, 31, 8, 0), at(2027, 1, 1, 7, 0)},
+		// 範圍與間隔
+		{"*/15 9-10 * * *", at(2026, 10, 18, 9, 1), at(2026, 10, 18, 9, 15)},
+		{"*/15 9-10 * * *", at(2026, 10, 18, 10, 45), at(2026, 10, 19, 9, 0)},
+		{"0 8-18/5 * * *", at(2026, 10, 18, 9, 0), at(2026, 10, 18, 13, 0)},
+		{"5/20 * * * *", at(2026, 10, 18, 9, 30), at(2026, 10, 18, 9, 45)},
+		{"0 12 1,15 * *", at(2026, 10, 2, 0, 0), at(2026, 10, 15, 12, 0)},
+		// 星期：2026-10-18 是星期日，7 也代表星期日
+		{"0 20 * * 0", at(2026, 10, 12, 0, 0), at(2026, 10, 18, 20, 0)},
+		{"0 20 * * 7", at(2026, 10, 12, 0, 0), at(2026, 10, 18, 20, 0)},
+		{"30 7 * * 1-5", at(2026, 10, 16, 8, 0), at(2026, 10, 19, 7, 30)},
+		// 日與星期都有指定時，任一符合即可（每月 1 日或星期一）
+		{"0 9 1 * 1", at(2026, 10, 20, 0, 0), at(2026, 10, 26, 9, 0)},
+		{"0 9 1 * 1", at(2026, 10, 27, 0, 0), at(2026, 11, 1, 9, 0)},
+		{"0 0 29 2 *", at(2026, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := ParseCronSpec(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCronSpec(%q): %v", tt.spec, err)
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q, %v) = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronScheduleImpossibleDate(t *testing.T) {
+	schedule, err := ParseCronSpec("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCronSpec: %v", err)
+	}
+	if next := schedule.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, taipeiLocation())); !next.IsZero() {
+		t.Errorf("Next for 2/30 = %v, want zero time", next)
+	}
+}
+
+func TestPickDailyCharacter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 7, 0, 0, 0, taipeiLocation())
+	characters := []string{"森", "林", "鳥"}
+	if got := pickDailyCharacter(characters, []string{"森"}, now); got != "林" {
+		t.Errorf("pickDailyCharacter = %q, want 林", got)
+	}
+	// 全部推播過時依日期輪替
+	if got := pickDailyCharacter(characters, characters, now); got != characters[now.YearDay()%3] {
+		t.Errorf("pickDailyCharacter after all pushed = %q", got)
+	}
+}
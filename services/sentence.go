@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 造句至少要有幾個字（不含標點符號）
+const minSentenceLength = 5
+
+// SentenceInput 造句評分的輸入
+type SentenceInput struct {
+	Character string   // 題目指定要用的字
+	Prompt    string   // 題目文字，學生照抄題目時不算造句
+	Sentence  string   // 學生寫的句子
+	Learned   []string // 已學過的字，空白時不計算已學字比例
+	Previous  []string // 同一次練習中先前送出的句子
+}
+
+// SentenceEvaluation 造句評分結果
+type SentenceEvaluation struct {
+	Accepted bool   `json:"accepted"` // 是否算答對
+	Score    int    `json:"score"`    // 分數（0-100）
+	Feedback string `json:"feedback"` // 給學生的建議
+}
+
+// SentenceScorer 造句評分器，可替換為本地模型等其他實作
+type SentenceScorer interface {
+	Evaluate(input SentenceInput) (*SentenceEvaluation, error)
+}
+
+// 目前使用的造句評分器，預設為規則評分
+var (
+	sentenceScorerMu sync.RWMutex
+	sentenceScorer   SentenceScorer = RuleSentenceScorer{}
+)
+
+// SetSentenceScorer 替換造句評分器（例如接上本地模型），傳入 nil 時恢復規則評分
+func SetSentenceScorer(scorer SentenceScorer) {
+	sentenceScorerMu.Lock()
+	defer sentenceScorerMu.Unlock()
+	if scorer == nil {
+		scorer = RuleSentenceScorer{}
+	}
+	sentenceScorer = scorer
+}
+
+// EvaluateSentence 評分造句，評分器失敗時改用規則評分
+func EvaluateSentence(input SentenceInput) *SentenceEvaluation {
+	sentenceScorerMu.RLock()
+	scorer := sentenceScorer
+	sentenceScorerMu.RUnlock()
+
+	evaluation, err := scorer.Evaluate(input)
+	if err != nil {
+		log.Printf("Error evaluating sentence, falling back to rules: %v", err)
+		evaluation, _ = RuleSentenceScorer{}.Evaluate(input)
+	}
+	return evaluation
+}
+
+// RuleSentenceScorer 以規則評分：檢查長度、是否只重複題目、是否重複送出，並依已學字比例計分
+type RuleSentenceScorer struct{}
+
+// Evaluate 規則評分
+func (RuleSentenceScorer) Evaluate(input SentenceInput) (*SentenceEvaluation, error) {
+	char := input.Character
+	text := sentenceText(input.Sentence)
+	reject := func(feedback string) (*SentenceEvaluation, error) {
+		return &SentenceEvaluation{Feedback: feedback}, nil
+	}
+
+	if !strings.Contains(text, char) {
+		return reject(fmt.Sprintf("句子裡要用到「%s」喔！", char))
+	}
+	repeatFeedback := fmt.Sprintf("不要只重複題目，試著用「%s」說一件事，例如誰在什麼地方做什麼。", char)
+	if text == char || (input.Prompt != "" && strings.Contains(sentenceText(input.Prompt), text)) {
+		return reject(repeatFeedback)
+	}
+	for _, previous := range input.Previous {
+		if sentenceText(previous) == text {
+			return reject("這個句子前面已經寫過了，換一個新的句子吧！")
+		}
+	}
+	length := len([]rune(text))
+	if length < minSentenceLength {
+		return reject(fmt.Sprintf("句子太短了（%d 個字），至少寫 %d 個字，加上誰、在哪裡或做什麼會更完整。", length, minSentenceLength))
+	}
+	if distinctRunes(text) <= 2 {
+		return reject(repeatFeedback)
+	}
+
+	// 基本分 60，句子越完整越高（最多 20），已學字比例越高越高（最多 20）
+	score := 60
+	lengthBonus := (length - minSentenceLength + 1) * 4
+	if lengthBonus > 20 {
+		lengthBonus = 20
+	}
+	score += lengthBonus
+
+	var tips []string
+	if len(input.Learned) > 0 {
+		learned := make(map[string]bool, len(input.Learned))
+		for _, c := range input.Learned {
+			learned[c] = true
+		}
+		hanCount, learnedCount := 0, 0
+		var unlearned []string
+		for _, r := range text {
+			if !unicode.Is(unicode.Han, r) {
+				continue
+			}
+			hanCount++
+			if c := string(r); learned[c] || c == char {
+				learnedCount++
+			} else if !containsString(unlearned, c) {
+				unlearned = append(unlearned, c)
+			}
+		}
+		if hanCount > 0 {
+			score += learnedCount * 20 / hanCount
+		}
+		if len(unlearned) > 0 {
+			tips = append(tips, fmt.Sprintf("用到還沒學過的字：%s，記得查查看有沒有寫對。", strings.Join(unlearned, "、")))
+		}
+	} else {
+		score += 10
+	}
+
+	if length < 8 {
+		tips = append(tips, "可以再加上時間、地點或心情，讓句子更生動。")
+	}
+	if !strings.ContainsAny(strings.TrimSpace(input.Sentence), "。！？!?") {
+		tips = append(tips, "句子最後記得加上標點符號，例如「。」。")
+	}
+
+	feedback := fmt.Sprintf("很棒的句子！你成功使用了「%s」這個字。（%d 分）", char, score)
+	if len(tips) > 0 {
+		feedback += "\n💡 " + strings.Join(tips, "\n💡 ")
+	}
+	return &SentenceEvaluation{Accepted: true, Score: score, Feedback: feedback}, nil
+}
+
+// 去除空白與標點，只留下句子的文字
+func sentenceText(sentence string) string {
+	var b strings.Builder
+	for _, r := range sentence {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// 不同的字數，用來判斷是否只是同一兩個字一直重複
+func distinctRunes(text string) int {
+	distinct := make(map[rune]bool)
+	for _, r := range text {
+		distinct[r] = true
+	}
+	return len(distinct)
+}
+
+// HTTPSentenceScorer 將通過規則檢查的句子送到評分服務（例如本地模型）取得分數與建議
+// 服務以 POST JSON 接收 {"character","sentence"}，回傳 {"accepted","score","feedback"}
+type HTTPSentenceScorer struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSentenceScorer 建立以 HTTP 呼叫評分服務的評分器
+func NewHTTPSentenceScorer(url string) *HTTPSentenceScorer {
+	return &HTTPSentenceScorer{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Evaluate 先以規則檢查，通過後再由評分服務評分
+func (s *HTTPSentenceScorer) Evaluate(input SentenceInput) (*SentenceEvaluation, error) {
+	evaluation, _ := RuleSentenceScorer{}.Evaluate(input)
+	if !evaluation.Accepted {
+		return evaluation, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"character": input.Character, "sentence": input.Sentence})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("sentence scorer request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sentence scorer returned status %d", resp.StatusCode)
+	}
+
+	var result SentenceEvaluation
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid sentence scorer response: %w", err)
+	}
+	if result.Feedback == "" {
+		result.Feedback = evaluation.Feedback
+	}
+	return &result, nil
+}
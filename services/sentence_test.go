@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+func TestRuleSentenceScorerRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    SentenceInput
+		feedback string
+	}{
+		{"missing character", SentenceInput{Character: "森", Sentence: "我喜歡去公園玩。"}, "要用到「森」"},
+		{"prompt only", SentenceInput{Character: "森", Sentence: "森"}, "不要只重複題目"},
+		{"copies the prompt", SentenceInput{Character: "森", Prompt: "請用「森」造句：", Sentence: "用森造句"}, "不要只重複題目"},
+		{"copies the whole prompt", SentenceInput{Character: "森", Prompt: "請用「森」造句：", Sentence: "請用「森」造句。"}, "不要只重複題目"},
+		{"duplicate", SentenceInput{Character: "森", Sentence: "我們去森林散步。", Previous: []string{"我們去森林散步"}}, "前面已經寫過"},
+		{"too short", SentenceInput{Character: "書", Sentence: "看書"}, "句子太短了"},
+		{"repeated characters", SentenceInput{Character: "森", Sentence: "森森森森森森"}, "不要只重複題目"},
+	}
+
+	for _, tt := range tests {
+		evaluation := EvaluateSentence(tt.input)
+		if evaluation.Accepted || !strings.Contains(evaluation.Feedback, tt.feedback) {
+			t.Errorf("%s: got %+v, want rejection containing %q", tt.name, evaluation, tt.feedback)
+		}
+	}
+}
+
+func TestRuleSentenceScorerScores(t *testing.T) {
+	// 句子越長、已學字比例越高，分數越高
+	short := EvaluateSentence(SentenceInput{Character: "森", Sentence: "我去森林玩。", Learned: []string{"我", "去", "林"}})
+	long := EvaluateSentence(SentenceInput{Character: "森", Sentence: "星期天我和爸爸一起去森林裡散步。", Learned: []string{"我", "去", "林"}})
+	allLearned := EvaluateSentence(SentenceInput{Character: "森", Sentence: "我去森林玩。", Learned: []string{"我", "去", "林", "玩"}})
+
+	for _, evaluation := range []*SentenceEvaluation{short, long, allLearned} {
+		if !evaluation.Accepted || evaluation.Score < 60 || evaluation.Score > 100 {
+			t.Fatalf("unexpected evaluation %+v", evaluation)
+		}
+	}
+	if allLearned.Score <= short.Score || long.Score <= short.Score {
+		t.Errorf("scores short=%d long=%d allLearned=%d, want short lowest", short.Score, long.Score, allLearned.Score)
+	}
+	if !strings.Contains(long.Feedback, "還沒學過的字") {
+		t.Errorf("feedback should list unlearned characters: %q", long.Feedback)
+	}
+	if strings.Contains(long.Feedback, "標點符號") || !strings.Contains(
+		EvaluateSentence(SentenceInput{Character: "森", Sentence: "我們去森林散步"}).Feedback, "標點符號") {
+		t.Errorf("punctuation tip should only appear when the sentence has no ending mark")
+	}
+}
+
+type failingScorer struct{}
+
+func (failingScorer) Evaluate(input SentenceInput) (*SentenceEvaluation, error) {
+	return nil, errors.New("scorer unavailable")
+}
+
+func TestEvaluateSentenceFallsBackToRules(t *testing.T) {
+	SetSentenceScorer(failingScorer{})
+	defer SetSentenceScorer(nil)
+
+	evaluation := EvaluateSentence(SentenceInput{Character: "森", Sentence: "我們去森林散步。"})
+	if evaluation == nil || !evaluation.Accepted {
+		t.Errorf("fallback evaluation = %+v, want accepted", evaluation)
+	}
+}
+
+func TestRuleSentenceScorerAcceptsSentencesAboutWriting(t *testing.T) {
+	// 句子裡提到「造句」不是照抄題目
+	evaluation := EvaluateSentence(SentenceInput{Character: "學", Prompt: "請用「學」造句：", Sentence: "我今天學造句。"})
+	if !evaluation.Accepted {
+		t.Errorf("evaluation = %+v, want accepted", evaluation)
+	}
+}
+
+func TestCheckSentenceAnswerUsesSession(t *testing.T) {
+	question := &models.PracticeQuestion{Type: string(models.PracticeTypeSentence), Character: "森", Question: "請用「森」造句："}
+	session := &models.PracticeSession{
+		Learned: []string{"我", "們", "去", "林", "散", "步"},
+		Answers: []models.PracticeAnswer{{QuestionType: question.Type, UserAnswer: "我們去森林散步。"}},
+	}
+
+	// 沒有會話時只看句子本身
+	if isCorrect, _ := checkAnswer(question, "我們去森林散步", nil); !isCorrect {
+		t.Errorf("sentence without a session was rejected")
+	}
+	if isCorrect, feedback := checkAnswer(question, "我們去森林散步", session); isCorrect || !strings.Contains(feedback, "前面已經寫過") {
+		t.Errorf("repeated sentence in a session: %v, %q", isCorrect, feedback)
+	}
+	if isCorrect, feedback := checkAnswer(question, "用森造句", session); isCorrect || !strings.Contains(feedback, "不要只重複題目") {
+		t.Errorf("copied prompt: %v, %q", isCorrect, feedback)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/utils"
+)
+
+// 筆順圖片路徑（由 Gin 伺服器提供），:char 為字、:file 為圖片種類
+// 除了分解圖與動畫，練習題的圖片參數也編碼在檔名中：
+// highlight-3.png（標示第 3 筆）、next-2-3_5_4.png（已寫 2 筆，候選第 3、5、4 筆）
+const (
+	StrokeImagePath     = "/strokes/:char/:file"
+	StrokeStepsFile     = "steps.png"
+	StrokeAnimationFile = "animation.gif"
+)
+
+// MaxNextStrokeChoices 「下一筆是哪一個」題目最多的候選圖數
+const MaxNextStrokeChoices = 4
+
+// ErrStrokeImageNotFound 沒有此字的筆順資料或圖片種類不存在
+var ErrStrokeImageNotFound = errors.New("stroke image not found")
+
+var (
+	strokeHighlightFilePattern = regexp.MustCompile(`^highlight-(\d+)\.png$`)
+	strokeNextFilePattern      = regexp.MustCompile(`^next-(\d+)-(\d+(?:_\d+)*)\.png$`)
+)
+
+// 筆順資料集（選用），由 LoadStrokeDataset 載入後供所有查詢共用
+var (
+	strokeDatasetMu sync.RWMutex
+	strokeDataset   map[string]*models.StrokeData
+)
+
+// LoadStrokeDataset 載入 Make Me a Hanzi 格式的筆順資料（graphics.txt，每行一個 JSON），回傳載入的字數
+func LoadStrokeDataset(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open stroke dataset: %w", err)
+	}
+	defer file.Close()
+
+	dataset := make(map[string]*models.StrokeData)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var data models.StrokeData
+		if err := json.Unmarshal([]byte(line), &data); err != nil {
+			return 0, fmt.Errorf("invalid stroke data on line %d: %w", lineNumber, err)
+		}
+		if data.Character == "" || data.StrokeCount() == 0 {
+			continue
+		}
+		dataset[data.Character] = &data
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read stroke dataset: %w", err)
+	}
+
+	strokeDatasetMu.Lock()
+	strokeDataset = dataset
+	strokeDatasetMu.Unlock()
+	return len(dataset), nil
+}
+
+// 從已載入的筆順資料集查詢，沒有時回傳 nil
+func lookupStrokeDataset(char string) *models.StrokeData {
+	strokeDatasetMu.RLock()
+	defer strokeDatasetMu.RUnlock()
+	return strokeDataset[char]
+}
+
+// StrokeService 提供筆順資料與筆順圖片網址
+type StrokeService struct {
+	baseURL string
+}
+
+// NewStrokeService 建立筆順服務，圖片網址以 PUBLIC_BASE_URL 為開頭
+func NewStrokeService() *StrokeService {
+	return &StrokeService{
+		baseURL: strings.TrimSuffix(os.Getenv("PUBLIC_BASE_URL"), "/"),
+	}
+}
+
+// GetStrokeData 從筆順資料集取得字的筆順資料，沒有時回傳 nil
+func (s *StrokeService) GetStrokeData(char string) *models.StrokeData {
+	return lookupStrokeDataset(char)
+}
+
+// ImageURL 筆順圖片的對外網址；未設定 PUBLIC_BASE_URL 時回傳空字串
+func (s *StrokeService) ImageURL(char string, file string) string {
+	if s.baseURL == "" {
+		return ""
+	}
+	return s.baseURL + "/strokes/" + url.PathEscape(char) + "/" + file
+}
+
+// HighlightImageURL 標示第 stroke 筆（從 1 開始）的圖片網址
+func (s *StrokeService) HighlightImageURL(char string, stroke int) string {
+	return s.ImageURL(char, fmt.Sprintf("highlight-%d.png", stroke))
+}
+
+// NextStrokeImageURL 「下一筆是哪一個」選項圖的網址，written 為已寫筆數，candidates 為候選筆畫（從 1 開始）
+func (s *StrokeService) NextStrokeImageURL(char string, written int, candidates []int) string {
+	var parts []string
+	for _, candidate := range candidates {
+		parts = append(parts, strconv.Itoa(candidate))
+	}
+	return s.ImageURL(char, fmt.Sprintf("next-%d-%s.png", written, strings.Join(parts, "_")))
+}
+
+// RenderImage 依檔名產生筆順圖片，回傳圖片內容與 Content-Type
+func (s *StrokeService) RenderImage(char string, file string) ([]byte, string, error) {
+	data := s.GetStrokeData(char)
+	if data == nil {
+		return nil, "", ErrStrokeImageNotFound
+	}
+
+	switch {
+	case file == StrokeStepsFile:
+		image, err := utils.RenderStrokeStepsPNG(data)
+		return image, "image/png", err
+	case file == StrokeAnimationFile:
+		image, err := utils.RenderStrokeAnimationGIF(data)
+		return image, "image/gif", err
+	case strokeHighlightFilePattern.MatchString(file):
+		stroke, _ := strconv.Atoi(strokeHighlightFilePattern.FindStringSubmatch(file)[1])
+		image, err := utils.RenderStrokeHighlightPNG(data, stroke-1)
+		return image, "image/png", err
+	case strokeNextFilePattern.MatchString(file):
+		m := strokeNextFilePattern.FindStringSubmatch(file)
+		written, _ := strconv.Atoi(m[1])
+		var candidates []int
+		for _, part := range strings.Split(m[2], "_") {
+			candidate, _ := strconv.Atoi(part)
+			candidates = append(candidates, candidate-1)
+		}
+		if len(candidates) > MaxNextStrokeChoices {
+			return nil, "", ErrStrokeImageNotFound
+		}
+		image, err := utils.RenderNextStrokeChoicesPNG(data, written, candidates)
+		return image, "image/png", err
+	default:
+		return nil, "", ErrStrokeImageNotFound
+	}
+}
+
+// StrokeCountOf 字的筆畫數：有筆順資料時以筆順資料為準，與字詞資料不一致時記錄警告
+func StrokeCountOf(char *models.CharacterInfo) int {
+	data := char.StrokeOrder
+	if data == nil {
+		data = lookupStrokeDataset(char.Character)
+	}
+	if data == nil || data.StrokeCount() == 0 {
+		return char.StrokeCount
+	}
+	if char.StrokeCount > 0 && char.StrokeCount != data.StrokeCount() {
+		log.Printf("Stroke count mismatch for %s: %d in character data, %d in stroke data", char.Character, char.StrokeCount, data.StrokeCount())
+	}
+	return data.StrokeCount()
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+)
+
+const subscriptionCollection = "subscriptions"
+
+type SubscriptionService struct {
+	firebaseClient *config.FirebaseClient
+}
+
+func NewSubscriptionService(firebaseClient *config.FirebaseClient) *SubscriptionService {
+	return &SubscriptionService{
+		firebaseClient: firebaseClient,
+	}
+}
+
+// GetSubscription 取得用戶訂閱設定（不存在時回傳預設值）
+func (s *SubscriptionService) GetSubscription(userID string) (*models.Subscription, error) {
+	doc, err := s.firebaseClient.Firestore.Collection(subscriptionCollection).Doc(userID).Get(s.firebaseClient.Ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return newSubscription(userID), nil
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	subscription := newSubscription(userID)
+	if err := doc.DataTo(subscription); err != nil {
+		return nil, fmt.Errorf("failed to parse subscription: %v", err)
+	}
+	subscription.UserID = userID
+
+	return subscription, nil
+}
+
+// SaveSubscription 儲存用戶訂閱設定
+func (s *SubscriptionService) SaveSubscription(subscription *models.Subscription) error {
+	now := time.Now().Unix()
+	if subscription.CreatedAt == 0 {
+		subscription.CreatedAt = now
+	}
+	subscription.UpdatedAt = now
+
+	_, err := s.firebaseClient.Firestore.Collection(subscriptionCollection).Doc(subscription.UserID).Set(s.firebaseClient.Ctx, subscription)
+	if err != nil {
+		return fmt.Errorf("failed to save subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription 刪除用戶訂閱設定（封鎖或取消好友時使用）
+func (s *SubscriptionService) DeleteSubscription(userID string) error {
+	_, err := s.firebaseClient.Firestore.Collection(subscriptionCollection).Doc(userID).Delete(s.firebaseClient.Ctx)
+	return err
+}
+
+// ListSubscribers 列出指定布林欄位為 true 的用戶（field 為 dailyCharacter、reviewReminder、weeklyReport 或 pendingDaily）
+func (s *SubscriptionService) ListSubscribers(field string) ([]*models.Subscription, error) {
+	docs, err := s.firebaseClient.Firestore.Collection(subscriptionCollection).
+		Where(field, "==", true).
+		Documents(s.firebaseClient.Ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscribers: %w", err)
+	}
+
+	var subscriptions []*models.Subscription
+	for _, doc := range docs {
+		subscription := newSubscription(doc.Ref.ID)
+		if err := doc.DataTo(subscription); err != nil {
+			continue
+		}
+		subscription.UserID = doc.Ref.ID
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// MarkDailyDelivered 記錄每日一字推播完成
+func (s *SubscriptionService) MarkDailyDelivered(userID string, date string, character string) error {
+	_, err := s.firebaseClient.Firestore.Collection(subscriptionCollection).Doc(userID).Update(s.firebaseClient.Ctx, []firestore.Update{
+		{Path: "pendingDaily", Value: false},
+		{Path: "lastDailyDate", Value: date},
+		{Path: "pushedChars", Value: firestore.ArrayUnion(character)},
+		{Path: "updatedAt", Value: time.Now().Unix()},
+	})
+	return err
+}
+
+// MarkDailyPending 標記每日一字因勿擾時段延後
+func (s *SubscriptionService) MarkDailyPending(userID string) error {
+	_, err := s.firebaseClient.Firestore.Collection(subscriptionCollection).Doc(userID).Update(s.firebaseClient.Ctx, []firestore.Update{
+		{Path: "pendingDaily", Value: true},
+		{Path: "updatedAt", Value: time.Now().Unix()},
+	})
+	return err
+}
+
+func newSubscription(userID string) *models.Subscription {
+	return &models.Subscription{
+		UserID:     userID,
+		QuietStart: -1,
+		QuietEnd:   -1,
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"chinese-learning-linebot/config"
+	"chinese-learning-linebot/models"
+	"chinese-learning-linebot/utils"
+)
+
+// 字帖下載路徑（由 Gin 伺服器提供）
+const WorksheetDownloadPath = "/worksheets/download"
+
+// 一份字帖最多的字數（避免網址與 PDF 過大）
+const MaxWorksheetCharacters = 120
+
+// 預設下載網址有效時間
+const defaultWorksheetURLExpiry = 24 * time.Hour
+
+// WorksheetService 產生字帖 PDF，並以 HMAC 簽章的到期網址提供下載
+// 字帖內容完全編碼在網址中，伺服器不需要保存檔案
+type WorksheetService struct {
+	firebaseClient *config.FirebaseClient
+	baseURL        string
+	secret         []byte
+	expiry         time.Duration
+}
+
+// NewWorksheetService 從環境變數讀取設定：
+// PUBLIC_BASE_URL（伺服器對外網址）、WORKSHEET_SIGNING_SECRET（專用的簽章金鑰，不可與 LINE_CHANNEL_SECRET 共用）、
+// WORKSHEET_URL_EXPIRE_MINUTES（下載網址有效分鐘數）
+func NewWorksheetService(firebaseClient *config.FirebaseClient) *WorksheetService {
+	secret := os.Getenv("WORKSHEET_SIGNING_SECRET")
+	expiry := defaultWorksheetURLExpiry
+	if minutes, err := strconv.Atoi(os.Getenv("WORKSHEET_URL_EXPIRE_MINUTES")); err == nil && minutes > 0 {
+		expiry = time.Duration(minutes) * time.Minute
+	}
+	return &WorksheetService{
+		firebaseClient: firebaseClient,
+		baseURL:        strings.TrimSuffix(os.Getenv("PUBLIC_BASE_URL"), "/"),
+		secret:         []byte(secret),
+		expiry:         expiry,
+	}
+}
+
+// Enabled 是否已設定對外網址與簽章金鑰；未設定時改用外部字帖網站
+func (s *WorksheetService) Enabled() bool {
+	return s.baseURL != "" && len(s.secret) > 0
+}
+
+// Expiry 下載網址有效時間
+func (s *WorksheetService) Expiry() time.Duration {
+	return s.expiry
+}
+
+// SignedURL 產生帶有簽章與到期時間的下載網址
+func (s *WorksheetService) SignedURL(request *models.WorksheetRequest) (string, error) {
+	if !s.Enabled() {
+		return "", fmt.Errorf("worksheet download is not configured")
+	}
+	if len(request.Characters) == 0 {
+		return "", fmt.Errorf("worksheet has no characters")
+	}
+	if len(request.Characters) > MaxWorksheetCharacters {
+		return "", fmt.Errorf("too many characters: %d (max %d)", len(request.Characters), MaxWorksheetCharacters)
+	}
+	if request.ExpiresAt == 0 {
+		request.ExpiresAt = time.Now().Add(s.expiry).Unix()
+	}
+
+	values := encodeWorksheetRequest(request)
+	values.Set("sig", s.sign(values))
+	return s.baseURL + WorksheetDownloadPath + "?" + values.Encode(), nil
+}
+
+// VerifyRequest 驗證下載網址的簽章與到期時間，並還原字帖內容
+func (s *WorksheetService) VerifyRequest(query url.Values) (*models.WorksheetRequest, error) {
+	if len(s.secret) == 0 {
+		return nil, fmt.Errorf("worksheet signing secret is not configured")
+	}
+
+	values := url.Values{}
+	for key, value := range query {
+		if key != "sig" {
+			values[key] = value
+		}
+	}
+	signature := query.Get("sig")
+	if signature == "" || !hmac.Equal([]byte(signature), []byte(s.sign(values))) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	request, err := decodeWorksheetRequest(values)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() > request.ExpiresAt {
+		return nil, fmt.Errorf("link expired")
+	}
+	return request, nil
+}
+
+// Render 查出每個字的注音並產生 PDF
+func (s *WorksheetService) Render(request *models.WorksheetRequest) ([]byte, error) {
+	phonetics := make(map[string]string)
+	if request.Options.Zhuyin && s.firebaseClient != nil {
+		characters, err := NewCharacterService(s.firebaseClient).GetCharacters(uniqueStrings(request.Characters))
+		if err != nil {
+			return nil, err
+		}
+		for char, info := range characters {
+			phonetics[char] = info.Phonetic
+		}
+	}
+
+	entries := make([]models.WorksheetEntry, 0, len(request.Characters))
+	for _, char := range request.Characters {
+		entries = append(entries, models.WorksheetEntry{Character: char, Phonetic: phonetics[char]})
+	}
+	return utils.RenderWorksheetPDF(request.Title, entries, request.Options)
+}
+
+func (s *WorksheetService) sign(values url.Values) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(values.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// 網址參數：c 字、t 標題、g 格線、n 描紅格數、z 注音、e 到期時間
+func encodeWorksheetRequest(request *models.WorksheetRequest) url.Values {
+	values := url.Values{}
+	values.Set("c", strings.Join(request.Characters, ""))
+	values.Set("t", request.Title)
+	values.Set("g", request.Options.Grid)
+	values.Set("n", strconv.Itoa(request.Options.TraceCells))
+	if request.Options.Zhuyin {
+		values.Set("z", "1")
+	} else {
+		values.Set("z", "0")
+	}
+	values.Set("e", strconv.FormatInt(request.ExpiresAt, 10))
+	return values
+}
+
+func decodeWorksheetRequest(values url.Values) (*models.WorksheetRequest, error) {
+	expiresAt, err := strconv.ParseInt(values.Get("e"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiry: %v", err)
+	}
+	traceCells, err := strconv.Atoi(values.Get("n"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace cells: %v", err)
+	}
+
+	var characters []string
+	for _, r := range values.Get("c") {
+		characters = append(characters, string(r))
+	}
+	if len(characters) == 0 || len(characters) > MaxWorksheetCharacters {
+		return nil, fmt.Errorf("invalid character count: %d", len(characters))
+	}
+
+	return &models.WorksheetRequest{
+		Title:      values.Get("t"),
+		Characters: characters,
+		Options: models.WorksheetOptions{
+			Grid:       values.Get("g"),
+			TraceCells: traceCells,
+			Zhuyin:     values.Get("z") == "1",
+		},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,152 @@
+package utils
+
+// 簡體字轉繁體字（台灣用字）
+// 每兩個字為一組「簡繁」；只收錄不會出現在繁體文字中的簡體字，遇到就一定轉換
+var simplifiedPairs = "" +
+	"爱愛碍礙袄襖坝壩罢罷摆擺败敗办辦帮幫绑綁宝寶饱飽报報贝貝备備笔筆币幣毕畢边邊编編变變标標别別宾賓饼餅补補" +
+	"蚕蠶参參残殘惭慚惨慘灿燦仓倉舱艙层層产產尝嘗长長场場车車彻徹尘塵陈陳衬襯称稱惩懲诚誠迟遲齿齒筹籌处處础礎" +
+	"触觸传傳闯闖创創锤錘纯純词詞辞辭聪聰从從丛叢错錯达達带帶担擔单單胆膽弹彈当當挡擋导導岛島灯燈邓鄧敌敵递遞" +
+	"点點电電垫墊钓釣调調叠疊顶頂订訂东東冻凍动動栋棟独獨读讀赌賭断斷锻鍛队隊对對吨噸夺奪鹅鵝额額恶惡尔爾饿餓" +
+	"发發罚罰阀閥饭飯访訪纺紡飞飛废廢费費纷紛坟墳奋奮愤憤粪糞风風疯瘋锋鋒凤鳳肤膚辅輔妇婦复復负負该該盖蓋冈岡" +
+	"刚剛钢鋼纲綱岗崗个個给給宫宮巩鞏贡貢沟溝构構购購够夠顾顧关關观觀馆館惯慣贯貫归歸龟龜规規轨軌贵貴柜櫃滚滾" +
+	"锅鍋国國过過还還汉漢贺賀红紅壶壺护護沪滬华華画畫话話怀懷欢歡环環换換唤喚黄黃挥揮辉輝汇匯会會绘繪贿賄浑渾" +
+	"获獲货貨祸禍击擊积積饥飢鸡雞极極级級挤擠纪紀际際剂劑济濟继繼迹跡计計记記驾駕坚堅间間监監检檢俭儉减減简簡" +
+	"见見舰艦剑劍鉴鑑践踐键鍵渐漸将將奖獎讲講酱醬胶膠骄驕娇嬌饺餃较較轿轎阶階节節洁潔结結紧緊仅僅尽盡进進劲勁" +
+	"惊驚经經竞競静靜镜鏡旧舊举舉剧劇惧懼觉覺绝絕军軍开開凯凱颗顆壳殼课課垦墾恳懇库庫裤褲块塊宽寬矿礦亏虧扩擴" +
+	"阔闊来來兰蘭拦攔栏欄蓝藍篮籃览覽懒懶烂爛滥濫劳勞乐樂类類泪淚离離礼禮历歷厉厲励勵丽麗连連联聯恋戀莲蓮脸臉" +
+	"练練炼煉粮糧两兩辆輛谅諒疗療辽遼猎獵临臨邻鄰铃鈴灵靈岭嶺领領刘劉龙龍笼籠楼樓卢盧芦蘆炉爐陆陸录錄虏虜驴驢" +
+	"铝鋁虑慮滤濾绿綠乱亂轮輪论論罗羅逻邏锣鑼骡騾络絡妈媽马馬吗嗎码碼买買卖賣麦麥脉脈满滿猫貓贸貿没沒门門们們" +
+	"梦夢弥彌眯瞇绵綿庙廟灭滅鸣鳴铭銘谋謀亩畝闹鬧呐吶难難脑腦恼惱拟擬鸟鳥农農浓濃钮鈕脓膿纽紐诺諾欧歐盘盤赔賠" +
+	"喷噴鹏鵬骗騙飘飄贫貧频頻评評泼潑扑撲铺鋪谱譜齐齊骑騎岂豈启啟弃棄铅鉛迁遷签簽钱錢浅淺谴譴枪槍墙牆抢搶桥橋" +
+	"乔喬侨僑窍竅亲親轻輕倾傾庆慶穷窮琼瓊区區驱驅躯軀趋趨权權劝勸确確让讓扰擾热熱认認荣榮软軟锐銳润潤伞傘丧喪" +
+	"扫掃杀殺纱紗晒曬闪閃伤傷赏賞烧燒绍紹设設摄攝绅紳审審婶嬸肾腎渗滲声聲绳繩圣聖师師诗詩狮獅湿濕时時实實识識" +
+	"势勢释釋试試视視饰飾寿壽兽獸书書输輸树樹数數帅帥双雙谁誰税稅顺順说說硕碩丝絲饲飼耸聳颂頌诉訴肃肅虽雖随隨" +
+	"岁歲孙孫损損笋筍缩縮锁鎖态態摊攤滩灘坛壇谈談叹嘆汤湯烫燙涛濤讨討腾騰誊謄题題体體条條铁鐵厅廳铜銅统統头頭" +
+	"图圖团團颓頹蜕蛻驮馱袜襪弯彎湾灣顽頑为為违違围圍韦韋纬緯伟偉卫衛稳穩问問窝窩卧臥乌烏无無误誤务務雾霧牺犧" +
+	"习習戏戲细細虾蝦吓嚇峡峽狭狹鲜鮮闲閒贤賢显顯险險现現献獻县縣线線宪憲乡鄉详詳响響项項萧蕭销銷晓曉协協胁脅" +
+	"写寫泻瀉谢謝兴興选選学學寻尋训訓讯訊逊遜压壓鸦鴉鸭鴨哑啞亚亞讶訝烟煙盐鹽严嚴颜顏艳艷验驗阳陽养養样樣痒癢" +
+	"杨楊药藥爷爺页頁业業医醫仪儀遗遺亿億忆憶义義艺藝议議异異译譯阴陰银銀饮飲隐隱应應营營赢贏拥擁涌湧优優忧憂" +
+	"邮郵犹猶鱼魚渔漁与與语語屿嶼预預狱獄员員园園圆圓远遠愿願约約跃躍阅閱运運晕暈杂雜灾災载載赞讚凿鑿枣棗责責" +
+	"则則贼賊赠贈闸閘诈詐债債毡氈战戰张張涨漲帐帳账賬胀脹赵趙这這针針侦偵诊診阵陣镇鎮争爭睁睜挣掙证證郑鄭织織" +
+	"职職执執纸紙质質钟鐘众眾肿腫轴軸昼晝皱皺猪豬烛燭嘱囑铸鑄驻駐专專砖磚转轉赚賺装裝壮壯状狀妆妝浊濁资資总總" +
+	"纵縱踪蹤邹鄒组組钻鑽请請闭閉闻聞须須韩韓鲁魯鲸鯨鹰鷹鸽鴿鹤鶴鸿鴻谜謎讽諷诞誕诵誦诱誘谊誼谦謙谨謹谓謂诸諸"
+
+// 兩岸都會用到的字（包括人名、部首用字）：只有在文字中已出現其他簡體字時才轉換，避免把繁體的「皇后」、「周杰倫」、「拮据」轉錯
+var ambiguousPairs = "" +
+	"后後干乾几幾里裡云雲余餘范範冲衝准準斗鬥占佔朴樸咸鹹丑醜划劃伙夥杰傑佣傭夸誇据據挂掛涂塗么麼叶葉适適丰豐" +
+	"虫蟲厂廠广廣儿兒气氣网網胜勝种種党黨万萬价價术術蜡蠟腊臘帘簾怜憐筑築凭憑洒灑听聽宁寧赶趕坏壞号號机機苹蘋"
+
+// 簡體輸入時需要整個詞一起轉換的例外（多對一的字）
+var simplifiedPhrases = map[string]string{
+	"头发": "頭髮", "理发": "理髮", "白发": "白髮", "发型": "髮型", "洗发": "洗髮",
+	"皇后": "皇后", "王后": "王后", "太后": "太后",
+	"干部": "幹部", "能干": "能幹", "干活": "幹活", "干什么": "幹什麼", "树干": "樹幹", "干扰": "干擾", "干涉": "干涉",
+	"茶几": "茶几",
+	"公里": "公里", "千里": "千里", "里程": "里程",
+	"面条": "麵條", "面包": "麵包", "面粉": "麵粉", "拉面": "拉麵",
+	"一只": "一隻", "两只": "兩隻", "三只": "三隻", "几只": "幾隻", "这只": "這隻", "那只": "那隻", "每只": "每隻",
+	"台风": "颱風", "刮风": "颳風", "刮大风": "颳大風", "刮台风": "颳颱風",
+	"复习": "複習", "重复": "重複", "复杂": "複雜", "复制": "複製", "复印": "複印", "回复": "回覆",
+	"日历": "日曆", "农历": "農曆", "历法": "曆法",
+	"关系": "關係", "联系": "聯繫", "系鞋带": "繫鞋帶",
+	"收获": "收穫",
+	"轻松": "輕鬆", "放松": "放鬆", "松开": "鬆開",
+	"冲洗": "沖洗", "冲水": "沖水",
+	"稻谷": "稻穀", "谷物": "穀物", "五谷": "五穀",
+	"旅游": "旅遊", "游戏": "遊戲", "游客": "遊客", "游览": "遊覽",
+	"周末": "週末", "一周": "一週", "每周": "每週", "周记": "週記", "周年": "週年",
+	"心脏": "心臟", "肝脏": "肝臟", "内脏": "內臟", "肮脏": "骯髒",
+	"特征": "特徵", "象征": "象徵",
+	"制作": "製作", "制造": "製造",
+	"批准": "批准", "不准": "不准", "准许": "准許",
+	"卷起": "捲起",
+	"北斗": "北斗", "漏斗": "漏斗", "斗笠": "斗笠", "熨斗": "熨斗",
+	"手表": "手錶", "老板": "老闆",
+	"胡子": "鬍子", "胡须": "鬍鬚",
+	"标签": "標籤", "牙签": "牙籤",
+	"舍不得": "捨不得", "舍得": "捨得", "取舍": "取捨",
+	"小丑": "小丑", "划船": "划船", "伙食": "伙食",
+	"占卜": "占卜",
+}
+
+// 異體字轉為台灣教育部標準字，不論是否為簡體輸入都轉換
+var variantPairs = "" +
+	"着著裏裡綫線峯峰羣群衞衛爲為眞真敎教晩晚册冊却卻户戶脚腳凉涼况況减減够夠污汙麪麵綉繡牀床吿告淸清靑青説說" +
+	"兑兌内內吕呂温溫强強録錄徳德値值"
+
+var (
+	simplifiedTable     = buildPairTable(simplifiedPairs)
+	ambiguousTable      = buildPairTable(ambiguousPairs)
+	variantTable        = buildPairTable(variantPairs)
+	maxSimplifiedPhrase = maxPhraseLength(simplifiedPhrases)
+)
+
+func buildPairTable(pairs string) map[rune]rune {
+	runes := []rune(pairs)
+	table := make(map[rune]rune, len(runes)/2)
+	for i := 0; i+1 < len(runes); i += 2 {
+		table[runes[i]] = runes[i+1]
+	}
+	return table
+}
+
+func maxPhraseLength(phrases map[string]string) int {
+	maxLength := 0
+	for phrase := range phrases {
+		if length := len([]rune(phrase)); length > maxLength {
+			maxLength = length
+		}
+	}
+	return maxLength
+}
+
+// NormalizeToTraditional 將簡體字與異體字轉為繁體（台灣用字），回傳轉換後的文字及是否有變動
+// 文字中含有簡體字時，才會一併轉換「后」、「里」等兩岸通用的字及多對一的詞
+func NormalizeToTraditional(text string) (string, bool) {
+	runes := []rune(text)
+	simplifiedInput := false
+	for _, r := range runes {
+		if _, ok := simplifiedTable[r]; ok {
+			simplifiedInput = true
+			break
+		}
+	}
+
+	result := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); {
+		if simplifiedInput {
+			if phrase, length := matchSimplifiedPhrase(runes[i:]); length > 0 {
+				result = append(result, []rune(phrase)...)
+				i += length
+				continue
+			}
+		}
+
+		r := runes[i]
+		if mapped, ok := simplifiedTable[r]; ok {
+			r = mapped
+		} else if mapped, ok := variantTable[r]; ok {
+			r = mapped
+		} else if mapped, ok := ambiguousTable[r]; ok && simplifiedInput {
+			r = mapped
+		}
+		result = append(result, r)
+		i++
+	}
+
+	converted := string(result)
+	return converted, converted != text
+}
+
+// 從開頭找出最長的例外詞
+func matchSimplifiedPhrase(runes []rune) (string, int) {
+	for length := maxSimplifiedPhrase; length >= 2; length-- {
+		if length > len(runes) {
+			continue
+		}
+		if phrase, ok := simplifiedPhrases[string(runes[:length])]; ok {
+			return phrase, length
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,60 @@
+package utils
+
+import "testing"
+
+func TestNormalizeToTraditional(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		changed bool
+	}{
+		{"喜欢", "喜歡", true},
+		{"我们一起去公园", "我們一起去公園", true},
+		{"头发很长", "頭髮很長", true},
+		{"今天刮大风", "今天颳大風", true},
+		// 含有簡體字時，兩岸通用的字一併轉換
+		{"杰出的设计", "傑出的設計", true},
+		{"皇后来了", "皇后來了", true},
+		// 異體字不論是否為簡體輸入都轉換
+		{"看着裏面", "看著裡面", true},
+
+		// 繁體文字不應被改寫
+		{"周杰倫", "周杰倫", false},
+		{"佣金", "佣金", false},
+		{"夸父追日", "夸父追日", false},
+		{"手頭拮据", "手頭拮据", false},
+		{"范老師的紫色筆", "范老師的紫色筆", false},
+		{"鹿港的里長", "鹿港的里長", false},
+		{"刮鬍子", "刮鬍子", false},
+		{"虫部的字", "虫部的字", false},
+		{"森林", "森林", false},
+	}
+
+	for _, tt := range tests {
+		got, changed := NormalizeToTraditional(tt.text)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("NormalizeToTraditional(%q) = %q, %v; want %q, %v", tt.text, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestSimplifiedPairsAreWellFormed(t *testing.T) {
+	seen := make(map[rune]bool)
+	runes := []rune(simplifiedPairs)
+	if len(runes)%2 != 0 {
+		t.Fatalf("simplifiedPairs has an odd number of characters")
+	}
+	for i := 0; i < len(runes); i += 2 {
+		simplified, traditional := runes[i], runes[i+1]
+		if simplified == traditional {
+			t.Errorf("identity pair %c%c marks Traditional text as Simplified", simplified, traditional)
+		}
+		if seen[simplified] {
+			t.Errorf("duplicate pair for %c", simplified)
+		}
+		if _, ok := ambiguousTable[simplified]; ok {
+			t.Errorf("%c is in both the Simplified and ambiguous tables", simplified)
+		}
+		seen[simplified] = true
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorKind 錯誤分類
+type ErrorKind string
+
+const (
+	ErrorKindUserInput ErrorKind = "user_input" // 用戶輸入錯誤，可直接提示用戶修正
+	ErrorKindTransient ErrorKind = "transient"  // 暫時性錯誤（儲存服務忙碌、逾時），稍後重試即可
+	ErrorKindPermanent ErrorKind = "permanent"  // 永久性錯誤（程式錯誤、資料損毀）
+)
+
+// AppError 帶有分類的應用程式錯誤
+type AppError struct {
+	Kind    ErrorKind
+	Message string // 可直接回覆給用戶的訊息（可為空）
+	Cause   error
+}
+
+func (e *AppError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Kind, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// NewUserInputError 建立用戶輸入錯誤，message 會直接回覆給用戶
+func NewUserInputError(message string) *AppError {
+	return &AppError{Kind: ErrorKindUserInput, Message: message}
+}
+
+// NewTransientError 建立暫時性錯誤
+func NewTransientError(message string, cause error) *AppError {
+	return &AppError{Kind: ErrorKindTransient, Message: message, Cause: cause}
+}
+
+// NewPermanentError 建立永久性錯誤
+func NewPermanentError(message string, cause error) *AppError {
+	return &AppError{Kind: ErrorKindPermanent, Message: message, Cause: cause}
+}
+
+// ClassifyError 判斷錯誤類型
+// 未經包裝的錯誤會依 gRPC 狀態碼（Firestore）或 context 錯誤推斷
+// 文件不存在（NotFound）代表資料缺漏而非用戶輸錯，預期中的不存在應由呼叫端自行處理
+func ClassifyError(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindTransient
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Internal:
+		return ErrorKindTransient
+	case codes.InvalidArgument, codes.OutOfRange:
+		return ErrorKindUserInput
+	}
+
+	return ErrorKindPermanent
+}
+
+// UserFacingErrorMessage 取得回覆給用戶的錯誤訊息
+func UserFacingErrorMessage(err error) string {
+	var appErr *AppError
+	if errors.As(err, &appErr) && appErr.Kind == ErrorKindUserInput && appErr.Message != "" {
+		return appErr.Message
+	}
+
+	switch ClassifyError(err) {
+	case ErrorKindUserInput:
+		return "輸入的內容無法處理，請確認後再試一次，或輸入「幫助」查看使用說明。"
+	case ErrorKindTransient:
+		return "系統忙碌，請稍後再試"
+	default:
+		return "抱歉，系統發生錯誤，我們已記錄問題並會盡快處理。請輸入「退出」後重新開始。"
+	}
+}
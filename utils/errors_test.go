@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"nil", nil, ""},
+		{"user input", NewUserInputError("請輸入課次"), ErrorKindUserInput},
+		{"wrapped app error", fmt.Errorf("query: %w", NewTransientError("busy", nil)), ErrorKindTransient},
+		{"context deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), ErrorKindTransient},
+		{"firestore unavailable", status.Error(codes.Unavailable, "unavailable"), ErrorKindTransient},
+		{"firestore invalid argument", status.Error(codes.InvalidArgument, "bad field"), ErrorKindUserInput},
+		{"firestore not found", status.Error(codes.NotFound, "no document"), ErrorKindPermanent},
+		{"firestore permission denied", status.Error(codes.PermissionDenied, "denied"), ErrorKindPermanent},
+		{"plain error", errors.New("boom"), ErrorKindPermanent},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("%s: ClassifyError = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUserFacingErrorMessage(t *testing.T) {
+	if got := UserFacingErrorMessage(NewUserInputError("請輸入課次")); got != "請輸入課次" {
+		t.Errorf("user input message = %q", got)
+	}
+	if got := UserFacingErrorMessage(NewTransientError("busy", nil)); got != "系統忙碌，請稍後再試" {
+		t.Errorf("transient message = %q", got)
+	}
+	// 永久性錯誤不把內部訊息顯示給用戶
+	if got := UserFacingErrorMessage(NewPermanentError("corrupt lesson data", nil)); got == "corrupt lesson data" {
+		t.Errorf("permanent error leaked internal message")
+	}
+}
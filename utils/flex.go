@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v7/linebot"
+
+	"chinese-learning-linebot/models"
+)
+
+// CreateDailyCharacterFlex 創建「今日一字」卡片
+func CreateDailyCharacterFlex(info *models.CharacterInfo, lessonTitle string) *linebot.FlexMessage {
+	bodyContents := []linebot.FlexComponent{
+		newFlexText("📅 今日一字", linebot.FlexTextSizeTypeSm, false, "#888888"),
+		&linebot.TextComponent{
+			Type:   linebot.FlexComponentTypeText,
+			Text:   info.Character,
+			Size:   linebot.FlexTextSizeType5xl,
+			Weight: linebot.FlexTextWeightTypeBold,
+			Align:  linebot.FlexComponentAlignTypeCenter,
+			Margin: linebot.FlexComponentMarginTypeMd,
+		},
+		&linebot.SeparatorComponent{Type: linebot.FlexComponentTypeSeparator, Margin: linebot.FlexComponentMarginTypeLg},
+	}
+
+	bodyContents = append(bodyContents, newFlexInfoRow("注音", info.Phonetic))
+	bodyContents = append(bodyContents, newFlexInfoRow("部首", info.Radical))
+	if info.StrokeCount > 0 {
+		bodyContents = append(bodyContents, newFlexInfoRow("筆畫", fmt.Sprintf("%d 畫", info.StrokeCount)))
+	}
+	bodyContents = append(bodyContents, newFlexInfoRow("字義", info.Meaning))
+
+	if len(info.Examples) > 0 {
+		examples := info.Examples
+		if len(examples) > 3 {
+			examples = examples[:3]
+		}
+		bodyContents = append(bodyContents, newFlexInfoRow("例句", strings.Join(examples, "\n")))
+	}
+
+	if lessonTitle != "" {
+		bodyContents = append(bodyContents, newFlexText("📖 下一課："+lessonTitle, linebot.FlexTextSizeTypeXs, false, "#888888"))
+	}
+
+	bubble := &linebot.BubbleContainer{
+		Type: linebot.FlexContainerTypeBubble,
+		Body: &linebot.BoxComponent{
+			Type:     linebot.FlexComponentTypeBox,
+			Layout:   linebot.FlexBoxLayoutTypeVertical,
+			Spacing:  linebot.FlexComponentSpacingTypeSm,
+			Contents: bodyContents,
+		},
+	}
+
+	altText := fmt.Sprintf("今日一字：%s（%s）", info.Character, info.Phonetic)
+	return linebot.NewFlexMessage(altText, bubble)
+}
+
+// 建立一般文字元件
+func newFlexText(text string, size linebot.FlexTextSizeType, bold bool, color string) *linebot.TextComponent {
+	component := &linebot.TextComponent{
+		Type:  linebot.FlexComponentTypeText,
+		Text:  text,
+		Size:  size,
+		Wrap:  true,
+		Color: color,
+	}
+	if bold {
+		component.Weight = linebot.FlexTextWeightTypeBold
+	}
+	return component
+}
+
+// 建立「標籤：內容」的橫向資訊列，內容為空時顯示「－」
+func newFlexInfoRow(label string, value string) *linebot.BoxComponent {
+	if value == "" {
+		value = "－"
+	}
+	labelFlex := 1
+	valueFlex := 4
+	return &linebot.BoxComponent{
+		Type:    linebot.FlexComponentTypeBox,
+		Layout:  linebot.FlexBoxLayoutTypeBaseline,
+		Spacing: linebot.FlexComponentSpacingTypeSm,
+		Contents: []linebot.FlexComponent{
+			&linebot.TextComponent{
+				Type:  linebot.FlexComponentTypeText,
+				Text:  label,
+				Size:  linebot.FlexTextSizeTypeSm,
+				Color: "#aaaaaa",
+				Flex:  &labelFlex,
+			},
+			&linebot.TextComponent{
+				Type: linebot.FlexComponentTypeText,
+				Text: value,
+				Size: linebot.FlexTextSizeTypeSm,
+				Wrap: true,
+				Flex: &valueFlex,
+			},
+		},
+	}
+}
+
+// CreateWeeklyReportFlex 創建學習週報卡片
+func CreateWeeklyReportFlex(report *models.WeeklyReport) *linebot.FlexMessage {
+	start := time.Unix(report.PeriodStart, 0)
+	end := time.Unix(report.PeriodEnd, 0)
+
+	bodyContents := []linebot.FlexComponent{
+		newFlexText("📊 學習週報", linebot.FlexTextSizeTypeXl, true, ""),
+		newFlexText(fmt.Sprintf("%s - %s", start.Format("2006/1/2"), end.Format("1/2")), linebot.FlexTextSizeTypeXs, false, "#888888"),
+		&linebot.SeparatorComponent{Type: linebot.FlexComponentTypeSeparator, Margin: linebot.FlexComponentMarginTypeMd},
+	}
+
+	if report.Progress != nil {
+		bodyContents = append(bodyContents, newFlexInfoRow("進度", fmt.Sprintf("%s %d年級%s 第%d課／共%d課",
+			report.Progress.Publisher, report.Progress.Grade, semesterText(report.Progress.Semester),
+			report.CurrentLesson, report.Progress.TotalLessons)))
+	}
+
+	learned := fmt.Sprintf("%d 字", len(report.LearnedThisWeek))
+	if len(report.LearnedThisWeek) > 0 {
+		learned += "\n" + strings.Join(report.LearnedThisWeek, " ")
+	}
+	bodyContents = append(bodyContents, newFlexInfoRow("本週", learned))
+
+	if len(report.UpcomingCharacters) > 0 {
+		bodyContents = append(bodyContents, newFlexInfoRow("下週", strings.Join(report.UpcomingCharacters, " ")))
+	}
+
+	bodyContents = append(bodyContents,
+		&linebot.SeparatorComponent{Type: linebot.FlexComponentTypeSeparator, Margin: linebot.FlexComponentMarginTypeMd},
+		newFlexText(fmt.Sprintf("✏️ 本週練習 %d 次", report.SessionsThisWeek), linebot.FlexTextSizeTypeSm, true, ""),
+	)
+	for _, entry := range sortedAccuracy(report.AccuracyByType) {
+		bodyContents = append(bodyContents, newFlexInfoRow(PracticeTypeDisplayName(entry.Type),
+			fmt.Sprintf("%.0f%%（%d/%d）", entry.Accuracy, entry.Correct, entry.Total)))
+	}
+
+	if report.Stats != nil {
+		streak := fmt.Sprintf("%d 天（最佳 %d 天）", report.Stats.Streak, report.Stats.BestStreak)
+		if !report.PracticedToday {
+			streak += "\n今天還沒練習喔"
+		}
+		bodyContents = append(bodyContents, newFlexInfoRow("連續", streak))
+	}
+
+	if len(report.MostMissed) > 0 {
+		var missed []string
+		for _, item := range report.MostMissed {
+			missed = append(missed, fmt.Sprintf("%s×%d", item.Character, item.WrongCount))
+		}
+		bodyContents = append(bodyContents, newFlexInfoRow("易錯", strings.Join(missed, "  ")))
+	}
+
+	bubble := &linebot.BubbleContainer{
+		Type: linebot.FlexContainerTypeBubble,
+		Body: &linebot.BoxComponent{
+			Type:     linebot.FlexComponentTypeBox,
+			Layout:   linebot.FlexBoxLayoutTypeVertical,
+			Spacing:  linebot.FlexComponentSpacingTypeSm,
+			Contents: bodyContents,
+		},
+	}
+
+	altText := fmt.Sprintf("學習週報：本週學會 %d 個字，練習 %d 次", len(report.LearnedThisWeek), report.SessionsThisWeek)
+	return linebot.NewFlexMessage(altText, bubble)
+}
+
+// CreateCharacterDetailFlex 創建單字詳細資料卡片
+// introducedIn 為用戶課本中首次教到此字的課次說明，practiceURL 為筆順練習連結
+func CreateCharacterDetailFlex(info *models.CharacterInfo, introducedIn string, practiceURL string) *linebot.FlexMessage {
+	bodyContents := []linebot.FlexComponent{
+		&linebot.TextComponent{
+			Type:   linebot.FlexComponentTypeText,
+			Text:   info.Character,
+			Size:   linebot.FlexTextSizeType5xl,
+			Weight: linebot.FlexTextWeightTypeBold,
+			Align:  linebot.FlexComponentAlignTypeCenter,
+		},
+		&linebot.SeparatorComponent{Type: linebot.FlexComponentTypeSeparator, Margin: linebot.FlexComponentMarginTypeLg},
+	}
+
+	if info.IsPolyphonic() {
+		// 破音字：每個讀音分別列出字義與例詞
+		for _, reading := range info.Readings {
+			value := reading.Meaning
+			if len(reading.Words) > 0 {
+				value += "\n例：" + strings.Join(reading.Words, "、")
+			}
+			bodyContents = append(bodyContents, newFlexInfoRow(reading.Phonetic, value))
+		}
+		bodyContents = append(bodyContents, newFlexInfoRow("部首", info.Radical))
+		if info.StrokeCount > 0 {
+			bodyContents = append(bodyContents, newFlexInfoRow("筆畫", fmt.Sprintf("%d 畫", info.StrokeCount)))
+		}
+	} else {
+		bodyContents = append(bodyContents, newFlexInfoRow("注音", info.Phonetic))
+		bodyContents = append(bodyContents, newFlexInfoRow("部首", info.Radical))
+		if info.StrokeCount > 0 {
+			bodyContents = append(bodyContents, newFlexInfoRow("筆畫", fmt.Sprintf("%d 畫", info.StrokeCount)))
+		}
+		bodyContents = append(bodyContents, newFlexInfoRow("字義", info.Meaning))
+	}
+
+	if len(info.Examples) > 0 {
+		examples := info.Examples
+		if len(examples) > 3 {
+			examples = examples[:3]
+		}
+		bodyContents = append(bodyContents, newFlexInfoRow("例句", strings.Join(examples, "\n")))
+	}
+
+	if introducedIn != "" {
+		bodyContents = append(bodyContents, newFlexInfoRow("課本", introducedIn))
+	}
+	if len(info.Lessons) > 0 {
+		bodyContents = append(bodyContents, newFlexInfoRow("出現", strings.Join(info.Lessons, "、")))
+	}
+
+	bubble := &linebot.BubbleContainer{
+		Type: linebot.FlexContainerTypeBubble,
+		Body: &linebot.BoxComponent{
+			Type:     linebot.FlexComponentTypeBox,
+			Layout:   linebot.FlexBoxLayoutTypeVertical,
+			Spacing:  linebot.FlexComponentSpacingTypeSm,
+			Contents: bodyContents,
+		},
+		Footer: &linebot.BoxComponent{
+			Type:   linebot.FlexComponentTypeBox,
+			Layout: linebot.FlexBoxLayoutTypeVertical,
+			Contents: []linebot.FlexComponent{
+				&linebot.ButtonComponent{
+					Type:   linebot.FlexComponentTypeButton,
+					Style:  linebot.FlexButtonStyleTypePrimary,
+					Action: linebot.NewURIAction("✍️ 筆順練習", practiceURL),
+				},
+			},
+		},
+	}
+
+	// 有筆順資料時加上筆順分解圖按鈕
+	if info.StrokeOrder != nil {
+		data := url.Values{}
+		data.Set("action", "command")
+		data.Set("text", "筆順 "+info.Character)
+		bubble.Footer.Contents = append(bubble.Footer.Contents, &linebot.ButtonComponent{
+			Type:   linebot.FlexComponentTypeButton,
+			Style:  linebot.FlexButtonStyleTypeSecondary,
+			Margin: linebot.FlexComponentMarginTypeSm,
+			Action: linebot.NewPostbackAction("🎬 筆順動畫", data.Encode(), "", "筆順 "+info.Character, "", ""),
+		})
+	}
+
+	phonetics := info.Phonetic
+	if info.IsPolyphonic() {
+		var readings []string
+		for _, reading := range info.Readings {
+			readings = append(readings, reading.Phonetic)
+		}
+		phonetics = strings.Join(readings, "、")
+	}
+	altText := fmt.Sprintf("%s（%s）：%s", info.Character, phonetics, info.Meaning)
+	return linebot.NewFlexMessage(altText, bubble)
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Intent 一個指令及其同義說法
+type Intent struct {
+	Command  string   // 實際執行的指令文字
+	Synonyms []string // 視同該指令的其他說法
+}
+
+// IntentMatch 比對結果
+type IntentMatch struct {
+	Command  string
+	Phrase   string // 比對到的說法（指令本身或同義詞）
+	Distance int    // 0 表示完全相同
+}
+
+// IntentMatcher 以字元與注音的編輯距離比對用戶輸入與指令
+type IntentMatcher struct {
+	intents []Intent
+}
+
+func NewIntentMatcher(intents []Intent) *IntentMatcher {
+	return &IntentMatcher{intents: intents}
+}
+
+// Suggest 回傳相近的指令，依距離排序，每個指令只出現一次
+// 距離取字元編輯距離與注音編輯距離（同音字視為相同）的較小者
+func (m *IntentMatcher) Suggest(text string, limit int) []IntentMatch {
+	normalized := []rune(normalizeIntentText(text))
+	if len(normalized) < 2 {
+		return nil
+	}
+	inputZhuyin := ToZhuyinSyllables(string(normalized))
+
+	var matches []IntentMatch
+	for _, intent := range m.intents {
+		best := IntentMatch{Distance: -1}
+		for _, phrase := range intent.phrases() {
+			target := []rune(normalizeIntentText(phrase))
+			distance := editDistance(runesToStrings(normalized), runesToStrings(target))
+			if zhuyinDistance := editDistance(inputZhuyin, ToZhuyinSyllables(string(target))); zhuyinDistance < distance {
+				distance = zhuyinDistance
+			}
+			if distance > allowedIntentDistance(len(target)) {
+				continue
+			}
+			if best.Distance < 0 || distance < best.Distance {
+				best = IntentMatch{Command: intent.Command, Phrase: phrase, Distance: distance}
+			}
+		}
+		if best.Distance >= 0 {
+			matches = append(matches, best)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Distance < matches[j].Distance
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func (i Intent) phrases() []string {
+	return append([]string{i.Command}, i.Synonyms...)
+}
+
+// 允許的編輯距離：約每四個字容許一個錯字，至少一個
+func allowedIntentDistance(length int) int {
+	allowed := (length + 2) / 4
+	if allowed < 1 {
+		allowed = 1
+	}
+	return allowed
+}
+
+// 去除空白與標點並轉小寫
+func normalizeIntentText(text string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+func runesToStrings(runes []rune) []string {
+	result := make([]string, len(runes))
+	for i, r := range runes {
+		result[i] = string(r)
+	}
+	return result
+}
+
+// 以字串為單位計算 Levenshtein 編輯距離
+func editDistance(a, b []string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
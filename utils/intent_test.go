@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testIntents = []Intent{
+	{Command: "查詢累積字詞", Synonyms: []string{"累積字詞", "查詢生字"}},
+	{Command: "練習", Synonyms: []string{"開始練習", "小測驗"}},
+	{Command: "印字帖", Synonyms: []string{"字帖"}},
+	{Command: "幫助", Synonyms: []string{"help", "說明"}},
+}
+
+func TestIntentMatcherSuggest(t *testing.T) {
+	matcher := NewIntentMatcher(testIntents)
+	tests := []struct {
+		text         string
+		wantCommand  string
+		wantDistance int
+	}{
+		// 錯字
+		{"查詢累計字詞", "查詢累積字詞", 0},
+		{"查詢累積自", "查詢累積字詞", 1},
+		// 同音字以注音比對視為相同
+		{"查尋累積字詞", "查詢累積字詞", 0},
+		{"印字貼", "印字帖", 0},
+		{"練息", "練習", 0},
+		// 英文不分大小寫，忽略空白與標點
+		{"HELP!", "幫助", 0},
+		{"helpp", "幫助", 1},
+	}
+
+	for _, tt := range tests {
+		matches := matcher.Suggest(tt.text, 3)
+		if len(matches) == 0 {
+			t.Errorf("Suggest(%q) found nothing, want %s", tt.text, tt.wantCommand)
+			continue
+		}
+		if matches[0].Command != tt.wantCommand || matches[0].Distance != tt.wantDistance {
+			t.Errorf("Suggest(%q) = %s (distance %d), want %s (distance %d)",
+				tt.text, matches[0].Command, matches[0].Distance, tt.wantCommand, tt.wantDistance)
+		}
+	}
+}
+
+func TestIntentMatcherSuggestRejectsUnrelated(t *testing.T) {
+	matcher := NewIntentMatcher(testIntents)
+	for _, text := range []string{"你好", "今天天氣很好", "字", ""} {
+		if matches := matcher.Suggest(text, 3); len(matches) != 0 {
+			t.Errorf("Suggest(%q) = %+v, want no match", text, matches)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"練習", "", 2},
+		{"練習", "練習", 0},
+		{"練習", "練息", 1},
+		{"印字帖", "字帖", 1},
+		{"查詢累積字詞", "查累積詞", 2},
+	}
+	for _, tt := range tests {
+		if got := editDistance(runesToStrings([]rune(tt.a)), runesToStrings([]rune(tt.b))); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestToZhuyinSyllables(t *testing.T) {
+	got := ToZhuyinSyllables("練習A字")
+	want := []string{"ㄌㄧㄢ", "ㄒㄧ", "a", "ㄗ"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToZhuyinSyllables = %v, want %v", got, want)
+	}
+}
+
+// 同一個字出現在兩組注音時，查表結果取決於 map 的走訪順序
+func TestZhuyinGroupsAreDisjoint(t *testing.T) {
+	seen := make(map[rune]string)
+	for syllable, chars := range zhuyinGroups {
+		for _, char := range chars {
+			if other, ok := seen[char]; ok {
+				t.Errorf("%q is listed under both %s and %s", char, other, syllable)
+			}
+			seen[char] = syllable
+		}
+	}
+}
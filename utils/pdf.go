@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// A4 紙張大小（單位：點，1/72 英吋）
+const (
+	PDFPageWidthA4  = 595.28
+	PDFPageHeightA4 = 841.89
+)
+
+// PDFDocument 簡易 PDF 產生器，只支援線條、矩形與中文字
+// 中文字使用 PDF 閱讀器內建的 MSung-Light（明體）CID 字型，不需嵌入字型檔
+type PDFDocument struct {
+	width  float64
+	height float64
+	pages  []*PDFPage
+}
+
+// PDFPage PDF 頁面，座標原點在左下角
+type PDFPage struct {
+	content bytes.Buffer
+}
+
+// NewPDFDocument 建立指定頁面大小的 PDF
+func NewPDFDocument(width, height float64) *PDFDocument {
+	return &PDFDocument{width: width, height: height}
+}
+
+// AddPage 新增一頁
+func (d *PDFDocument) AddPage() *PDFPage {
+	page := &PDFPage{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// SetLineWidth 設定線寬
+func (p *PDFPage) SetLineWidth(width float64) {
+	fmt.Fprintf(&p.content, "%s w\n", pdfNumber(width))
+}
+
+// SetStrokeGray 設定線條灰階（0 為黑、1 為白）
+func (p *PDFPage) SetStrokeGray(gray float64) {
+	fmt.Fprintf(&p.content, "%s G\n", pdfNumber(gray))
+}
+
+// SetFillGray 設定填色（文字）灰階（0 為黑、1 為白）
+func (p *PDFPage) SetFillGray(gray float64) {
+	fmt.Fprintf(&p.content, "%s g\n", pdfNumber(gray))
+}
+
+// SetDash 設定虛線樣式，on 為 0 時改回實線
+func (p *PDFPage) SetDash(on, off float64) {
+	if on <= 0 {
+		p.content.WriteString("[] 0 d\n")
+		return
+	}
+	fmt.Fprintf(&p.content, "[%s %s] 0 d\n", pdfNumber(on), pdfNumber(off))
+}
+
+// Line 畫直線
+func (p *PDFPage) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l S\n", pdfNumber(x1), pdfNumber(y1), pdfNumber(x2), pdfNumber(y2))
+}
+
+// Rect 畫矩形外框
+func (p *PDFPage) Rect(x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re S\n", pdfNumber(x), pdfNumber(y), pdfNumber(width), pdfNumber(height))
+}
+
+// Text 在 (x, y) 基線位置寫字；超出 BMP 的字無法以 UCS-2 編碼，會被略過
+func (p *PDFPage) Text(x, y, size float64, text string) {
+	var hex strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF {
+			continue
+		}
+		fmt.Fprintf(&hex, "%04X", r)
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %s Tf %s %s Td <%s> Tj ET\n", pdfNumber(size), pdfNumber(x), pdfNumber(y), hex.String())
+}
+
+// Bytes 輸出 PDF 檔案內容
+func (d *PDFDocument) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		return nil, fmt.Errorf("pdf has no pages")
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// 1-5：目錄、頁面樹、字型；之後每頁兩個物件（頁面與內容）
+	const firstPageObject = 6
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPageObject+i*2))
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	writeObject("<< /Type /Font /Subtype /Type0 /BaseFont /MSung-Light /Encoding /UniCNS-UCS2-H /DescendantFonts [4 0 R] >>")
+	writeObject("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /MSung-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (CNS1) /Supplement 0 >> /FontDescriptor 5 0 R /DW 1000 >>")
+	writeObject("<< /Type /FontDescriptor /FontName /MSung-Light /Flags 6 /FontBBox [-160 -249 1015 888] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+
+	for i, page := range d.pages {
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		if _, err := writer.Write(page.content.Bytes()); err != nil {
+			return nil, fmt.Errorf("failed to compress page %d: %w", i+1, err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress page %d: %w", i+1, err)
+		}
+
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfNumber(d.width), pdfNumber(d.height), firstPageObject+i*2+1))
+		writeObject(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+			compressed.Len(), compressed.Bytes()))
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xrefOffset)
+	return buf.Bytes(), nil
+}
+
+// 數字輸出到小數點後兩位，並去掉多餘的 0
+func pdfNumber(value float64) string {
+	text := strings.TrimRight(fmt.Sprintf("%.2f", value), "0")
+	return strings.TrimSuffix(text, ".")
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OneShotQuery 一句話完成的累積字詞查詢，未提到的欄位為零值
+type OneShotQuery struct {
+	Publisher string
+	Grade     int
+	Semester  int
+	Lesson    int
+	QueryText string
+}
+
+// HasCourse 是否有提到出版社、年級、學期或課次
+func (q *OneShotQuery) HasCourse() bool {
+	return q.Publisher != "" || q.Grade > 0 || q.Semester > 0 || q.Lesson > 0
+}
+
+// 一句話查詢開頭可出現的片段，每種欄位只取一次
+var (
+	oneShotPublisherPattern     = regexp.MustCompile(`^(康軒|南一|翰林)版?`)
+	oneShotGradeSemesterPattern = regexp.MustCompile(`^([1-6一二三四五六])\s*(?:年級)?\s*([上下])(?:學期)?`)
+	oneShotGradePattern         = regexp.MustCompile(`^([1-6一二三四五六])\s*年級`)
+	oneShotSemesterPattern      = regexp.MustCompile(`^([上下])學期`)
+	oneShotLessonPattern        = regexp.MustCompile(`^(?:第\s*([0-9一二兩三四五六七八九十]+)\s*課|[Ll]\s*(\d+)|(\d+)\s*課)`)
+	oneShotKeywordPattern       = regexp.MustCompile(`^(?:查詢|查)`)
+)
+
+// ParseOneShotQuery 解析「康軒 二上 第5課 我好喜歡吃飯」或「翰林3下L7 查 森林」這類一句話查詢
+// 出版社、年級學期、課次與「查」須寫在開頭，其餘的中文字即為查詢字詞
+// 沒有任何課程欄位也沒有「查」，或沒有查詢字詞時回傳 false
+func ParseOneShotQuery(text string) (*OneShotQuery, bool) {
+	query, rest, hasKeyword := parseOneShotPrefix(text, true)
+	query.QueryText = strings.Join(ExtractChineseCharacters(rest), "")
+	if query.QueryText == "" || (!query.HasCourse() && !hasKeyword) {
+		return nil, false
+	}
+	return query, true
+}
+
+// ParseCourse 解析開頭的課程設定，例如「康軒 二上 第5課 二年甲班」，回傳課程欄位與其餘文字
+func ParseCourse(text string) (*OneShotQuery, string) {
+	course, rest, _ := parseOneShotPrefix(text, false)
+	return course, strings.TrimSpace(rest)
+}
+
+// 依序解析開頭的出版社、年級學期、課次（以及 allowKeyword 時的「查」）
+func parseOneShotPrefix(text string, allowKeyword bool) (*OneShotQuery, string, bool) {
+	query := &OneShotQuery{}
+	rest := strings.TrimSpace(text)
+	hasKeyword := false
+
+	for {
+		rest = strings.TrimLeft(rest, " 　,，、")
+		if query.Publisher == "" {
+			if m := oneShotPublisherPattern.FindStringSubmatch(rest); m != nil {
+				query.Publisher = m[1]
+				rest = rest[len(m[0]):]
+				continue
+			}
+		}
+		if query.Grade == 0 && query.Semester == 0 {
+			if m := oneShotGradeSemesterPattern.FindStringSubmatch(rest); m != nil && endsOneShotToken(rest[len(m[0]):]) {
+				query.Grade = ParseChineseNumber(m[1])
+				query.Semester = semesterFromText(m[2])
+				rest = rest[len(m[0]):]
+				continue
+			}
+		}
+		if query.Grade == 0 {
+			if m := oneShotGradePattern.FindStringSubmatch(rest); m != nil {
+				query.Grade = ParseChineseNumber(m[1])
+				rest = rest[len(m[0]):]
+				continue
+			}
+		}
+		if query.Semester == 0 {
+			if m := oneShotSemesterPattern.FindStringSubmatch(rest); m != nil {
+				query.Semester = semesterFromText(m[1])
+				rest = rest[len(m[0]):]
+				continue
+			}
+		}
+		if query.Lesson == 0 {
+			if m := oneShotLessonPattern.FindStringSubmatch(rest); m != nil {
+				for _, group := range m[1:] {
+					if group != "" {
+						query.Lesson = ParseChineseNumber(group)
+					}
+				}
+				rest = rest[len(m[0]):]
+				continue
+			}
+		}
+		if allowKeyword && !hasKeyword {
+			// 「查理是誰」的查不是關鍵字：查之後須有分隔符號，或前後有課程欄位
+			if m := oneShotKeywordPattern.FindString(rest); m != "" && (query.HasCourse() || startsWithOneShotSeparator(rest[len(m):]) || startsWithCourse(rest[len(m):])) {
+				hasKeyword = true
+				rest = rest[len(m):]
+				continue
+			}
+		}
+		break
+	}
+	return query, rest, hasKeyword
+}
+
+// ParseChineseNumber 解析阿拉伯數字或一到九十九的中文數字，無法解析時回傳 0
+func ParseChineseNumber(text string) int {
+	if n, err := strconv.Atoi(text); err == nil {
+		return n
+	}
+
+	digits := map[rune]int{'一': 1, '二': 2, '兩': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	runes := []rune(text)
+	switch {
+	case len(runes) == 1 && runes[0] == '十':
+		return 10
+	case len(runes) == 1:
+		return digits[runes[0]]
+	case len(runes) == 2 && runes[0] == '十':
+		// 十一 ~ 十九
+		if d, ok := digits[runes[1]]; ok {
+			return 10 + d
+		}
+	case len(runes) == 2 && runes[1] == '十':
+		// 二十、三十…
+		if d, ok := digits[runes[0]]; ok {
+			return d * 10
+		}
+	case len(runes) == 3 && runes[1] == '十':
+		// 二十一 ~ 九十九
+		tens, ok1 := digits[runes[0]]
+		ones, ok2 := digits[runes[2]]
+		if ok1 && ok2 {
+			return tens*10 + ones
+		}
+	}
+	return 0
+}
+
+// 「二上」這類簡寫後面須接空白、課次或「查」，避免把「一下子」當成一年級下學期
+func endsOneShotToken(rest string) bool {
+	if rest == "" {
+		return true
+	}
+	next := []rune(rest)[0]
+	return strings.ContainsRune(" 　,，、第Ll查", next) || (next >= '0' && next <= '9')
+}
+
+// 開頭是否為空白或標點
+func startsWithOneShotSeparator(rest string) bool {
+	return rest != "" && strings.ContainsRune(" 　,，、:：", []rune(rest)[0])
+}
+
+// 開頭是否為課程欄位，例如「查康軒二上 森林」
+func startsWithCourse(rest string) bool {
+	course, _, _ := parseOneShotPrefix(rest, false)
+	return course.HasCourse()
+}
+
+func semesterFromText(text string) int {
+	if text == "下" {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,63 @@
+package utils
+
+import "testing"
+
+func TestParseOneShotQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		ok   bool
+		want OneShotQuery
+	}{
+		{"康軒 二上 第5課 我好喜歡吃飯", true, OneShotQuery{Publisher: "康軒", Grade: 2, Semester: 1, Lesson: 5, QueryText: "我好喜歡吃飯"}},
+		{"翰林3下L7 查 森林", true, OneShotQuery{Publisher: "翰林", Grade: 3, Semester: 2, Lesson: 7, QueryText: "森林"}},
+		{"南一版 四年級 下學期 第十二課 花園", true, OneShotQuery{Publisher: "南一", Grade: 4, Semester: 2, Lesson: 12, QueryText: "花園"}},
+		{"第3課 大象", true, OneShotQuery{Lesson: 3, QueryText: "大象"}},
+		{"查 森林", true, OneShotQuery{QueryText: "森林"}},
+		{"查詢：森林", true, OneShotQuery{QueryText: "森林"}},
+		{"查康軒二上 森林", true, OneShotQuery{Publisher: "康軒", Grade: 2, Semester: 1, QueryText: "森林"}},
+		{"康軒二上查森林", true, OneShotQuery{Publisher: "康軒", Grade: 2, Semester: 1, QueryText: "森林"}},
+		// 查之後沒有分隔也沒有課程欄位，只是一般的句子
+		{"查理是誰", false, OneShotQuery{}},
+		{"查詢森林", false, OneShotQuery{}},
+		// 「一下子」不是一年級下學期
+		{"一下子就好", false, OneShotQuery{}},
+		{"康軒 二上", false, OneShotQuery{}},
+		{"你好", false, OneShotQuery{}},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseOneShotQuery(tt.text)
+		if ok != tt.ok {
+			t.Errorf("ParseOneShotQuery(%q) ok = %v, want %v", tt.text, ok, tt.ok)
+			continue
+		}
+		if ok && *got != tt.want {
+			t.Errorf("ParseOneShotQuery(%q) = %+v, want %+v", tt.text, *got, tt.want)
+		}
+	}
+}
+
+func TestParseCourse(t *testing.T) {
+	course, rest := ParseCourse("康軒 二上 第5課 二年甲班")
+	want := OneShotQuery{Publisher: "康軒", Grade: 2, Semester: 1, Lesson: 5}
+	if *course != want || rest != "二年甲班" {
+		t.Errorf("ParseCourse = %+v, %q; want %+v, %q", *course, rest, want, "二年甲班")
+	}
+
+	// 「查」只在一句話查詢中是關鍵字
+	course, rest = ParseCourse("查 森林")
+	if course.HasCourse() || rest != "查 森林" {
+		t.Errorf("ParseCourse(查 森林) = %+v, %q", *course, rest)
+	}
+}
+
+func TestParseChineseNumber(t *testing.T) {
+	tests := map[string]int{
+		"5": 5, "一": 1, "兩": 2, "十": 10, "十二": 12, "二十": 20, "三十五": 35, "百": 0, "": 0,
+	}
+	for text, want := range tests {
+		if got := ParseChineseNumber(text); got != want {
+			t.Errorf("ParseChineseNumber(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
@@ -3,7 +3,11 @@ package utils
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	"chinese-learning-linebot/models"
 )
 
 // CreateCumulativeQueryResultMessage 創建累積字詞查詢結果的訊息
@@ -71,4 +75,151 @@ func CreateCharacterInputMessage(publisher string, grade int, semester int, less
 		"📖 查詢範圍：%s %d年級第%d學期第%d課\n\n"+
 		"例如：我好喜歡吃飯配菜",
 		publisher, grade, semester, lesson)
-}
\ No newline at end of file
+}
+// PracticeTypeDisplayName 題型的中文名稱
+func PracticeTypeDisplayName(practiceType string) string {
+	switch models.PracticeType(practiceType) {
+	case models.PracticeTypePhonetic:
+		return "注音"
+	case models.PracticeTypeStroke:
+		return "筆畫"
+	case models.PracticeTypeSentence:
+		return "造句"
+	case models.PracticeTypeStrokeOrder:
+		return "筆順"
+	case models.PracticeTypeNextStroke:
+		return "下一筆"
+	case models.PracticeTypeRadical:
+		return "部首"
+	case models.PracticeTypeRadicalCharacter:
+		return "部首找字"
+	case models.PracticeTypeCloze:
+		return "填空"
+	case models.PracticeTypeMixed:
+		return "綜合"
+	default:
+		return practiceType
+	}
+}
+
+// CreateWeeklyReportText 創建學習週報的純文字版本
+func CreateWeeklyReportText(report *models.WeeklyReport) string {
+	var result strings.Builder
+
+	start := time.Unix(report.PeriodStart, 0)
+	end := time.Unix(report.PeriodEnd, 0)
+	result.WriteString(fmt.Sprintf("📊 學習週報（%s - %s）\n\n", start.Format("1/2"), end.Format("1/2")))
+
+	if report.Progress != nil {
+		result.WriteString(fmt.Sprintf("📚 %s %d年級%s：第%d課／共%d課（%.0f%%）\n\n",
+			report.Progress.Publisher, report.Progress.Grade, semesterText(report.Progress.Semester),
+			report.CurrentLesson, report.Progress.TotalLessons, report.Progress.ProgressPercentage))
+	}
+
+	result.WriteString(fmt.Sprintf("✅ 本週學會 %d 個字", len(report.LearnedThisWeek)))
+	if len(report.LearnedThisWeek) > 0 {
+		result.WriteString("：\n" + strings.Join(report.LearnedThisWeek, "、"))
+	}
+	result.WriteString("\n\n")
+
+	if len(report.UpcomingCharacters) > 0 {
+		result.WriteString(fmt.Sprintf("📖 下週預習（%s）：\n%s\n\n", report.UpcomingLesson, strings.Join(report.UpcomingCharacters, "、")))
+	}
+
+	result.WriteString(fmt.Sprintf("✏️ 本週練習 %d 次\n", report.SessionsThisWeek))
+	for _, entry := range sortedAccuracy(report.AccuracyByType) {
+		result.WriteString(fmt.Sprintf("• %s：%d/%d 題（%.0f%%）\n", PracticeTypeDisplayName(entry.Type), entry.Correct, entry.Total, entry.Accuracy))
+	}
+	result.WriteString("\n")
+
+	if report.Stats != nil {
+		streakStatus := "今天還沒練習喔"
+		if report.PracticedToday {
+			streakStatus = "今天已完成練習"
+		}
+		result.WriteString(fmt.Sprintf("🔥 連續練習 %d 天（最佳 %d 天），%s\n\n", report.Stats.Streak, report.Stats.BestStreak, streakStatus))
+	}
+
+	if len(report.MostMissed) > 0 {
+		result.WriteString("⚠️ 最常答錯：")
+		var missed []string
+		for _, item := range report.MostMissed {
+			missed = append(missed, fmt.Sprintf("%s（%d 次）", item.Character, item.WrongCount))
+		}
+		result.WriteString(strings.Join(missed, "、"))
+	} else {
+		result.WriteString("🌟 本週沒有答錯的字，繼續保持！")
+	}
+
+	return result.String()
+}
+
+// 依題型名稱排序，讓週報輸出順序固定
+func sortedAccuracy(accuracy map[string]models.QuestionTypeAccuracy) []models.QuestionTypeAccuracy {
+	var entries []models.QuestionTypeAccuracy
+	for _, entry := range accuracy {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Type < entries[j].Type
+	})
+	return entries
+}
+
+func semesterText(semester *int) string {
+	if semester == nil {
+		return "全學年"
+	}
+	if *semester == 2 {
+		return "下學期"
+	}
+	return "上學期"
+}
+
+// CreateClassroomReportText 班級練習報告（給老師），names 為成員的顯示名稱，查不到名稱的成員以編號表示
+func CreateClassroomReportText(report *models.ClassroomReport, names map[string]string) string {
+	var result strings.Builder
+
+	classroom := report.Classroom
+	start := time.Unix(report.PeriodStart, 0)
+	end := time.Unix(report.PeriodEnd, 0)
+	result.WriteString(fmt.Sprintf("🏫 %s 班級報告（%s - %s）\n", classroom.Name, start.Format("1/2"), end.Format("1/2")))
+	semester := classroom.Semester
+	result.WriteString(fmt.Sprintf("📚 %s %d年級%s 第%d課\n\n", classroom.Publisher, classroom.Grade, semesterText(&semester), classroom.Lesson))
+
+	result.WriteString(fmt.Sprintf("👥 參與練習 %d/%d 人，共練習 %d 次\n", report.ActiveParticipants(), len(report.Participants), report.Sessions))
+	if report.Questions > 0 {
+		result.WriteString(fmt.Sprintf("✅ 全班正確率：%d/%d 題（%.0f%%）\n", report.Correct, report.Questions, float64(report.Correct)*100/float64(report.Questions)))
+	}
+	for _, entry := range sortedAccuracy(report.AccuracyByType) {
+		result.WriteString(fmt.Sprintf("• %s：%d/%d 題（%.0f%%）\n", PracticeTypeDisplayName(entry.Type), entry.Correct, entry.Total, entry.Accuracy))
+	}
+	result.WriteString("\n")
+
+	if len(report.MostMissed) > 0 {
+		var missed []string
+		for _, item := range report.MostMissed {
+			missed = append(missed, fmt.Sprintf("%s（%d 次）", item.Character, item.WrongCount))
+		}
+		result.WriteString("⚠️ 全班最常答錯：" + strings.Join(missed, "、") + "\n\n")
+	}
+
+	if len(report.Participants) > 0 {
+		result.WriteString("📝 同學練習情形：\n")
+		for i, participant := range report.Participants {
+			name := names[participant.UserID]
+			if name == "" {
+				name = fmt.Sprintf("同學%d", i+1)
+			}
+			if participant.Sessions == 0 {
+				result.WriteString(fmt.Sprintf("• %s：本週還沒練習\n", name))
+				continue
+			}
+			result.WriteString(fmt.Sprintf("• %s：練習 %d 次，答對 %d/%d 題\n", name, participant.Sessions, participant.Correct, participant.Questions))
+		}
+	} else {
+		result.WriteString("📝 還沒有同學在群組中練習，請同學在班級群組輸入「練習」開始")
+	}
+
+	return strings.TrimRight(result.String(), "\n")
+}
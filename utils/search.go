@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"chinese-learning-linebot/models"
+)
+
+var (
+	searchZhuyinPattern      = regexp.MustCompile(`^[ㄅ-ㄩˉˊˇˋ˙]+$`)
+	searchRadicalPattern     = regexp.MustCompile(`^(?:部首)?(\p{Han})部?$`)
+	searchStrokeRangePattern = regexp.MustCompile(`^(\d+)\s*[-~到至]\s*(\d+)\s*畫?$`)
+	searchStrokePattern      = regexp.MustCompile(`^(\d+)\s*畫(以上|以下)?$`)
+	searchPagePattern        = regexp.MustCompile(`^第(\d+)頁$`)
+)
+
+// ParseCharacterSearch 解析找字條件，例如「ㄕㄣ」、「木部 5-8畫」、「ㄇㄨˋ 已學」
+// 條件以空白分隔；learnedOnly 表示只找已學過的字
+func ParseCharacterSearch(text string) (criteria models.CharacterSearchCriteria, learnedOnly bool, ok bool) {
+	for _, token := range strings.Fields(text) {
+		switch {
+		case token == "已學" || token == "已學過" || token == "學過":
+			learnedOnly = true
+		case searchZhuyinPattern.MatchString(token):
+			criteria.Zhuyin = token
+		case searchStrokeRangePattern.MatchString(token):
+			m := searchStrokeRangePattern.FindStringSubmatch(token)
+			criteria.MinStrokes, _ = strconv.Atoi(m[1])
+			criteria.MaxStrokes, _ = strconv.Atoi(m[2])
+			if criteria.MinStrokes > criteria.MaxStrokes {
+				criteria.MinStrokes, criteria.MaxStrokes = criteria.MaxStrokes, criteria.MinStrokes
+			}
+		case searchStrokePattern.MatchString(token):
+			m := searchStrokePattern.FindStringSubmatch(token)
+			strokes, _ := strconv.Atoi(m[1])
+			switch m[2] {
+			case "以上":
+				criteria.MinStrokes = strokes
+			case "以下":
+				criteria.MaxStrokes = strokes
+			default:
+				criteria.MinStrokes = strokes
+				criteria.MaxStrokes = strokes
+			}
+		case searchPagePattern.MatchString(token):
+			criteria.Page, _ = strconv.Atoi(searchPagePattern.FindStringSubmatch(token)[1])
+		case searchRadicalPattern.MatchString(token):
+			criteria.Radical = searchRadicalPattern.FindStringSubmatch(token)[1]
+		default:
+			return criteria, false, false
+		}
+	}
+
+	ok = criteria.Zhuyin != "" || criteria.Radical != "" || criteria.MinStrokes > 0 || criteria.MaxStrokes > 0
+	return criteria, learnedOnly, ok
+}
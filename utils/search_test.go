@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+func TestParseCharacterSearch(t *testing.T) {
+	tests := []struct {
+		text        string
+		want        models.CharacterSearchCriteria
+		wantLearned bool
+		wantOK      bool
+	}{
+		{"ㄕㄣ", models.CharacterSearchCriteria{Zhuyin: "ㄕㄣ"}, false, true},
+		{"ㄇㄨˋ 已學", models.CharacterSearchCriteria{Zhuyin: "ㄇㄨˋ"}, true, true},
+		{"木部 5-8畫", models.CharacterSearchCriteria{Radical: "木", MinStrokes: 5, MaxStrokes: 8}, false, true},
+		{"部首木", models.CharacterSearchCriteria{Radical: "木"}, false, true},
+		{"8~5", models.CharacterSearchCriteria{MinStrokes: 5, MaxStrokes: 8}, false, true},
+		{"12畫", models.CharacterSearchCriteria{MinStrokes: 12, MaxStrokes: 12}, false, true},
+		{"10畫以上", models.CharacterSearchCriteria{MinStrokes: 10}, false, true},
+		{"3畫以下", models.CharacterSearchCriteria{MaxStrokes: 3}, false, true},
+		{"水部 第2頁", models.CharacterSearchCriteria{Radical: "水", Page: 2}, false, true},
+		// 沒有任何搜尋條件，或有無法辨識的文字
+		{"已學", models.CharacterSearchCriteria{}, true, false},
+		{"第2頁", models.CharacterSearchCriteria{Page: 2}, false, false},
+		{"你好", models.CharacterSearchCriteria{}, false, false},
+		{"ㄕㄣ 你好", models.CharacterSearchCriteria{Zhuyin: "ㄕㄣ"}, false, false},
+	}
+
+	for _, tt := range tests {
+		got, learned, ok := ParseCharacterSearch(tt.text)
+		if ok != tt.wantOK || (ok && (learned != tt.wantLearned || !sameSearchCriteria(got, tt.want))) {
+			t.Errorf("ParseCharacterSearch(%q) = %+v, %v, %v; want %+v, %v, %v",
+				tt.text, got, learned, ok, tt.want, tt.wantLearned, tt.wantOK)
+		}
+	}
+}
+
+func sameSearchCriteria(a, b models.CharacterSearchCriteria) bool {
+	return a.Zhuyin == b.Zhuyin && a.Radical == b.Radical && a.MinStrokes == b.MinStrokes &&
+		a.MaxStrokes == b.MaxStrokes && a.Page == b.Page
+}
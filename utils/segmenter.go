@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 課本語詞的詞頻：遠高於一般詞典，讓課本語詞優先成詞
+const lessonWordFrequency = 100000
+
+// Segmenter 以詞典建立有向無環圖（DAG），再以詞頻動態規劃找出機率最大的斷詞
+// 可疊加在另一個 Segmenter（例如一般詞典）之上，查不到的詞再查 base
+type Segmenter struct {
+	base      *Segmenter
+	frequency map[string]int
+	total     int
+	maxLength int
+}
+
+// NewSegmenter 建立空的斷詞器，base 可為 nil
+func NewSegmenter(base *Segmenter) *Segmenter {
+	s := &Segmenter{
+		base:      base,
+		frequency: make(map[string]int),
+		maxLength: 1,
+	}
+	if base != nil {
+		s.maxLength = base.maxLength
+	}
+	return s
+}
+
+// AddWord 加入詞與詞頻（詞頻小於 1 時以 1 計）
+func (s *Segmenter) AddWord(word string, frequency int) {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return
+	}
+	if frequency < 1 {
+		frequency = 1
+	}
+	s.total += frequency - s.frequency[word]
+	s.frequency[word] = frequency
+	if length := len([]rune(word)); length > s.maxLength {
+		s.maxLength = length
+	}
+}
+
+// AddWords 以相同詞頻加入多個詞
+func (s *Segmenter) AddWords(words []string, frequency int) {
+	for _, word := range words {
+		s.AddWord(word, frequency)
+	}
+}
+
+// LoadDictionary 從 Reader 載入詞典，每行「詞 [詞頻] [詞性]」，# 開頭為註解
+func (s *Segmenter) LoadDictionary(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		frequency := 1
+		if len(fields) > 1 {
+			f, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return fmt.Errorf("invalid frequency on line %d: %q", lineNumber, line)
+			}
+			frequency = f
+		}
+		s.AddWord(fields[0], frequency)
+	}
+	return scanner.Err()
+}
+
+// Contains 詞典（含 base）中是否有此詞
+func (s *Segmenter) Contains(word string) bool {
+	return s.lookup(word) > 0
+}
+
+// Segment 斷詞；連續的英數字視為一段，空白會被略過，標點各自成段
+func (s *Segmenter) Segment(text string) []string {
+	var segments []string
+	var han []rune
+	var other []rune
+
+	flushHan := func() {
+		if len(han) > 0 {
+			segments = append(segments, s.segmentHan(han)...)
+			han = han[:0]
+		}
+	}
+	flushOther := func() {
+		if len(other) > 0 {
+			segments = append(segments, string(other))
+			other = other[:0]
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case IsChineseCharacter(r):
+			flushOther()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			other = append(other, r)
+		case unicode.IsSpace(r):
+			flushHan()
+			flushOther()
+		default:
+			flushHan()
+			flushOther()
+			segments = append(segments, string(r))
+		}
+	}
+	flushHan()
+	flushOther()
+	return segments
+}
+
+// 對一段連續的中文字斷詞
+func (s *Segmenter) segmentHan(runes []rune) []string {
+	n := len(runes)
+	maxLength := s.maxLength
+
+	// dag[i]：從 i 開始、詞典中有的詞的結束位置（不含），單字一定可以成詞
+	dag := make([][]int, n)
+	for i := 0; i < n; i++ {
+		dag[i] = []int{i + 1}
+		for end := i + 2; end <= n && end-i <= maxLength; end++ {
+			if s.lookup(string(runes[i:end])) > 0 {
+				dag[i] = append(dag[i], end)
+			}
+		}
+	}
+
+	// route[i]：從 i 到結尾的最大對數機率，next[i] 為對應的下一個切點
+	logTotal := math.Log(float64(s.totalFrequency() + 1))
+	route := make([]float64, n+1)
+	next := make([]int, n+1)
+	for i := n - 1; i >= 0; i-- {
+		route[i] = math.Inf(-1)
+		for _, end := range dag[i] {
+			frequency := s.lookup(string(runes[i:end]))
+			if frequency < 1 {
+				frequency = 1
+			}
+			score := math.Log(float64(frequency)) - logTotal + route[end]
+			if score > route[i] {
+				route[i] = score
+				next[i] = end
+			}
+		}
+	}
+
+	var segments []string
+	for i := 0; i < n; i = next[i] {
+		segments = append(segments, string(runes[i:next[i]]))
+	}
+	return segments
+}
+
+func (s *Segmenter) lookup(word string) int {
+	if frequency, ok := s.frequency[word]; ok {
+		return frequency
+	}
+	if s.base != nil {
+		return s.base.lookup(word)
+	}
+	return 0
+}
+
+func (s *Segmenter) totalFrequency() int {
+	total := s.total
+	if s.base != nil {
+		total += s.base.totalFrequency()
+	}
+	return total
+}
+
+// 一般詞典（選用），由 LoadGeneralDictionary 載入後供所有斷詞共用
+var (
+	generalDictionaryMu sync.RWMutex
+	generalDictionary   *Segmenter
+)
+
+// LoadGeneralDictionary 從檔案載入一般詞典，之後的 SegmentWords 會以它為基礎
+func LoadGeneralDictionary(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open dictionary: %w", err)
+	}
+	defer file.Close()
+
+	dictionary := NewSegmenter(nil)
+	if err := dictionary.LoadDictionary(file); err != nil {
+		return fmt.Errorf("failed to load dictionary %s: %w", path, err)
+	}
+
+	generalDictionaryMu.Lock()
+	generalDictionary = dictionary
+	generalDictionaryMu.Unlock()
+	return nil
+}
+
+// NewLessonSegmenter 以課本語詞（優先）及一般詞典（若已載入）建立斷詞器
+func NewLessonSegmenter(words []string) *Segmenter {
+	generalDictionaryMu.RLock()
+	base := generalDictionary
+	generalDictionaryMu.RUnlock()
+
+	segmenter := NewSegmenter(base)
+	segmenter.AddWords(words, lessonWordFrequency)
+	return segmenter
+}
+
+// SegmentWords 以課本語詞斷詞，詞典中沒有的字單獨成段
+func SegmentWords(text string, words []string) []string {
+	return NewLessonSegmenter(words).Segment(text)
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSegmenterPicksMostProbablePath(t *testing.T) {
+	segmenter := NewSegmenter(nil)
+	segmenter.AddWord("研究", 500)
+	segmenter.AddWord("研究生", 20)
+	segmenter.AddWord("生命", 400)
+	segmenter.AddWord("起源", 300)
+
+	got := segmenter.Segment("研究生命起源")
+	want := []string{"研究", "生命", "起源"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Segment = %v, want %v", got, want)
+	}
+
+	// 詞頻改變時改走另一條路徑
+	segmenter.AddWord("研究生", 1000000)
+	got = segmenter.Segment("研究生命起源")
+	want = []string{"研究生", "命", "起源"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Segment after reweighting = %v, want %v", got, want)
+	}
+}
+
+func TestSegmenterMixedText(t *testing.T) {
+	segmenter := NewSegmenter(nil)
+	segmenter.AddWords([]string{"喜歡", "吃飯"}, 10)
+
+	got := segmenter.Segment("我喜歡吃飯，LINE bot 123！")
+	want := []string{"我", "喜歡", "吃飯", "，", "LINE", "bot", "123", "！"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Segment = %v, want %v", got, want)
+	}
+}
+
+func TestSegmenterBaseDictionary(t *testing.T) {
+	base := NewSegmenter(nil)
+	if err := base.LoadDictionary(strings.NewReader("# 一般詞典\n森林 50 n\n大象\n\n公園 30\n")); err != nil {
+		t.Fatalf("LoadDictionary: %v", err)
+	}
+	if !base.Contains("大象") || base.Contains("動物") {
+		t.Errorf("Contains mismatch after LoadDictionary")
+	}
+
+	// 上層加入的詞優先，同時仍可使用 base 的詞
+	segmenter := NewSegmenter(base)
+	segmenter.AddWord("動物園", lessonWordFrequency)
+	got := segmenter.Segment("大象在動物園和森林")
+	want := []string{"大象", "在", "動物園", "和", "森林"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Segment = %v, want %v", got, want)
+	}
+}
+
+func TestSegmentWords(t *testing.T) {
+	got := SegmentWords("小明喜歡散步", []string{"小明", "散步"})
+	want := []string{"小明", "喜", "歡", "散步"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SegmentWords = %v, want %v", got, want)
+	}
+}
+
+// 基準測試用的詞典與文章
+func benchmarkSegmenter() *Segmenter {
+	segmenter := NewSegmenter(nil)
+	segmenter.AddWords([]string{
+		"小明", "喜歡", "森林", "散步", "早上", "陽光", "照在", "樹葉", "上面", "鳥兒",
+		"唱歌", "我們", "一起", "公園", "大象", "動物園", "老師", "同學", "學校", "下課",
+	}, lessonWordFrequency)
+	return segmenter
+}
+
+var benchmarkPassage = strings.Repeat("早上的陽光照在樹葉上面，小明喜歡和同學一起到森林散步，聽鳥兒唱歌。", 20)
+
+func BenchmarkSegmentSentence(b *testing.B) {
+	segmenter := benchmarkSegmenter()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		segmenter.Segment("小明喜歡在森林裡散步")
+	}
+}
+
+func BenchmarkSegmentPassage(b *testing.B) {
+	segmenter := benchmarkSegmenter()
+	b.SetBytes(int64(len(benchmarkPassage)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		segmenter.Segment(benchmarkPassage)
+	}
+}
+
+func BenchmarkSegmentWithBase(b *testing.B) {
+	base := benchmarkSegmenter()
+	segmenter := NewSegmenter(base)
+	segmenter.AddWords([]string{"聽鳥兒唱歌", "樹葉上面"}, lessonWordFrequency)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		segmenter.Segment(benchmarkPassage)
+	}
+}
@@ -0,0 +1,460 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"math"
+	"sort"
+
+	"chinese-learning-linebot/models"
+)
+
+// 筆順資料的座標範圍
+const (
+	strokeDataSize     = 1024.0
+	strokeDataBaseline = 900.0
+)
+
+// 筆順動畫的設定
+const (
+	strokeAnimationSize      = 240
+	strokeFramesPerStroke    = 6
+	strokeFrameDelay         = 8   // 每格 0.08 秒
+	strokeFinalFrameDelay    = 150 // 寫完後停 1.5 秒
+	strokeRevealRadius       = 70.0
+	strokeStepCellSize       = 120
+	strokeStepColumns        = 5
+	strokeStepCellGap        = 6
+	strokeCoverageSubsamples = 4
+)
+
+var (
+	strokeBackgroundColor = color.RGBA{255, 255, 255, 255}
+	strokeGuideColor      = color.RGBA{225, 225, 225, 255}
+	strokePendingColor    = color.RGBA{215, 215, 215, 255}
+	strokeDoneColor       = color.RGBA{34, 34, 34, 255}
+	strokeCurrentColor    = color.RGBA{211, 47, 47, 255}
+)
+
+// RenderStrokeStepsPNG 產生筆順分解圖：每一格多寫一筆，新的一筆以紅色標示
+func RenderStrokeStepsPNG(data *models.StrokeData) ([]byte, error) {
+	strokes, err := parseStrokePolygons(data)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := strokeStepColumns
+	if len(strokes) < columns {
+		columns = len(strokes)
+	}
+	rows := (len(strokes) + columns - 1) / columns
+	width := columns*strokeStepCellSize + (columns+1)*strokeStepCellGap
+	height := rows*strokeStepCellSize + (rows+1)*strokeStepCellGap
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(canvas, canvas.Bounds(), strokeBackgroundColor)
+
+	masks := rasterizeStrokes(strokes, strokeStepCellSize)
+	for step := range strokes {
+		originX := strokeStepCellGap + (step%columns)*(strokeStepCellSize+strokeStepCellGap)
+		originY := strokeStepCellGap + (step/columns)*(strokeStepCellSize+strokeStepCellGap)
+		cell := image.Rect(originX, originY, originX+strokeStepCellSize, originY+strokeStepCellSize)
+		drawStrokeGuides(canvas, cell)
+		for index, mask := range masks {
+			switch {
+			case index < step:
+				blendMask(canvas, cell.Min, mask, nil, strokeDoneColor)
+			case index == step:
+				blendMask(canvas, cell.Min, mask, nil, strokeCurrentColor)
+			default:
+				blendMask(canvas, cell.Min, mask, nil, strokePendingColor)
+			}
+		}
+	}
+
+	return encodePNG(canvas)
+}
+
+// RenderStrokeAnimationGIF 產生筆順動畫：依中線方向逐筆寫出，寫完後停留再重播
+func RenderStrokeAnimationGIF(data *models.StrokeData) ([]byte, error) {
+	strokes, err := parseStrokePolygons(data)
+	if err != nil {
+		return nil, err
+	}
+
+	size := strokeAnimationSize
+	bounds := image.Rect(0, 0, size, size)
+	masks := rasterizeStrokes(strokes, size)
+	scale := float64(size) / strokeDataSize
+
+	base := image.NewRGBA(bounds)
+	fillRect(base, bounds, strokeBackgroundColor)
+	drawStrokeGuides(base, bounds)
+	for _, mask := range masks {
+		blendMask(base, bounds.Min, mask, nil, strokePendingColor)
+	}
+
+	palette := strokePalette()
+	animation := &gif.GIF{}
+	addFrame := func(frame *image.RGBA, delay int) {
+		paletted := image.NewPaletted(bounds, palette)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				paletted.SetColorIndex(x, y, uint8(palette.Index(frame.RGBAAt(x, y))))
+			}
+		}
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, delay)
+	}
+
+	addFrame(base, strokeFrameDelay*3)
+	for index, mask := range masks {
+		median := toCanvasPoints(data.Median(index), scale)
+		length := polylineLength(median)
+		for step := 1; step <= strokeFramesPerStroke; step++ {
+			frame := cloneRGBA(base)
+			if step == strokeFramesPerStroke || len(median) < 2 {
+				blendMask(frame, bounds.Min, mask, nil, strokeCurrentColor)
+			} else {
+				prefix := polylinePrefix(median, length*float64(step)/strokeFramesPerStroke)
+				reveal := func(x, y int) bool {
+					return distanceToPolyline(PathPoint{float64(x) + 0.5, float64(y) + 0.5}, prefix) <= strokeRevealRadius*scale
+				}
+				blendMask(frame, bounds.Min, mask, reveal, strokeCurrentColor)
+			}
+			addFrame(frame, strokeFrameDelay)
+		}
+		blendMask(base, bounds.Min, mask, nil, strokeDoneColor)
+	}
+	addFrame(base, strokeFinalFrameDelay)
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		return nil, fmt.Errorf("failed to encode stroke animation: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderStrokeHighlightPNG 產生整個字，並以紅色標示第 index 筆（從 0 開始）
+func RenderStrokeHighlightPNG(data *models.StrokeData, index int) ([]byte, error) {
+	strokes, err := parseStrokePolygons(data)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(strokes) {
+		return nil, fmt.Errorf("stroke %d out of range", index+1)
+	}
+
+	size := strokeAnimationSize
+	bounds := image.Rect(0, 0, size, size)
+	canvas := image.NewRGBA(bounds)
+	fillRect(canvas, bounds, strokeBackgroundColor)
+	drawStrokeGuides(canvas, bounds)
+	for i, mask := range rasterizeStrokes(strokes, size) {
+		c := strokeDoneColor
+		if i == index {
+			c = strokeCurrentColor
+		}
+		blendMask(canvas, bounds.Min, mask, nil, c)
+	}
+	return encodePNG(canvas)
+}
+
+// RenderNextStrokeChoicesPNG 產生「下一筆是哪一個」的選項圖：
+// 每格都畫出前 written 筆，再以紅色畫出一個候選筆畫（candidates 為筆畫索引，從 0 開始），格子下方標示選項編號
+func RenderNextStrokeChoicesPNG(data *models.StrokeData, written int, candidates []int) ([]byte, error) {
+	strokes, err := parseStrokePolygons(data)
+	if err != nil {
+		return nil, err
+	}
+	if written < 0 || written > len(strokes) || len(candidates) == 0 {
+		return nil, fmt.Errorf("invalid next stroke choices")
+	}
+	for _, candidate := range candidates {
+		if candidate < 0 || candidate >= len(strokes) {
+			return nil, fmt.Errorf("stroke %d out of range", candidate+1)
+		}
+	}
+
+	const labelHeight = 30
+	cell := strokeStepCellSize
+	width := len(candidates)*cell + (len(candidates)+1)*strokeStepCellGap
+	height := cell + labelHeight + 2*strokeStepCellGap
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(canvas, canvas.Bounds(), strokeBackgroundColor)
+
+	masks := rasterizeStrokes(strokes, cell)
+	for i, candidate := range candidates {
+		originX := strokeStepCellGap + i*(cell+strokeStepCellGap)
+		rect := image.Rect(originX, strokeStepCellGap, originX+cell, strokeStepCellGap+cell)
+		drawStrokeGuides(canvas, rect)
+		for j := 0; j < written; j++ {
+			blendMask(canvas, rect.Min, masks[j], nil, strokeDoneColor)
+		}
+		blendMask(canvas, rect.Min, masks[candidate], nil, strokeCurrentColor)
+		drawDigit(canvas, originX+cell/2, rect.Max.Y+labelHeight/2, i+1, strokeDoneColor)
+	}
+	return encodePNG(canvas)
+}
+
+func encodePNG(canvas image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// 5×7 點陣數字，用來標示選項編號
+var digitBitmaps = [10][7]string{
+	{"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	{"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	{"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	{"11110", "00001", "00001", "01110", "00001", "00001", "11110"},
+	{"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	{"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	{"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	{"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	{"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	{"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+}
+
+// 以 (centerX, centerY) 為中心畫一位數字
+func drawDigit(canvas *image.RGBA, centerX, centerY, digit int, c color.RGBA) {
+	const scale = 3
+	bitmap := digitBitmaps[digit%10]
+	left := centerX - 5*scale/2
+	top := centerY - 7*scale/2
+	for row, line := range bitmap {
+		for column, bit := range line {
+			if bit != '1' {
+				continue
+			}
+			fillRect(canvas, image.Rect(left+column*scale, top+row*scale, left+(column+1)*scale, top+(row+1)*scale), c)
+		}
+	}
+}
+
+func parseStrokePolygons(data *models.StrokeData) ([][][]PathPoint, error) {
+	if data == nil || data.StrokeCount() == 0 {
+		return nil, fmt.Errorf("no stroke data")
+	}
+	strokes := make([][][]PathPoint, 0, data.StrokeCount())
+	for index, path := range data.Strokes {
+		polygons, err := ParseSVGPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stroke %d: %w", index+1, err)
+		}
+		strokes = append(strokes, polygons)
+	}
+	return strokes, nil
+}
+
+// 將每一筆轉為 size×size 的覆蓋率遮罩（0 到 1，有反鋸齒）
+func rasterizeStrokes(strokes [][][]PathPoint, size int) [][]float64 {
+	scale := float64(size) / strokeDataSize
+	masks := make([][]float64, 0, len(strokes))
+	for _, polygons := range strokes {
+		var canvasPolygons [][]PathPoint
+		for _, polygon := range polygons {
+			canvasPolygons = append(canvasPolygons, toCanvasPathPoints(polygon, scale))
+		}
+		masks = append(masks, rasterizePolygons(canvasPolygons, size))
+	}
+	return masks
+}
+
+// 掃描線填滿多邊形（非零環繞規則），每列取數條次掃描線以計算覆蓋率
+func rasterizePolygons(polygons [][]PathPoint, size int) []float64 {
+	type crossing struct {
+		x         float64
+		direction int
+	}
+
+	mask := make([]float64, size*size)
+	weight := 1.0 / strokeCoverageSubsamples
+	for y := 0; y < size; y++ {
+		for sub := 0; sub < strokeCoverageSubsamples; sub++ {
+			scanY := float64(y) + (float64(sub)+0.5)*weight
+			var crossings []crossing
+			for _, polygon := range polygons {
+				for i := range polygon {
+					a := polygon[i]
+					b := polygon[(i+1)%len(polygon)]
+					if (a.Y <= scanY) == (b.Y <= scanY) {
+						continue
+					}
+					x := a.X + (scanY-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+					direction := 1
+					if b.Y < a.Y {
+						direction = -1
+					}
+					crossings = append(crossings, crossing{x, direction})
+				}
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding := 0
+			for i, c := range crossings {
+				winding += c.direction
+				if winding != 0 && i+1 < len(crossings) {
+					addCoverageSpan(mask[y*size:(y+1)*size], c.x, crossings[i+1].x, weight)
+				}
+			}
+		}
+	}
+	for i, value := range mask {
+		mask[i] = math.Min(1, value)
+	}
+	return mask
+}
+
+// 在一列中加上 [x0, x1) 的覆蓋率，頭尾不足一格的部分依比例計算
+func addCoverageSpan(row []float64, x0, x1, weight float64) {
+	x0 = math.Max(0, x0)
+	x1 = math.Min(float64(len(row)), x1)
+	for x0 < x1 {
+		pixel := int(x0)
+		end := math.Min(x1, float64(pixel+1))
+		row[pixel] += (end - x0) * weight
+		x0 = end
+	}
+}
+
+// 以遮罩將顏色疊到畫布上；reveal 不為 nil 時只畫 reveal 回傳 true 的像素
+func blendMask(canvas *image.RGBA, origin image.Point, mask []float64, reveal func(x, y int) bool, c color.RGBA) {
+	size := int(math.Sqrt(float64(len(mask))))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			alpha := mask[y*size+x]
+			if alpha <= 0 || (reveal != nil && !reveal(x, y)) {
+				continue
+			}
+			base := canvas.RGBAAt(origin.X+x, origin.Y+y)
+			canvas.SetRGBA(origin.X+x, origin.Y+y, color.RGBA{
+				R: blendChannel(base.R, c.R, alpha),
+				G: blendChannel(base.G, c.G, alpha),
+				B: blendChannel(base.B, c.B, alpha),
+				A: 255,
+			})
+		}
+	}
+}
+
+func blendChannel(base, top uint8, alpha float64) uint8 {
+	return uint8(math.Round(float64(base)*(1-alpha) + float64(top)*alpha))
+}
+
+// 田字格輔助線與外框
+func drawStrokeGuides(canvas *image.RGBA, cell image.Rectangle) {
+	midX := (cell.Min.X + cell.Max.X) / 2
+	midY := (cell.Min.Y + cell.Max.Y) / 2
+	for x := cell.Min.X; x < cell.Max.X; x++ {
+		canvas.SetRGBA(x, cell.Min.Y, strokeGuideColor)
+		canvas.SetRGBA(x, cell.Max.Y-1, strokeGuideColor)
+		if (x-cell.Min.X)%6 < 3 {
+			canvas.SetRGBA(x, midY, strokeGuideColor)
+		}
+	}
+	for y := cell.Min.Y; y < cell.Max.Y; y++ {
+		canvas.SetRGBA(cell.Min.X, y, strokeGuideColor)
+		canvas.SetRGBA(cell.Max.X-1, y, strokeGuideColor)
+		if (y-cell.Min.Y)%6 < 3 {
+			canvas.SetRGBA(midX, y, strokeGuideColor)
+		}
+	}
+}
+
+func fillRect(canvas *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			canvas.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func cloneRGBA(src *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	copy(dst.Pix, src.Pix)
+	return dst
+}
+
+// GIF 調色盤：白色到各種筆畫顏色的漸層，讓反鋸齒邊緣仍然平滑
+func strokePalette() color.Palette {
+	palette := color.Palette{strokeBackgroundColor, strokeGuideColor}
+	for _, c := range []color.RGBA{strokePendingColor, strokeDoneColor, strokeCurrentColor} {
+		for level := 1; level <= 16; level++ {
+			alpha := float64(level) / 16
+			palette = append(palette, color.RGBA{
+				R: blendChannel(strokeBackgroundColor.R, c.R, alpha),
+				G: blendChannel(strokeBackgroundColor.G, c.G, alpha),
+				B: blendChannel(strokeBackgroundColor.B, c.B, alpha),
+				A: 255,
+			})
+		}
+	}
+	return palette
+}
+
+// 筆順資料座標轉為畫布座標（y 軸翻轉）
+func toCanvasPathPoints(points []PathPoint, scale float64) []PathPoint {
+	result := make([]PathPoint, len(points))
+	for i, p := range points {
+		result[i] = PathPoint{X: p.X * scale, Y: (strokeDataBaseline - p.Y) * scale}
+	}
+	return result
+}
+
+func toCanvasPoints(points [][]float64, scale float64) []PathPoint {
+	var result []PathPoint
+	for _, p := range points {
+		if len(p) >= 2 {
+			result = append(result, PathPoint{X: p[0] * scale, Y: (strokeDataBaseline - p[1]) * scale})
+		}
+	}
+	return result
+}
+
+func polylineLength(points []PathPoint) float64 {
+	length := 0.0
+	for i := 1; i < len(points); i++ {
+		length += math.Hypot(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y)
+	}
+	return length
+}
+
+// 折線從起點算起長度為 length 的部分
+func polylinePrefix(points []PathPoint, length float64) []PathPoint {
+	prefix := []PathPoint{points[0]}
+	for i := 1; i < len(points); i++ {
+		segment := math.Hypot(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y)
+		if segment >= length {
+			t := 0.0
+			if segment > 0 {
+				t = length / segment
+			}
+			return append(prefix, PathPoint{
+				X: points[i-1].X + (points[i].X-points[i-1].X)*t,
+				Y: points[i-1].Y + (points[i].Y-points[i-1].Y)*t,
+			})
+		}
+		length -= segment
+		prefix = append(prefix, points[i])
+	}
+	return prefix
+}
+
+func distanceToPolyline(p PathPoint, points []PathPoint) float64 {
+	if len(points) == 1 {
+		return math.Hypot(p.X-points[0].X, p.Y-points[0].Y)
+	}
+	distance := math.Inf(1)
+	for i := 1; i < len(points); i++ {
+		distance = math.Min(distance, distanceToSegment(p, points[i-1], points[i]))
+	}
+	return distance
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"bytes"
+	"image/gif"
+	"image/png"
+	"math"
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+// 兩筆的測試資料：左上與右下各一個方塊（筆順資料座標，y 軸向上）
+func testStrokeData() *models.StrokeData {
+	return &models.StrokeData{
+		Character: "二",
+		Strokes: []string{
+			"M 100 800 L 400 800 L 400 500 L 100 500 Z",
+			"M 600 300 L 900 300 L 900 0 L 600 0 Z",
+		},
+		Medians: [][][]float64{
+			{{100, 650}, {400, 650}},
+			{{600, 150}, {900, 150}},
+		},
+	}
+}
+
+func TestRasterizePolygons(t *testing.T) {
+	mask := rasterizePolygons([][]PathPoint{{{2, 2}, {6, 2}, {6, 6}, {2, 6}}}, 8)
+	at := func(x, y int) float64 { return mask[y*8+x] }
+	if at(3, 3) != 1 || at(5, 5) != 1 {
+		t.Errorf("inside coverage = %v, %v; want 1", at(3, 3), at(5, 5))
+	}
+	if at(0, 0) != 0 || at(7, 3) != 0 || at(3, 7) != 0 {
+		t.Errorf("outside pixels are covered")
+	}
+
+	// 半格邊界的覆蓋率約為一半
+	half := rasterizePolygons([][]PathPoint{{{0, 0}, {2.5, 0}, {2.5, 4}, {0, 4}}}, 4)
+	if math.Abs(half[1*4+2]-0.5) > 1e-9 {
+		t.Errorf("edge coverage = %v, want 0.5", half[1*4+2])
+	}
+}
+
+func TestPolylinePrefix(t *testing.T) {
+	line := []PathPoint{{0, 0}, {10, 0}, {10, 10}}
+	if length := polylineLength(line); length != 20 {
+		t.Fatalf("polylineLength = %v, want 20", length)
+	}
+	prefix := polylinePrefix(line, 15)
+	if end := prefix[len(prefix)-1]; end != (PathPoint{10, 5}) {
+		t.Errorf("prefix end = %v, want (10, 5)", end)
+	}
+	if distance := distanceToPolyline(PathPoint{5, 3}, line); distance != 3 {
+		t.Errorf("distanceToPolyline = %v, want 3", distance)
+	}
+}
+
+func TestRenderStrokeStepsPNG(t *testing.T) {
+	data, err := RenderStrokeStepsPNG(testStrokeData())
+	if err != nil {
+		t.Fatalf("RenderStrokeStepsPNG: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// 兩筆排成一列兩格
+	wantWidth := 2*strokeStepCellSize + 3*strokeStepCellGap
+	wantHeight := strokeStepCellSize + 2*strokeStepCellGap
+	if bounds := img.Bounds(); bounds.Dx() != wantWidth || bounds.Dy() != wantHeight {
+		t.Errorf("image size = %v, want %dx%d", bounds.Size(), wantWidth, wantHeight)
+	}
+
+	if _, err := RenderStrokeStepsPNG(&models.StrokeData{Character: "一"}); err == nil {
+		t.Errorf("rendering without strokes succeeded, want error")
+	}
+}
+
+func TestRenderStrokeAnimationGIF(t *testing.T) {
+	data, err := RenderStrokeAnimationGIF(testStrokeData())
+	if err != nil {
+		t.Fatalf("RenderStrokeAnimationGIF: %v", err)
+	}
+	animation, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// 開頭一格、每筆數格、結尾停留一格
+	if want := 2 + 2*strokeFramesPerStroke; len(animation.Image) != want {
+		t.Errorf("got %d frames, want %d", len(animation.Image), want)
+	}
+	if last := animation.Delay[len(animation.Delay)-1]; last != strokeFinalFrameDelay {
+		t.Errorf("final frame delay = %d, want %d", last, strokeFinalFrameDelay)
+	}
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"unicode"
+)
+
+// PathPoint 平面上的點
+type PathPoint struct {
+	X, Y float64
+}
+
+// 每段曲線以幾條線段近似
+const svgCurveSegments = 12
+
+// ParseSVGPath 將 SVG path 轉為多邊形（曲線以線段近似）
+// 支援 M、L、H、V、Q、C、Z 及對應的小寫相對座標指令，足以處理筆順資料的輪廓
+func ParseSVGPath(d string) ([][]PathPoint, error) {
+	tokens, err := tokenizeSVGPath(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var polygons [][]PathPoint
+	var current []PathPoint
+	var position, start PathPoint
+	command := byte(0)
+
+	closePolygon := func() {
+		if len(current) > 2 {
+			polygons = append(polygons, current)
+		}
+		current = nil
+	}
+
+	for i := 0; i < len(tokens); {
+		if tokens[i].command != 0 {
+			command = tokens[i].command
+			i++
+			if command == 'Z' || command == 'z' {
+				position = start
+				closePolygon()
+				continue
+			}
+		}
+		if command == 0 {
+			return nil, fmt.Errorf("path must start with a command")
+		}
+
+		argCount := map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'Q': 4, 'C': 6}[byte(unicode.ToUpper(rune(command)))]
+		if argCount == 0 {
+			return nil, fmt.Errorf("unsupported path command %q", command)
+		}
+		args := make([]float64, argCount)
+		for j := range args {
+			if i >= len(tokens) || tokens[i].command != 0 {
+				return nil, fmt.Errorf("missing arguments for path command %q", command)
+			}
+			args[j] = tokens[i].value
+			i++
+		}
+
+		relative := unicode.IsLower(rune(command))
+		point := func(x, y float64) PathPoint {
+			if relative {
+				return PathPoint{position.X + x, position.Y + y}
+			}
+			return PathPoint{x, y}
+		}
+
+		switch unicode.ToUpper(rune(command)) {
+		case 'M':
+			closePolygon()
+			position = point(args[0], args[1])
+			start = position
+			current = []PathPoint{position}
+			// M 之後的座標視為 L
+			if relative {
+				command = 'l'
+			} else {
+				command = 'L'
+			}
+		case 'L':
+			position = point(args[0], args[1])
+			current = append(current, position)
+		case 'H':
+			if relative {
+				position.X += args[0]
+			} else {
+				position.X = args[0]
+			}
+			current = append(current, position)
+		case 'V':
+			if relative {
+				position.Y += args[0]
+			} else {
+				position.Y = args[0]
+			}
+			current = append(current, position)
+		case 'Q':
+			control := point(args[0], args[1])
+			end := point(args[2], args[3])
+			for step := 1; step <= svgCurveSegments; step++ {
+				t := float64(step) / svgCurveSegments
+				current = append(current, quadraticBezier(position, control, end, t))
+			}
+			position = end
+		case 'C':
+			control1 := point(args[0], args[1])
+			control2 := point(args[2], args[3])
+			end := point(args[4], args[5])
+			for step := 1; step <= svgCurveSegments; step++ {
+				t := float64(step) / svgCurveSegments
+				current = append(current, cubicBezier(position, control1, control2, end, t))
+			}
+			position = end
+		}
+	}
+	closePolygon()
+	return polygons, nil
+}
+
+type svgPathToken struct {
+	command byte
+	value   float64
+}
+
+func tokenizeSVGPath(d string) ([]svgPathToken, error) {
+	var tokens []svgPathToken
+	for i := 0; i < len(d); {
+		c := d[i]
+		switch {
+		case c == ' ' || c == ',' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case (c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z') && c != 'e' && c != 'E':
+			tokens = append(tokens, svgPathToken{command: c})
+			i++
+		default:
+			end := i + 1
+			for end < len(d) {
+				next := d[end]
+				if next >= '0' && next <= '9' || next == '.' || next == 'e' || next == 'E' ||
+					(next == '-' || next == '+') && (d[end-1] == 'e' || d[end-1] == 'E') {
+					end++
+					continue
+				}
+				break
+			}
+			value, err := strconv.ParseFloat(d[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q in path", d[i:end])
+			}
+			tokens = append(tokens, svgPathToken{value: value})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+func quadraticBezier(p0, p1, p2 PathPoint, t float64) PathPoint {
+	u := 1 - t
+	return PathPoint{
+		X: u*u*p0.X + 2*u*t*p1.X + t*t*p2.X,
+		Y: u*u*p0.Y + 2*u*t*p1.Y + t*t*p2.Y,
+	}
+}
+
+func cubicBezier(p0, p1, p2, p3 PathPoint, t float64) PathPoint {
+	u := 1 - t
+	return PathPoint{
+		X: u*u*u*p0.X + 3*u*u*t*p1.X + 3*u*t*t*p2.X + t*t*t*p3.X,
+		Y: u*u*u*p0.Y + 3*u*u*t*p1.Y + 3*u*t*t*p2.Y + t*t*t*p3.Y,
+	}
+}
+
+// 點到線段的距離
+func distanceToSegment(p, a, b PathPoint) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / lengthSquared
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.X-(a.X+t*dx), p.Y-(a.Y+t*dy))
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseSVGPath(t *testing.T) {
+	square := []PathPoint{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	tests := []struct {
+		name string
+		path string
+		want [][]PathPoint
+	}{
+		{"absolute", "M 0 0 L 10 0 L 10 10 L 0 10 Z", [][]PathPoint{square}},
+		{"relative with H and V", "m 0 0 h 10 v 10 h -10 z", [][]PathPoint{square}},
+		{"implicit lineto after M", "M0,0 10,0 10,10 0,10Z", [][]PathPoint{square}},
+		{"compact negative numbers", "M10-5L20-5L20 5Z", [][]PathPoint{{{10, -5}, {20, -5}, {20, 5}}}},
+		{"two subpaths", "M0 0L10 0L10 10Z M20 0L30 0L30 10Z", [][]PathPoint{{{0, 0}, {10, 0}, {10, 10}}, {{20, 0}, {30, 0}, {30, 10}}}},
+		// 不足三點的路徑不構成多邊形
+		{"degenerate", "M0 0L10 0Z", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSVGPath(tt.path)
+		if err != nil {
+			t.Errorf("%s: ParseSVGPath error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseSVGPath = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseSVGPathCurves(t *testing.T) {
+	polygons, err := ParseSVGPath("M 0 0 Q 50 100 100 0 C 100 -50 0 -50 0 0 Z")
+	if err != nil {
+		t.Fatalf("ParseSVGPath: %v", err)
+	}
+	points := polygons[0]
+	if len(points) != 1+2*svgCurveSegments {
+		t.Fatalf("got %d points, want %d", len(points), 1+2*svgCurveSegments)
+	}
+	// 二次曲線的中點為 (50, 50)，終點為 (100, 0)
+	middle := points[svgCurveSegments/2]
+	if math.Abs(middle.X-50) > 1e-9 || math.Abs(middle.Y-50) > 1e-9 {
+		t.Errorf("curve midpoint = %v, want (50, 50)", middle)
+	}
+	if end := points[svgCurveSegments]; end != (PathPoint{100, 0}) {
+		t.Errorf("curve end = %v, want (100, 0)", end)
+	}
+}
+
+func TestParseSVGPathErrors(t *testing.T) {
+	for _, path := range []string{"10 10", "M 0", "M 0 0 A 1 1 0 0 1 2 2", "M 0 0 L 1..2 3"} {
+		if _, err := ParseSVGPath(path); err == nil {
+			t.Errorf("ParseSVGPath(%q) succeeded, want error", path)
+		}
+	}
+}
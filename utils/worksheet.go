@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"chinese-learning-linebot/models"
+)
+
+// 字帖版面（單位：點）
+const (
+	worksheetMargin      = 36.0
+	worksheetTitleHeight = 40.0
+	worksheetFooterSize  = 20.0
+	worksheetCellSize    = 46.0
+	worksheetRowGap      = 8.0
+	worksheetZhuyinWidth = 22.0
+	worksheetCellsPerRow = 10
+)
+
+// 字帖灰階：範字、描紅字、外框、輔助線
+const (
+	worksheetModelGray = 0.0
+	worksheetTraceGray = 0.78
+	worksheetFrameGray = 0.35
+	worksheetGuideGray = 0.7
+)
+
+var (
+	worksheetTracePattern       = regexp.MustCompile(`^描紅?(\d+)格$`)
+	worksheetLessonPattern      = regexp.MustCompile(`^第?(\d+)課$`)
+	worksheetLessonRangePattern = regexp.MustCompile(`^第?(\d+)\s*[-~到至]\s*第?(\d+)課$`)
+)
+
+// ParseWorksheetOptions 解析字帖選項，例如「米字格 描紅5格 不要注音」
+// 以空白分隔，無法辨識的條件原樣放在 rest 中回傳
+func ParseWorksheetOptions(text string) (options models.WorksheetOptions, rest []string) {
+	options = models.DefaultWorksheetOptions()
+	for _, token := range strings.Fields(text) {
+		switch {
+		case token == "田字格":
+			options.Grid = models.WorksheetGridTian
+		case token == "米字格":
+			options.Grid = models.WorksheetGridMi
+		case token == "不要注音" || token == "無注音" || token == "不標注音":
+			options.Zhuyin = false
+		case token == "注音" || token == "標注音":
+			options.Zhuyin = true
+		case worksheetTracePattern.MatchString(token):
+			options.TraceCells, _ = strconv.Atoi(worksheetTracePattern.FindStringSubmatch(token)[1])
+		default:
+			rest = append(rest, token)
+		}
+	}
+	return options, rest
+}
+
+// ParseWorksheetSelection 解析字帖內容，例如「第5課」、「第3-5課」、「生字」、「複習」
+// 無法辨識的條件原樣放在 rest 中回傳
+func ParseWorksheetSelection(tokens []string) (selection models.WorksheetSelection, rest []string) {
+	for _, token := range tokens {
+		switch {
+		case token == "生字" || token == "未學" || token == "查詢生字":
+			selection = models.WorksheetSelection{Source: models.WorksheetSourceUnlearned}
+		case token == "複習" || token == "待複習":
+			selection = models.WorksheetSelection{Source: models.WorksheetSourceReview}
+		case worksheetLessonPattern.MatchString(token):
+			lesson, _ := strconv.Atoi(worksheetLessonPattern.FindStringSubmatch(token)[1])
+			selection = models.WorksheetSelection{Source: models.WorksheetSourceLesson, FromLesson: lesson, ToLesson: lesson}
+		case worksheetLessonRangePattern.MatchString(token):
+			m := worksheetLessonRangePattern.FindStringSubmatch(token)
+			from, _ := strconv.Atoi(m[1])
+			to, _ := strconv.Atoi(m[2])
+			if from > to {
+				from, to = to, from
+			}
+			selection = models.WorksheetSelection{Source: models.WorksheetSourceLesson, FromLesson: from, ToLesson: to}
+		default:
+			rest = append(rest, token)
+		}
+	}
+	return selection, rest
+}
+
+// WorksheetRowsPerPage 每頁可放的字數（每個字一行）
+func WorksheetRowsPerPage() int {
+	available := PDFPageHeightA4 - worksheetMargin*2 - worksheetTitleHeight - worksheetFooterSize
+	return int((available + worksheetRowGap) / (worksheetCellSize + worksheetRowGap))
+}
+
+// RenderWorksheetPDF 產生 A4 字帖：每行第一格為範字，接著是描紅格與空白格，範字旁可標注音
+func RenderWorksheetPDF(title string, entries []models.WorksheetEntry, options models.WorksheetOptions) ([]byte, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("worksheet has no characters")
+	}
+	traceCells := options.TraceCells
+	if traceCells < 0 {
+		traceCells = 0
+	}
+	if traceCells > worksheetCellsPerRow-1 {
+		traceCells = worksheetCellsPerRow - 1
+	}
+
+	rowWidth := worksheetZhuyinWidth + worksheetCellSize*worksheetCellsPerRow
+	left := (PDFPageWidthA4 - rowWidth) / 2
+	rowsPerPage := WorksheetRowsPerPage()
+	totalPages := (len(entries) + rowsPerPage - 1) / rowsPerPage
+
+	doc := NewPDFDocument(PDFPageWidthA4, PDFPageHeightA4)
+	for pageIndex := 0; pageIndex < totalPages; pageIndex++ {
+		page := doc.AddPage()
+		top := PDFPageHeightA4 - worksheetMargin
+
+		page.SetFillGray(worksheetModelGray)
+		page.Text(left, top-16, 16, title)
+		page.Text(left+rowWidth-150, top-16, 10, "姓名：＿＿＿＿＿＿＿")
+		footer := fmt.Sprintf("第 %d / %d 頁", pageIndex+1, totalPages)
+		page.Text(PDFPageWidthA4/2-25, worksheetMargin, 9, footer)
+
+		start := pageIndex * rowsPerPage
+		end := start + rowsPerPage
+		if end > len(entries) {
+			end = len(entries)
+		}
+		for row, entry := range entries[start:end] {
+			cellY := top - worksheetTitleHeight - float64(row+1)*worksheetCellSize - float64(row)*worksheetRowGap
+			cellsX := left + worksheetZhuyinWidth
+
+			if options.Zhuyin && entry.Phonetic != "" {
+				page.SetFillGray(worksheetModelGray)
+				drawWorksheetZhuyin(page, left, cellY, entry.Phonetic)
+			}
+
+			for column := 0; column < worksheetCellsPerRow; column++ {
+				cellX := cellsX + float64(column)*worksheetCellSize
+				drawWorksheetCell(page, cellX, cellY, options.Grid)
+				switch {
+				case column == 0:
+					page.SetFillGray(worksheetModelGray)
+					drawWorksheetCharacter(page, cellX, cellY, entry.Character)
+				case column <= traceCells:
+					page.SetFillGray(worksheetTraceGray)
+					drawWorksheetCharacter(page, cellX, cellY, entry.Character)
+				}
+			}
+		}
+	}
+	return doc.Bytes()
+}
+
+// 畫一個格子：實線外框加虛線輔助線
+func drawWorksheetCell(page *PDFPage, x, y float64, grid string) {
+	size := worksheetCellSize
+	page.SetDash(2, 2)
+	page.SetLineWidth(0.4)
+	page.SetStrokeGray(worksheetGuideGray)
+	page.Line(x+size/2, y, x+size/2, y+size)
+	page.Line(x, y+size/2, x+size, y+size/2)
+	if grid == models.WorksheetGridMi {
+		page.Line(x, y, x+size, y+size)
+		page.Line(x, y+size, x+size, y)
+	}
+
+	page.SetDash(0, 0)
+	page.SetLineWidth(0.8)
+	page.SetStrokeGray(worksheetFrameGray)
+	page.Rect(x, y, size, size)
+}
+
+// 把字置中寫在格子裡（中文字形的下緣約在基線下 0.12 倍字級）
+func drawWorksheetCharacter(page *PDFPage, x, y float64, char string) {
+	fontSize := worksheetCellSize * 0.78
+	padding := (worksheetCellSize - fontSize) / 2
+	page.Text(x+padding, y+padding+fontSize*0.12, fontSize, char)
+}
+
+// 直式注音：符號由上往下排，聲調標在最後一個符號右側，輕聲標在最上方
+func drawWorksheetZhuyin(page *PDFPage, x, y float64, phonetic string) {
+	const symbolSize = 9.0
+	const lineHeight = 10.0
+
+	var symbols []string
+	tone := ""
+	light := false
+	for _, r := range phonetic {
+		switch {
+		case r == '˙':
+			light = true
+		case r == 'ˊ' || r == 'ˇ' || r == 'ˋ':
+			tone = string(r)
+		case r >= 'ㄅ' && r <= 'ㄩ':
+			symbols = append(symbols, string(r))
+		}
+	}
+	if len(symbols) == 0 {
+		return
+	}
+
+	blockTop := y + worksheetCellSize/2 + float64(len(symbols))*lineHeight/2
+	baseline := 0.0
+	for i, symbol := range symbols {
+		baseline = blockTop - float64(i+1)*lineHeight + 1.5
+		page.Text(x+2, baseline, symbolSize, symbol)
+	}
+	if tone != "" {
+		page.Text(x+2+symbolSize, baseline+3, symbolSize-1, tone)
+	}
+	if light {
+		page.Text(x+2, blockTop+1.5, symbolSize-1, "˙")
+	}
+}
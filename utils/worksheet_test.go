@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"chinese-learning-linebot/models"
+)
+
+func TestParseWorksheetSelection(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   models.WorksheetSelection
+		rest   []string
+	}{
+		{[]string{"第5課"}, models.WorksheetSelection{Source: models.WorksheetSourceLesson, FromLesson: 5, ToLesson: 5}, nil},
+		{[]string{"3課"}, models.WorksheetSelection{Source: models.WorksheetSourceLesson, FromLesson: 3, ToLesson: 3}, nil},
+		{[]string{"第2-4課"}, models.WorksheetSelection{Source: models.WorksheetSourceLesson, FromLesson: 2, ToLesson: 4}, nil},
+		{[]string{"第6到第3課"}, models.WorksheetSelection{Source: models.WorksheetSourceLesson, FromLesson: 3, ToLesson: 6}, nil},
+		{[]string{"生字"}, models.WorksheetSelection{Source: models.WorksheetSourceUnlearned}, nil},
+		{[]string{"待複習", "米字格"}, models.WorksheetSelection{Source: models.WorksheetSourceReview}, []string{"米字格"}},
+		{[]string{"第五課"}, models.WorksheetSelection{}, []string{"第五課"}},
+		{nil, models.WorksheetSelection{}, nil},
+	}
+
+	for _, tt := range tests {
+		got, rest := ParseWorksheetSelection(tt.tokens)
+		if got != tt.want || !reflect.DeepEqual(rest, tt.rest) {
+			t.Errorf("ParseWorksheetSelection(%q) = %+v, %q; want %+v, %q", tt.tokens, got, rest, tt.want, tt.rest)
+		}
+	}
+}
+
+func TestParseWorksheetOptions(t *testing.T) {
+	options, rest := ParseWorksheetOptions("米字格 描紅5格 不要注音 第3課")
+	want := models.WorksheetOptions{Grid: models.WorksheetGridMi, TraceCells: 5, Zhuyin: false}
+	if options != want || !reflect.DeepEqual(rest, []string{"第3課"}) {
+		t.Errorf("ParseWorksheetOptions = %+v, %q; want %+v, [第3課]", options, rest, want)
+	}
+
+	options, rest = ParseWorksheetOptions("")
+	if options != models.DefaultWorksheetOptions() || rest != nil {
+		t.Errorf("ParseWorksheetOptions(\"\") = %+v, %q; want defaults", options, rest)
+	}
+}
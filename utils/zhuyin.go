@@ -0,0 +1,108 @@
+package utils
+
+import "strings"
+
+// 指令用字及常見同音錯字的注音（不含聲調），供模糊比對指令使用
+// 以同一注音分組，方便維護
+var zhuyinGroups = map[string]string{
+	"ㄔㄚ":  "查茶差",
+	"ㄒㄩㄣ": "詢尋循",
+	"ㄌㄟ":  "累壘淚類",
+	"ㄐㄧ":  "積基機績跡級集極記紀計技",
+	"ㄗ":   "字自子",
+	"ㄘ":   "詞辭此次",
+	"ㄔㄨㄥ": "重蟲",
+	"ㄕㄜ":  "設社射",
+	"ㄆㄧㄢ": "偏篇片",
+	"ㄏㄠ":  "好號",
+	"ㄉㄧㄥ": "定訂頂",
+	"ㄑㄧㄥ": "清請輕",
+	"ㄔㄨ":  "除出初廚",
+	"ㄧ":   "憶一意億義易",
+	"ㄕ":   "使時是事始式",
+	"ㄩㄥ":  "用永",
+	"ㄓㄜ":  "者這",
+	"ㄎㄜ":  "課客科可",
+	"ㄔㄥ":  "程成城",
+	"ㄎㄢ":  "看砍",
+	"ㄨㄛ":  "我",
+	"ㄉㄜ":  "的得德",
+	"ㄧㄣ":  "印因音",
+	"ㄊㄧㄝ": "帖貼鐵",
+	"ㄆㄧㄥ": "平評瓶",
+	"ㄅㄢ":  "板版班般",
+	"ㄒㄩㄝ": "學雪",
+	"ㄒㄧㄝ": "寫謝些鞋",
+	"ㄌㄧㄢ": "練鍊煉連聯",
+	"ㄒㄧ":  "習息席系西洗",
+	"ㄓㄡ":  "週周州洲",
+	"ㄅㄠ":  "報抱寶保包",
+	"ㄨㄣ":  "文問聞",
+	"ㄅㄤ":  "幫邦棒",
+	"ㄓㄨ":  "助住注主祝",
+	"ㄕㄨㄛ": "說",
+	"ㄇㄧㄥ": "明名命",
+	"ㄊㄨㄟ": "退推腿",
+	"ㄩㄝ":  "閱月越悅",
+	"ㄇㄟ":  "每美妹",
+	"ㄖ":   "日",
+	"ㄑㄩ":  "取去區",
+	"ㄒㄧㄠ": "消銷小笑",
+	"ㄈㄨ":  "複復覆服父",
+	"ㄊㄧ":  "提題體",
+	"ㄒㄧㄥ": "醒性興星行",
+	"ㄨ":   "勿物務無五",
+	"ㄖㄠ":  "擾繞",
+	"ㄉㄨㄢ": "段斷",
+	"ㄐㄧㄚ": "佳家加",
+	"ㄑㄩㄢ": "全權泉",
+	"ㄌㄧㄝ": "列烈",
+	"ㄍㄠ":  "告高",
+	"ㄉㄨ":  "度讀都",
+	"ㄓㄢ":  "戰站",
+	"ㄓㄠ":  "找照",
+	"ㄊㄧㄠ": "挑條跳",
+	"ㄒㄧㄣ": "新心信",
+	"ㄍㄥ":  "更耕",
+	"ㄗㄨㄟ": "最嘴醉",
+	"ㄅㄣ":  "本笨",
+	"ㄕㄨ":  "束書數樹",
+	"ㄇㄛ":  "模摸魔",
+	"ㄘㄜ":  "測策冊",
+	"ㄕㄥ":  "生聲升",
+	"ㄉㄥ":  "登等燈",
+	"ㄅㄧ":  "筆必比",
+	"ㄐㄧㄝ": "結節接",
+	"ㄐㄧㄣ": "進今近",
+	"ㄌㄨ":  "錄路露",
+	"ㄎㄞ":  "開凱",
+	"ㄒㄧㄢ": "限先現線",
+	"ㄌㄧ":  "離理力裡",
+	"ㄕㄨㄣ": "順",
+	"ㄧㄢ":  "驗眼言鹽",
+}
+
+var zhuyinTable = buildZhuyinTable()
+
+func buildZhuyinTable() map[rune]string {
+	table := make(map[rune]string)
+	for syllable, chars := range zhuyinGroups {
+		for _, char := range chars {
+			table[char] = syllable
+		}
+	}
+	return table
+}
+
+// ToZhuyinSyllables 將文字逐字轉為注音（不含聲調），查不到的字保留原字
+func ToZhuyinSyllables(text string) []string {
+	var syllables []string
+	for _, char := range text {
+		if syllable, ok := zhuyinTable[char]; ok {
+			syllables = append(syllables, syllable)
+		} else {
+			syllables = append(syllables, strings.ToLower(string(char)))
+		}
+	}
+	return syllables
+}